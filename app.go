@@ -2,24 +2,32 @@ package main
 
 import (
 	"context"
+	"errors"
+	goflag "flag"
 	"fmt"
 	"os"
-	"sync"
+	"time"
 
 	"github.com/elC0mpa/aws-doctor/model"
-	awsconfig "github.com/elC0mpa/aws-doctor/service/aws/config"
-	awscostexplorer "github.com/elC0mpa/aws-doctor/service/aws/costexplorer"
-	awsec2 "github.com/elC0mpa/aws-doctor/service/aws/ec2"
-	awssts "github.com/elC0mpa/aws-doctor/service/aws/sts"
-	azurecompute "github.com/elC0mpa/aws-doctor/service/azure/compute"
-	azureconfig "github.com/elC0mpa/aws-doctor/service/azure/config"
-	azurecostmanagement "github.com/elC0mpa/aws-doctor/service/azure/costmanagement"
-	azureidentity "github.com/elC0mpa/aws-doctor/service/azure/identity"
+	"github.com/elC0mpa/aws-doctor/service"
+	"github.com/elC0mpa/aws-doctor/service/apierror"
+	"github.com/elC0mpa/aws-doctor/service/appconfig"
+	"github.com/elC0mpa/aws-doctor/service/budget"
+	"github.com/elC0mpa/aws-doctor/service/cache"
+	"github.com/elC0mpa/aws-doctor/service/chargeback"
+	"github.com/elC0mpa/aws-doctor/service/currency"
+	"github.com/elC0mpa/aws-doctor/service/email"
+	"github.com/elC0mpa/aws-doctor/service/engine"
 	"github.com/elC0mpa/aws-doctor/service/flag"
-	gcpbilling "github.com/elC0mpa/aws-doctor/service/gcp/billing"
-	gcpcompute "github.com/elC0mpa/aws-doctor/service/gcp/compute"
-	gcpidentity "github.com/elC0mpa/aws-doctor/service/gcp/identity"
+	"github.com/elC0mpa/aws-doctor/service/history"
+	"github.com/elC0mpa/aws-doctor/service/notify"
 	"github.com/elC0mpa/aws-doctor/service/orchestrator"
+	"github.com/elC0mpa/aws-doctor/service/ownership"
+	"github.com/elC0mpa/aws-doctor/service/policy"
+	"github.com/elC0mpa/aws-doctor/service/resilience"
+	"github.com/elC0mpa/aws-doctor/service/tagaudit"
+	"github.com/elC0mpa/aws-doctor/service/warnings"
+	"github.com/elC0mpa/aws-doctor/service/wastecost"
 	"github.com/elC0mpa/aws-doctor/utils"
 )
 
@@ -30,739 +38,622 @@ func main() {
 	flagService := flag.NewService()
 	flags, err := flagService.GetParsedFlags()
 	if err != nil {
-		panic(err)
-	}
-
-	switch flags.Provider {
-	case "aws":
-		err = runAWS(flags)
-	case "gcp":
-		err = runGCP(flags)
-	case "azure":
-		err = runAzure(flags)
-	case "all":
-		err = runAll(flags)
-	default:
 		utils.StopSpinner()
-		fmt.Printf("Unknown provider: %s. Supported providers: aws, gcp, azure, all\n", flags.Provider)
-		os.Exit(1)
+		if errors.Is(err, goflag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(2)
 	}
 
+	cfg, err := appconfig.NewService(flags.ConfigPath).Load()
 	if err != nil {
 		utils.StopSpinner()
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
-}
 
-func runAWS(flags model.Flags) error {
-	cfgService := awsconfig.NewService()
-	awsCfg, err := cfgService.GetAWSCfg(context.Background(), flags.Region, flags.Profile)
-	if err != nil {
-		return err
+	if flags.Currency != "" {
+		cfg.Currency.ReportingCurrency = flags.Currency
 	}
 
-	costService := awscostexplorer.NewService(awsCfg)
-	stsService := awssts.NewService(awsCfg)
-	ec2Service := awsec2.NewService(awsCfg)
+	if flags.GroupBy != "" && flags.GroupBy != model.GroupByOwner {
+		utils.StopSpinner()
+		fmt.Printf("Unknown --group-by value: %s. Supported values: %s\n", flags.GroupBy, model.GroupByOwner)
+		os.Exit(2)
+	}
 
-	orchestratorService := orchestrator.NewService(stsService, costService, ec2Service)
+	var policyService policy.PolicyService
+	if flags.FailOn != "" {
+		prices, err := wastecost.NewPrices(cfg.Metrics)
+		if err != nil {
+			utils.StopSpinner()
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 
-	return orchestratorService.Orchestrate(flags)
-}
+		policyService, err = policy.NewService(flags.FailOn, prices)
+		if err != nil {
+			utils.StopSpinner()
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(model.ExitUsage)
+		}
+	}
 
-func runGCP(flags model.Flags) error {
-	ctx := context.Background()
+	if (flags.Serve || flags.MetricsAddr != "" || flags.APIAddr != "" || flags.DashboardAddr != "") && (flags.Trend || flags.Waste || flags.TagAudit || flags.Chargeback || flags.FailOn != "" || flags.Notify || flags.SendEmail || flags.Diff) {
+		utils.StopSpinner()
+		fmt.Println("--serve, --metrics-addr, --api-addr and --dashboard-addr run until interrupted and cannot be combined with report flags")
+		os.Exit(model.ExitUsage)
+	}
 
-	// Validate required GCP flags
-	if flags.Project == "" {
+	if flags.TUI && (flags.Serve || flags.MetricsAddr != "" || flags.APIAddr != "" || flags.DashboardAddr != "" || flags.Trend || flags.Waste || flags.TagAudit || flags.Chargeback || flags.FailOn != "" || flags.Notify || flags.SendEmail || flags.Diff) {
 		utils.StopSpinner()
-		return fmt.Errorf("--project flag is required for GCP provider")
+		fmt.Println("--tui shows every report itself and cannot be combined with report or server flags")
+		os.Exit(model.ExitUsage)
 	}
 
-	if flags.BillingAccount == "" && !flags.Waste {
+	if (flags.Notify || flags.SendEmail) && (flags.Trend || flags.TagAudit || flags.Chargeback) {
 		utils.StopSpinner()
-		return fmt.Errorf("--billing-account flag is required for GCP cost analysis\n\nTo find your billing account ID:\n  gcloud billing accounts list\n\nUsage:\n  cloud-doctor --provider gcp --project PROJECT_ID --billing-account billingAccounts/XXXXXX-XXXXXX-XXXXXX")
+		fmt.Println("--notify and --send-email are only supported for cost and waste reports")
+		os.Exit(model.ExitUsage)
 	}
 
-	// Create GCP identity service
-	identityService, err := gcpidentity.NewService(ctx, flags.Project)
-	if err != nil {
-		return fmt.Errorf("failed to create GCP identity service: %w", err)
+	var notifyService notify.NotifyService
+	if flags.Notify {
+		if len(cfg.Notifications.Channels) == 0 {
+			utils.StopSpinner()
+			fmt.Println("Error: --notify needs notifications.channels in the config file")
+			os.Exit(model.ExitError)
+		}
+
+		notifyService, err = notify.NewService(cfg.Notifications)
+		if err != nil {
+			utils.StopSpinner()
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(model.ExitError)
+		}
 	}
 
-	// Handle waste detection
-	if flags.Waste {
-		// Create GCP compute service for waste detection
-		computeService, err := gcpcompute.NewService(ctx, flags.Project)
+	var emailService email.EmailService
+	if flags.SendEmail {
+		if flags.EmailEnv == "" {
+			flags.EmailEnv = model.DefaultEmailEnvironment
+		}
+		if len(cfg.Email.Recipients[flags.EmailEnv]) == 0 {
+			utils.StopSpinner()
+			fmt.Printf("Error: --send-email needs email.recipients.%s in the config file\n", flags.EmailEnv)
+			os.Exit(model.ExitError)
+		}
+
+		emailService, err = email.NewService(cfg.Email, cfg.Notifications.TopMovers)
 		if err != nil {
-			return fmt.Errorf("failed to create GCP compute service: %w", err)
+			utils.StopSpinner()
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(model.ExitError)
 		}
+	}
 
-		// Create orchestrator with identity and compute services (no billing needed)
-		orchestratorService := orchestrator.NewService(identityService, nil, computeService)
-		return orchestratorService.Orchestrate(flags)
+	resiliencePolicy, err := resilience.NewPolicy(cfg.Resilience, flags.Timeout)
+	if err != nil {
+		utils.StopSpinner()
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := budget.Validate(cfg.Budgets); err != nil {
+		utils.StopSpinner()
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Handle cost analysis (default and trend)
-	billingService, err := gcpbilling.NewService(ctx, flags.Project, flags.BillingAccount)
+	costCache, err := newCostCache(flags, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create GCP billing service: %w", err)
+		utils.StopSpinner()
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
 	}
-	defer billingService.Close()
 
-	// Create orchestrator with GCP services
-	// Note: For cost analysis, we pass nil for resource service since it's not needed
-	orchestratorService := orchestrator.NewService(identityService, billingService, nil)
+	engineService := engine.NewService(costCache, engine.Settings{
+		Policy:       resiliencePolicy,
+		OwnerTagKeys: cfg.Waste.OwnerTagKeys,
+		Budgets:      cfg.Budgets,
+	})
 
-	return orchestratorService.Orchestrate(flags)
-}
+	var result *model.RunResult
 
-func runAzure(flags model.Flags) error {
-	// Validate required Azure flags
-	if flags.Subscription == "" {
+	switch flags.Provider {
+	case "aws", "gcp", "azure", "all":
+	default:
 		utils.StopSpinner()
-		return fmt.Errorf("--subscription flag is required for Azure provider\n\nTo find your subscription ID:\n  az account list --output table\n\nUsage:\n  cloud-doctor --provider azure --subscription SUBSCRIPTION_ID")
+		fmt.Printf("Unknown provider: %s. Supported providers: aws, gcp, azure, all\n", flags.Provider)
+		os.Exit(1)
 	}
 
-	// Create Azure config service (handles authentication)
-	cfgService, err := azureconfig.NewService(flags.Subscription)
-	if err != nil {
-		return fmt.Errorf("failed to create Azure config: %w", err)
+	if flags.Serve || flags.MetricsAddr != "" || flags.APIAddr != "" || flags.DashboardAddr != "" {
+		utils.StopSpinner()
+		if err := runServe(flags, cfg, engineService); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(model.ExitError)
+		}
+		return
 	}
 
-	// Create Azure identity service
-	identityService, err := azureidentity.NewService(flags.Subscription, cfgService.GetCredential())
-	if err != nil {
-		return fmt.Errorf("failed to create Azure identity service: %w", err)
+	if flags.TUI {
+		utils.StopSpinner()
+		if err := runTUI(flags, cfg, engineService); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(model.ExitError)
+		}
+		return
 	}
 
-	// Handle waste detection
-	if flags.Waste {
-		// Create Azure compute service for waste detection
-		computeService, err := azurecompute.NewService(flags.Subscription, cfgService.GetCredential())
-		if err != nil {
-			return fmt.Errorf("failed to create Azure compute service: %w", err)
-		}
+	switch {
+	case flags.TagAudit:
+		result, err = runTagAudit(flags, cfg, engineService)
+	case flags.Chargeback:
+		result, err = runChargeback(flags, cfg, engineService)
+	case flags.Provider != "all":
+		result, err = runProvider(flags, cfg, engineService)
+	default:
+		result, err = runAll(flags, cfg, engineService)
+	}
 
-		// Create orchestrator with identity and compute services (no cost service needed)
-		orchestratorService := orchestrator.NewService(identityService, nil, computeService)
-		return orchestratorService.Orchestrate(flags)
+	var diffs []model.SnapshotDiff
+	if err == nil {
+		compare := policyService != nil && policyService.NeedsHistory()
+		diffs, err = recordHistory(flags, cfg, result, compare)
+		if err == nil {
+			err = deliverReport(flags, result, notifyService, emailService)
+		}
+	} else if flags.Provider != "all" {
+		err = apierror.Classify(flags.Provider, err)
 	}
 
-	// Handle cost analysis (default and trend)
-	costService, err := azurecostmanagement.NewService(flags.Subscription, cfgService.GetCredential())
 	if err != nil {
-		return fmt.Errorf("failed to create Azure cost management service: %w", err)
+		utils.StopSpinner()
+		fmt.Printf("Error: %v\n", err)
+		if policyService != nil {
+			fmt.Println(utils.FormatPolicySummary(model.PolicyReport{Mode: runMode(result), ExitCode: model.ExitError}))
+		}
+		os.Exit(model.ExitError)
 	}
 
-	// Create orchestrator with Azure services
-	// Note: For cost analysis, we pass nil for resource service since it's not needed
-	orchestratorService := orchestrator.NewService(identityService, costService, nil)
-
-	return orchestratorService.Orchestrate(flags)
+	if policyService != nil {
+		report := policyService.Evaluate(result, diffs)
+		utils.DrawPolicyReport(report)
+		os.Exit(report.ExitCode)
+	}
 }
 
-func runAll(flags model.Flags) error {
+// deliverReport sends the run to the notification channels and by email when
+// requested. Both are attempted even if one fails.
+func deliverReport(flags model.Flags, result *model.RunResult, notifyService notify.NotifyService, emailService email.EmailService) error {
 	ctx := context.Background()
 
-	if flags.Waste {
-		return runAllWaste(ctx, flags)
+	var errs []error
+	if notifyService != nil {
+		errs = append(errs, notifyService.Notify(ctx, result))
 	}
-
-	if flags.Trend {
-		return runAllTrend(ctx, flags)
+	if emailService != nil {
+		errs = append(errs, emailService.Send(ctx, result, flags.EmailEnv))
 	}
-
-	return runAllCosts(ctx, flags)
+	return errors.Join(errs...)
 }
 
-func runAllCosts(ctx context.Context, flags model.Flags) error {
-	var results []model.ProviderCostResult
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-
-	// Run AWS
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		result := collectAWSCosts(ctx, flags)
-		mu.Lock()
-		results = append(results, result)
-		mu.Unlock()
-	}()
-
-	// Run GCP (only if project and billing account are provided)
-	if flags.Project != "" && flags.BillingAccount != "" {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			result := collectGCPCosts(ctx, flags)
-			mu.Lock()
-			results = append(results, result)
-			mu.Unlock()
-		}()
-	}
-
-	// Run Azure (only if subscription is provided)
-	if flags.Subscription != "" {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			result := collectAzureCosts(ctx, flags)
-			mu.Lock()
-			results = append(results, result)
-			mu.Unlock()
-		}()
-	}
-
-	wg.Wait()
-	utils.StopSpinner()
-
-	if len(results) == 0 {
-		return fmt.Errorf("no providers configured. Use --region/--profile for AWS, --project/--billing-account for GCP, --subscription for Azure")
+func runMode(result *model.RunResult) string {
+	if result == nil {
+		return ""
 	}
-
-	utils.SortProviderCostResults(results)
-	utils.DrawMultiCloudCostTable(results)
-
-	return nil
+	return result.Mode
 }
 
-func runAllTrend(ctx context.Context, flags model.Flags) error {
-	var results []model.ProviderCostResult
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-
-	// Run AWS
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		result := collectAWSTrend(ctx, flags)
-		mu.Lock()
-		results = append(results, result)
-		mu.Unlock()
-	}()
-
-	// Run GCP (only if project and billing account are provided)
-	if flags.Project != "" && flags.BillingAccount != "" {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			result := collectGCPTrend(ctx, flags)
-			mu.Lock()
-			results = append(results, result)
-			mu.Unlock()
-		}()
-	}
-
-	// Run Azure (only if subscription is provided)
-	if flags.Subscription != "" {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			result := collectAzureTrend(ctx, flags)
-			mu.Lock()
-			results = append(results, result)
-			mu.Unlock()
-		}()
-	}
-
-	wg.Wait()
-	utils.StopSpinner()
-
-	if len(results) == 0 {
-		return fmt.Errorf("no providers configured. Use --region/--profile for AWS, --project/--billing-account for GCP, --subscription for Azure")
+// newCostCache returns the on-disk cost cache, or nil when caching is disabled
+func newCostCache(flags model.Flags, cfg *model.Config) (cache.CacheService, error) {
+	if flags.NoCache || cfg.Cache.Disabled {
+		return nil, nil
 	}
 
-	utils.SortProviderCostResults(results)
-	utils.DrawMultiCloudTrendChart(results)
-
-	return nil
-}
-
-func runAllWaste(ctx context.Context, flags model.Flags) error {
-	var results []model.ProviderWasteResult
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-
-	// Run AWS
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		result := collectAWSWaste(ctx, flags)
-		mu.Lock()
-		results = append(results, result)
-		mu.Unlock()
-	}()
-
-	// Run GCP (only if project is provided)
-	if flags.Project != "" {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			result := collectGCPWaste(ctx, flags)
-			mu.Lock()
-			results = append(results, result)
-			mu.Unlock()
-		}()
-	}
-
-	// Run Azure (only if subscription is provided)
-	if flags.Subscription != "" {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			result := collectAzureWaste(ctx, flags)
-			mu.Lock()
-			results = append(results, result)
-			mu.Unlock()
-		}()
-	}
-
-	wg.Wait()
-	utils.StopSpinner()
-
-	if len(results) == 0 {
-		return fmt.Errorf("no providers configured. Use --region/--profile for AWS, --project for GCP, --subscription for Azure")
+	ttl := flags.CacheTTL
+	if ttl == 0 && cfg.Cache.TTL != "" {
+		parsed, err := time.ParseDuration(cfg.Cache.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache ttl %q in config: %w", cfg.Cache.TTL, err)
+		}
+		ttl = parsed
 	}
 
-	utils.SortProviderWasteResults(results)
-	utils.DrawMultiCloudWasteTable(results)
-
-	return nil
-}
-
-// AWS cost collectors
-func collectAWSCosts(ctx context.Context, flags model.Flags) model.ProviderCostResult {
-	result := model.ProviderCostResult{Provider: "aws"}
-
-	cfgService := awsconfig.NewService()
-	awsCfg, err := cfgService.GetAWSCfg(ctx, flags.Region, flags.Profile)
+	costCache, err := cache.NewService(cfg.Cache.Dir, ttl)
 	if err != nil {
-		result.Error = err
-		return result
+		return nil, err
 	}
 
-	costService := awscostexplorer.NewService(awsCfg)
-	stsService := awssts.NewService(awsCfg)
+	return costCache, nil
+}
 
-	accountInfo, err := stsService.GetAccountInfo(ctx)
-	if err != nil {
-		result.Error = err
-		return result
+// recordHistory persists cost and waste snapshots from a run and, with --diff,
+// shows what changed since the previous (or selected) snapshot. The diffs are
+// also computed and returned when compare is set, for --fail-on rules.
+func recordHistory(flags model.Flags, cfg *model.Config, result *model.RunResult, compare bool) ([]model.SnapshotDiff, error) {
+	if result == nil || (result.Mode != model.RunModeCost && result.Mode != model.RunModeWaste) {
+		return nil, nil
 	}
-	result.AccountID = accountInfo.AccountID
-
-	currentMonthData, err := costService.GetCurrentMonthCostsByService(ctx)
-	if err != nil {
-		result.Error = err
-		return result
+	compare = compare || flags.Diff
+	if flags.NoHistory && !compare {
+		return nil, nil
 	}
-	result.CurrentMonthData = currentMonthData
 
-	lastMonthData, err := costService.GetLastMonthCostsByService(ctx)
+	historyService, err := history.NewService(cfg.History.Dir)
 	if err != nil {
-		result.Error = err
-		return result
+		return nil, err
 	}
-	result.LastMonthData = lastMonthData
 
-	currentTotalCost, err := costService.GetCurrentMonthTotalCosts(ctx)
-	if err != nil {
-		result.Error = err
-		return result
-	}
-	result.CurrentTotalCost = *currentTotalCost
-
-	lastTotalCost, err := costService.GetLastMonthTotalCosts(ctx)
-	if err != nil {
-		result.Error = err
-		return result
+	var snapshots []model.Snapshot
+	for _, cost := range result.Costs {
+		if cost.Error == nil {
+			snapshots = append(snapshots, history.NewCostSnapshot(cost, result.Timestamp))
+		}
 	}
-	result.LastTotalCost = *lastTotalCost
-
-	return result
-}
-
-func collectAWSTrend(ctx context.Context, flags model.Flags) model.ProviderCostResult {
-	result := model.ProviderCostResult{Provider: "aws"}
-
-	cfgService := awsconfig.NewService()
-	awsCfg, err := cfgService.GetAWSCfg(ctx, flags.Region, flags.Profile)
-	if err != nil {
-		result.Error = err
-		return result
+	for _, waste := range result.Waste {
+		if waste.Error == nil {
+			snapshots = append(snapshots, history.NewWasteSnapshot(waste, result.Timestamp))
+		}
 	}
 
-	costService := awscostexplorer.NewService(awsCfg)
-	stsService := awssts.NewService(awsCfg)
+	var diffs []model.SnapshotDiff
+	for _, snapshot := range snapshots {
+		if compare {
+			baseline, err := historyService.Find(snapshot.Provider, snapshot.AccountID, snapshot.Kind, flags.DiffFrom, snapshot.Timestamp)
+			if err != nil {
+				return nil, err
+			}
+			diffs = append(diffs, history.Diff(baseline, snapshot))
+		}
 
-	accountInfo, err := stsService.GetAccountInfo(ctx)
-	if err != nil {
-		result.Error = err
-		return result
+		if !flags.NoHistory {
+			if err := historyService.Save(snapshot); err != nil {
+				return nil, err
+			}
+		}
 	}
-	result.AccountID = accountInfo.AccountID
 
-	trendData, err := costService.GetLastSixMonthsCosts(ctx)
-	if err != nil {
-		result.Error = err
-		return result
+	if flags.Diff {
+		utils.DrawSnapshotDiffs(diffs)
 	}
-	result.TrendData = trendData
 
-	return result
+	return diffs, nil
 }
 
-func collectAWSWaste(ctx context.Context, flags model.Flags) model.ProviderWasteResult {
-	result := model.ProviderWasteResult{Provider: "aws"}
-
-	cfgService := awsconfig.NewService()
-	awsCfg, err := cfgService.GetAWSCfg(ctx, flags.Region, flags.Profile)
-	if err != nil {
-		result.Error = err
-		return result
-	}
-
-	stsService := awssts.NewService(awsCfg)
-	ec2Service := awsec2.NewService(awsCfg)
+// runProvider runs the report of a single provider through the orchestrator,
+// with the services the report needs from the engine
+func runProvider(flags model.Flags, cfg *model.Config, engineService engine.EngineService) (*model.RunResult, error) {
+	ctx := context.Background()
 
-	accountInfo, err := stsService.GetAccountInfo(ctx)
-	if err != nil {
-		result.Error = err
-		return result
+	// Validate the provider's required flags
+	switch {
+	case flags.Provider == "gcp" && flags.Project == "":
+		utils.StopSpinner()
+		return nil, fmt.Errorf("--project flag is required for GCP provider")
+	case flags.Provider == "gcp" && flags.BillingAccount == "" && !flags.Waste:
+		utils.StopSpinner()
+		return nil, fmt.Errorf("--billing-account flag is required for GCP cost analysis\n\nTo find your billing account ID:\n  gcloud billing accounts list\n\nUsage:\n  cloud-doctor --provider gcp --project PROJECT_ID --billing-account billingAccounts/XXXXXX-XXXXXX-XXXXXX")
+	case flags.Provider == "azure" && flags.Subscription == "":
+		utils.StopSpinner()
+		return nil, fmt.Errorf("--subscription flag is required for Azure provider\n\nTo find your subscription ID:\n  az account list --output table\n\nUsage:\n  cloud-doctor --provider azure --subscription SUBSCRIPTION_ID")
 	}
-	result.AccountID = accountInfo.AccountID
 
-	unusedIPs, err := ec2Service.GetUnusedIPs(ctx)
+	session, err := engineService.Open(ctx, flagAccount(flags, flags.Provider))
 	if err != nil {
-		result.Error = err
-		return result
+		return nil, err
 	}
-	result.UnusedIPs = unusedIPs
+	defer session.Close()
 
-	unusedVolumes, err := ec2Service.GetUnusedVolumes(ctx)
-	if err != nil {
-		result.Error = err
-		return result
+	// Waste detection only needs the resource service, cost analysis (default
+	// and trend) the cost and budget services
+	var costService service.CostService
+	var resourceService service.ResourceService
+	var budgetService service.BudgetService
+	if flags.Waste {
+		resourceService, err = session.Resources(ctx)
+	} else {
+		costService, err = session.Costs(ctx)
+		if err == nil && flags.ImportBudgets {
+			budgetService, err = session.Budgets(ctx)
+		}
 	}
-	result.UnusedVolumes = unusedVolumes
-
-	stoppedInstances, attachedVolumes, err := ec2Service.GetStoppedInstances(ctx)
 	if err != nil {
-		result.Error = err
-		return result
+		return nil, err
 	}
-	result.StoppedInstances = stoppedInstances
-	result.AttachedVolumes = attachedVolumes
 
-	expiringReservations, err := ec2Service.GetExpiringReservations(ctx)
-	if err != nil {
-		result.Error = err
-		return result
-	}
-	result.ExpiringReservations = expiringReservations
+	orchestratorService := orchestrator.NewService(session.Identity(), costService, resourceService, budgetService, cfg.Budgets)
 
-	return result
+	return orchestratorService.Orchestrate(flags)
 }
 
-// GCP cost collectors
-func collectGCPCosts(ctx context.Context, flags model.Flags) model.ProviderCostResult {
-	result := model.ProviderCostResult{Provider: "gcp"}
-
-	identityService, err := gcpidentity.NewService(ctx, flags.Project)
-	if err != nil {
-		result.Error = err
-		return result
+// runAll reports on every configured provider with the same collectors as the
+// daemon, then draws the report
+func runAll(flags model.Flags, cfg *model.Config, engineService engine.EngineService) (*model.RunResult, error) {
+	check := model.ScheduledCheck{Mode: model.CheckCost}
+	if flags.Waste {
+		check.Mode = model.CheckWaste
+	} else if flags.Trend {
+		check.Mode = model.CheckTrend
 	}
 
-	billingService, err := gcpbilling.NewService(ctx, flags.Project, flags.BillingAccount)
+	currencyService, err := currency.NewService(cfg.Currency)
 	if err != nil {
-		result.Error = err
-		return result
+		utils.StopSpinner()
+		return nil, err
 	}
-	defer billingService.Close()
 
-	accountInfo, err := identityService.GetAccountInfo(ctx)
+	result, err := collectCheck(context.Background(), flags, cfg, engineService, check)
+	utils.StopSpinner()
 	if err != nil {
-		result.Error = err
-		return result
+		return nil, err
 	}
-	result.AccountID = accountInfo.AccountID
 
-	currentMonthData, err := billingService.GetCurrentMonthCostsByService(ctx)
-	if err != nil {
-		result.Error = err
-		return result
+	switch result.Mode {
+	case model.RunModeWaste:
+		utils.DrawMultiCloudWasteTable(result.Waste)
+		if flags.GroupBy == model.GroupByOwner {
+			utils.DrawOwnerWasteTable(ownership.GroupWaste(result.Waste))
+		}
+	case model.RunModeTrend:
+		utils.DrawMultiCloudTrendChart(result.Costs)
+	default:
+		utils.DrawMultiCloudCostTable(result.Costs, currencyService.GetReportingCurrency())
 	}
-	result.CurrentMonthData = currentMonthData
 
-	lastMonthData, err := billingService.GetLastMonthCostsByService(ctx)
-	if err != nil {
-		result.Error = err
-		return result
-	}
-	result.LastMonthData = lastMonthData
+	return result, nil
+}
 
-	currentTotalCost, err := billingService.GetCurrentMonthTotalCosts(ctx)
-	if err != nil {
-		result.Error = err
-		return result
+// selectProviders reports which providers a report covers: the one named by
+// --provider, or with --provider all every provider whose flags are set.
+// GCP needs --billing-account as well when the report reads costs.
+func selectProviders(flags model.Flags, gcpNeedsBilling bool) (aws, gcp, azure bool, err error) {
+	if flags.Provider == "gcp" && flags.Project == "" {
+		return false, false, false, fmt.Errorf("--project flag is required for GCP provider")
 	}
-	result.CurrentTotalCost = *currentTotalCost
-
-	lastTotalCost, err := billingService.GetLastMonthTotalCosts(ctx)
-	if err != nil {
-		result.Error = err
-		return result
+	if flags.Provider == "gcp" && gcpNeedsBilling && flags.BillingAccount == "" {
+		return false, false, false, fmt.Errorf("--billing-account flag is required for GCP cost analysis")
+	}
+	if flags.Provider == "azure" && flags.Subscription == "" {
+		return false, false, false, fmt.Errorf("--subscription flag is required for Azure provider")
 	}
-	result.LastTotalCost = *lastTotalCost
 
-	return result
+	all := flags.Provider == "all"
+	aws = flags.Provider == "aws" || all
+	gcp = flags.Provider == "gcp" || (all && flags.Project != "" && (!gcpNeedsBilling || flags.BillingAccount != ""))
+	azure = flags.Provider == "azure" || (all && flags.Subscription != "")
+	return aws, gcp, azure, nil
 }
 
-func collectGCPTrend(ctx context.Context, flags model.Flags) model.ProviderCostResult {
-	result := model.ProviderCostResult{Provider: "gcp"}
-
-	identityService, err := gcpidentity.NewService(ctx, flags.Project)
-	if err != nil {
-		result.Error = err
-		return result
+// flagAccount is the account of a provider named by the flags
+func flagAccount(flags model.Flags, provider string) engine.Account {
+	return engine.Account{
+		Provider:       provider,
+		Region:         flags.Region,
+		Profile:        flags.Profile,
+		ProjectID:      flags.Project,
+		BillingAccount: flags.BillingAccount,
+		SubscriptionID: flags.Subscription,
 	}
+}
 
-	billingService, err := gcpbilling.NewService(ctx, flags.Project, flags.BillingAccount)
+// flagAccounts are the accounts of every provider selectProviders picks
+func flagAccounts(flags model.Flags, gcpNeedsBilling bool) ([]engine.Account, error) {
+	useAWS, useGCP, useAzure, err := selectProviders(flags, gcpNeedsBilling)
 	if err != nil {
-		result.Error = err
-		return result
+		return nil, err
 	}
-	defer billingService.Close()
 
-	accountInfo, err := identityService.GetAccountInfo(ctx)
-	if err != nil {
-		result.Error = err
-		return result
+	var accounts []engine.Account
+	if useAWS {
+		accounts = append(accounts, flagAccount(flags, "aws"))
 	}
-	result.AccountID = accountInfo.AccountID
-
-	trendData, err := billingService.GetLastSixMonthsCosts(ctx)
-	if err != nil {
-		result.Error = err
-		return result
+	if useGCP {
+		accounts = append(accounts, flagAccount(flags, "gcp"))
 	}
-	result.TrendData = trendData
-
-	return result
+	if useAzure {
+		accounts = append(accounts, flagAccount(flags, "azure"))
+	}
+	return accounts, nil
 }
 
-func collectGCPWaste(ctx context.Context, flags model.Flags) model.ProviderWasteResult {
-	result := model.ProviderWasteResult{Provider: "gcp"}
+// runChargeback allocates one month of spend from the selected providers to
+// teams using the chargeback mapping file
+func runChargeback(flags model.Flags, cfg *model.Config, engineService engine.EngineService) (*model.RunResult, error) {
+	ctx := context.Background()
 
-	identityService, err := gcpidentity.NewService(ctx, flags.Project)
-	if err != nil {
-		result.Error = err
-		return result
+	mappingPath := flags.MappingPath
+	if mappingPath == "" {
+		mappingPath = cfg.Chargeback.MappingFile
 	}
-
-	computeService, err := gcpcompute.NewService(ctx, flags.Project)
-	if err != nil {
-		result.Error = err
-		return result
+	if mappingPath == "" {
+		utils.StopSpinner()
+		return nil, fmt.Errorf("a chargeback mapping file is required: use --mapping or set chargeback.mapping_file in the config")
 	}
 
-	accountInfo, err := identityService.GetAccountInfo(ctx)
+	mapping, err := chargeback.LoadMapping(mappingPath)
 	if err != nil {
-		result.Error = err
-		return result
+		utils.StopSpinner()
+		return nil, err
 	}
-	result.AccountID = accountInfo.AccountID
 
-	unusedIPs, err := computeService.GetUnusedIPs(ctx)
+	currencyService, err := currency.NewService(cfg.Currency)
 	if err != nil {
-		result.Error = err
-		return result
+		utils.StopSpinner()
+		return nil, err
 	}
-	result.UnusedIPs = unusedIPs
 
-	unusedVolumes, err := computeService.GetUnusedVolumes(ctx)
+	chargebackService, err := chargeback.NewService(*mapping, currencyService)
 	if err != nil {
-		result.Error = err
-		return result
+		utils.StopSpinner()
+		return nil, err
 	}
-	result.UnusedVolumes = unusedVolumes
 
-	stoppedInstances, attachedVolumes, err := computeService.GetStoppedInstances(ctx)
+	start, end, err := chargeback.ParsePeriod(flags.Month, time.Now())
 	if err != nil {
-		result.Error = err
-		return result
+		utils.StopSpinner()
+		return nil, err
 	}
-	result.StoppedInstances = stoppedInstances
-	result.AttachedVolumes = attachedVolumes
 
-	expiringReservations, err := computeService.GetExpiringReservations(ctx)
+	accounts, err := flagAccounts(flags, true)
 	if err != nil {
-		result.Error = err
-		return result
+		utils.StopSpinner()
+		return nil, err
 	}
-	result.ExpiringReservations = expiringReservations
 
-	return result
-}
-
-// Azure cost collectors
-func collectAzureCosts(ctx context.Context, flags model.Flags) model.ProviderCostResult {
-	result := model.ProviderCostResult{Provider: "azure"}
+	results := engine.CollectAll(ctx, accounts, func(ctx context.Context, account engine.Account) model.ProviderChargeback {
+		return collectChargeback(ctx, engineService, account, chargebackService.GetSource(account.Provider), start, end)
+	})
+	utils.StopSpinner()
 
-	cfgService, err := azureconfig.NewService(flags.Subscription)
-	if err != nil {
-		result.Error = err
-		return result
+	utils.SortProviderChargebacks(results)
+	report := &model.ChargebackReport{
+		Start:     start.Format("2006-01-02"),
+		End:       end.Format("2006-01-02"),
+		Currency:  currencyService.GetReportingCurrency(),
+		Teams:     chargebackService.Allocate(results),
+		Providers: results,
 	}
+	utils.DrawChargebackReport(*report)
 
-	identityService, err := azureidentity.NewService(flags.Subscription, cfgService.GetCredential())
-	if err != nil {
-		result.Error = err
-		return result
+	if flags.CSVPath != "" {
+		if err := writeChargebackCSV(flags.CSVPath, *report); err != nil {
+			return nil, err
+		}
+		fmt.Printf("\n Chargeback CSV written to %s\n", flags.CSVPath)
 	}
 
-	costService, err := azurecostmanagement.NewService(flags.Subscription, cfgService.GetCredential())
-	if err != nil {
-		result.Error = err
-		return result
-	}
+	return &model.RunResult{Mode: model.RunModeChargeback, Timestamp: time.Now(), Chargeback: report}, nil
+}
 
-	accountInfo, err := identityService.GetAccountInfo(ctx)
+func writeChargebackCSV(path string, report model.ChargebackReport) error {
+	file, err := os.Create(path)
 	if err != nil {
-		result.Error = err
-		return result
+		return fmt.Errorf("failed to create CSV file: %w", err)
 	}
-	result.AccountID = accountInfo.AccountID
 
-	currentMonthData, err := costService.GetCurrentMonthCostsByService(ctx)
-	if err != nil {
-		result.Error = err
-		return result
+	if err := utils.WriteChargebackCSV(file, report); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write CSV file: %w", err)
 	}
-	result.CurrentMonthData = currentMonthData
+	return file.Close()
+}
 
-	lastMonthData, err := costService.GetLastMonthCostsByService(ctx)
-	if err != nil {
-		result.Error = err
-		return result
-	}
-	result.LastMonthData = lastMonthData
+// runTagAudit audits tag compliance for the selected provider, or for every
+// configured provider with --provider all
+func runTagAudit(flags model.Flags, cfg *model.Config, engineService engine.EngineService) (*model.RunResult, error) {
+	ctx := context.Background()
+	auditor := tagaudit.NewService(cfg.Tagging)
 
-	currentTotalCost, err := costService.GetCurrentMonthTotalCosts(ctx)
+	accounts, err := flagAccounts(flags, false)
 	if err != nil {
-		result.Error = err
-		return result
+		utils.StopSpinner()
+		return nil, err
 	}
-	result.CurrentTotalCost = *currentTotalCost
 
-	lastTotalCost, err := costService.GetLastMonthTotalCosts(ctx)
-	if err != nil {
-		result.Error = err
-		return result
-	}
-	result.LastTotalCost = *lastTotalCost
+	results := engine.CollectAll(ctx, accounts, func(ctx context.Context, account engine.Account) model.ProviderTagAudit {
+		return collectTagAudit(ctx, engineService, account, auditor)
+	})
+	utils.StopSpinner()
 
-	return result
+	utils.SortProviderTagAudits(results)
+	utils.DrawTagAuditReport(results, auditor.GetRequiredKeys())
+
+	return &model.RunResult{Mode: model.RunModeTags, Timestamp: time.Now(), TagAudits: results}, nil
 }
 
-func collectAzureTrend(ctx context.Context, flags model.Flags) model.ProviderCostResult {
-	result := model.ProviderCostResult{Provider: "azure"}
+// collectTagAudit audits one account's tags. GCP spend by label is only read
+// when --billing-account is set.
+func collectTagAudit(ctx context.Context, engineService engine.EngineService, account engine.Account, auditor tagaudit.TagAuditService) (result model.ProviderTagAudit) {
+	result = model.ProviderTagAudit{Provider: account.Provider}
+
+	collector := warnings.NewCollector()
+	ctx = warnings.WithCollector(ctx, collector)
+	defer func() {
+		result.Warnings = collector.List()
+		result.Error = apierror.Classify(account.Provider, result.Error)
+		result.SpendError = apierror.Classify(account.Provider, result.SpendError)
+	}()
 
-	cfgService, err := azureconfig.NewService(flags.Subscription)
+	session, err := engineService.Open(ctx, account)
 	if err != nil {
 		result.Error = err
 		return result
 	}
+	defer session.Close()
 
-	identityService, err := azureidentity.NewService(flags.Subscription, cfgService.GetCredential())
+	tagService, err := session.Tags(ctx)
 	if err != nil {
 		result.Error = err
 		return result
 	}
 
-	costService, err := azurecostmanagement.NewService(flags.Subscription, cfgService.GetCredential())
-	if err != nil {
-		result.Error = err
-		return result
+	var costService service.CostService
+	if account.Provider != "gcp" || account.BillingAccount != "" {
+		costService, err = session.Costs(ctx)
+		if err != nil {
+			result.Error = err
+			return result
+		}
 	}
 
-	accountInfo, err := identityService.GetAccountInfo(ctx)
+	accountInfo, err := session.Identity().GetAccountInfo(ctx)
 	if err != nil {
 		result.Error = err
 		return result
 	}
-	result.AccountID = accountInfo.AccountID
 
-	trendData, err := costService.GetLastSixMonthsCosts(ctx)
+	audit, err := auditor.Audit(ctx, tagService, costService)
 	if err != nil {
 		result.Error = err
 		return result
 	}
-	result.TrendData = trendData
+	result = *audit
+	result.Provider = account.Provider
+	result.AccountID = accountInfo.AccountID
 
 	return result
 }
 
-func collectAzureWaste(ctx context.Context, flags model.Flags) model.ProviderWasteResult {
-	result := model.ProviderWasteResult{Provider: "azure"}
-
-	cfgService, err := azureconfig.NewService(flags.Subscription)
-	if err != nil {
-		result.Error = err
-		return result
-	}
+// collectChargeback reads one account's spend in [start, end) grouped by its allocation source
+func collectChargeback(ctx context.Context, engineService engine.EngineService, account engine.Account, source model.AllocationSource, start, end time.Time) (result model.ProviderChargeback) {
+	result = model.ProviderChargeback{Provider: account.Provider, Source: source}
 
-	identityService, err := azureidentity.NewService(flags.Subscription, cfgService.GetCredential())
-	if err != nil {
-		result.Error = err
-		return result
-	}
+	collector := warnings.NewCollector()
+	ctx = warnings.WithCollector(ctx, collector)
+	defer func() {
+		result.Warnings = collector.List()
+		result.Error = apierror.Classify(account.Provider, result.Error)
+	}()
 
-	computeService, err := azurecompute.NewService(flags.Subscription, cfgService.GetCredential())
+	session, err := engineService.Open(ctx, account)
 	if err != nil {
 		result.Error = err
 		return result
 	}
+	defer session.Close()
 
-	accountInfo, err := identityService.GetAccountInfo(ctx)
+	accountInfo, err := session.Identity().GetAccountInfo(ctx)
 	if err != nil {
 		result.Error = err
 		return result
 	}
 	result.AccountID = accountInfo.AccountID
 
-	unusedIPs, err := computeService.GetUnusedIPs(ctx)
-	if err != nil {
-		result.Error = err
-		return result
-	}
-	result.UnusedIPs = unusedIPs
-
-	unusedVolumes, err := computeService.GetUnusedVolumes(ctx)
-	if err != nil {
-		result.Error = err
-		return result
-	}
-	result.UnusedVolumes = unusedVolumes
-
-	stoppedInstances, attachedVolumes, err := computeService.GetStoppedInstances(ctx)
+	costService, err := session.Costs(ctx)
 	if err != nil {
 		result.Error = err
 		return result
 	}
-	result.StoppedInstances = stoppedInstances
-	result.AttachedVolumes = attachedVolumes
 
-	expiringReservations, err := computeService.GetExpiringReservations(ctx)
+	costs, err := chargeback.GroupedCosts(ctx, source, costService, start, end)
 	if err != nil {
 		result.Error = err
 		return result
 	}
-	result.ExpiringReservations = expiringReservations
+	result.Costs = costs
+	result.CachedAt = cache.CachedAt(costService)
 
 	return result
 }
@@ -1,6 +1,17 @@
 package main
 
-import "os"
+import (
+	"flag"
+	"os"
+	"strings"
+)
+
+// MCP transports
+const (
+	TransportStdio = "stdio"
+	TransportSSE   = "sse"
+	TransportHTTP  = "http"
+)
 
 // Config holds environment-based configuration for all cloud providers
 type Config struct {
@@ -14,17 +25,55 @@ type Config struct {
 
 	// Azure configuration
 	AzureSubscriptionID string
+
+	// Cloud Doctor config file and reporting currency override
+	ConfigPath        string
+	ReportingCurrency string
+
+	// Cost cache controls
+	NoCache  bool
+	CacheTTL string
+
+	// Per-call timeout for provider API requests
+	Timeout string
+
+	// Transport is stdio, sse or http (streamable HTTP)
+	Transport string
+
+	// Listen address and bearer tokens of the sse and http transports
+	Address string
+	Tokens  []string
+
+	// Longest a tool call may run before it is cancelled
+	RequestTimeout string
 }
 
-// LoadConfig reads configuration from environment variables
+// LoadConfig reads configuration from environment variables. The transport
+// settings can also be given as flags, which take precedence.
 func LoadConfig() *Config {
-	return &Config{
+	cfg := &Config{
 		AWSRegion:           getEnvOrDefault("AWS_REGION", "us-east-1"),
 		AWSProfile:          os.Getenv("AWS_PROFILE"),
 		GCPProjectID:        os.Getenv("GCP_PROJECT_ID"),
 		GCPBillingAccount:   os.Getenv("GCP_BILLING_ACCOUNT"),
 		AzureSubscriptionID: os.Getenv("AZURE_SUBSCRIPTION_ID"),
+		ConfigPath:          os.Getenv("CLOUD_DOCTOR_CONFIG"),
+		ReportingCurrency:   os.Getenv("CLOUD_DOCTOR_CURRENCY"),
+		NoCache:             os.Getenv("CLOUD_DOCTOR_NO_CACHE") == "true",
+		CacheTTL:            os.Getenv("CLOUD_DOCTOR_CACHE_TTL"),
+		Timeout:             os.Getenv("CLOUD_DOCTOR_TIMEOUT"),
+		Transport:           getEnvOrDefault("CLOUD_DOCTOR_MCP_TRANSPORT", TransportStdio),
+		Address:             getEnvOrDefault("CLOUD_DOCTOR_MCP_ADDR", ":8080"),
+		Tokens:              splitList(os.Getenv("CLOUD_DOCTOR_MCP_TOKENS")),
+		RequestTimeout:      getEnvOrDefault("CLOUD_DOCTOR_MCP_REQUEST_TIMEOUT", "5m"),
 	}
+
+	flag.StringVar(&cfg.Transport, "transport", cfg.Transport, "MCP transport: stdio, sse or http (env CLOUD_DOCTOR_MCP_TRANSPORT)")
+	flag.StringVar(&cfg.Address, "addr", cfg.Address, "Listen address of the sse and http transports (env CLOUD_DOCTOR_MCP_ADDR)")
+	flag.StringVar(&cfg.RequestTimeout, "request-timeout", cfg.RequestTimeout, "Longest a tool call may run, 0 for no limit (env CLOUD_DOCTOR_MCP_REQUEST_TIMEOUT)")
+	flag.Parse()
+
+	return cfg
 }
 
 // HasAWS returns true if AWS is available (always true - uses default credential chain)
@@ -47,9 +96,25 @@ func (c *Config) HasAzure() bool {
 	return c.AzureSubscriptionID != ""
 }
 
+// IsHTTP returns true if the server listens on the network instead of stdio
+func (c *Config) IsHTTP() bool {
+	return c.Transport == TransportSSE || c.Transport == TransportHTTP
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
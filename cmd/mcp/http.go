@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// shutdownTimeout bounds how long open sessions and requests delay exit
+const shutdownTimeout = 5 * time.Second
+
+// transportServer is the part of the SSE and streamable HTTP servers used here
+type transportServer interface {
+	Start(addr string) error
+	Shutdown(ctx context.Context) error
+}
+
+// serveHTTP serves s over SSE or streamable HTTP until ctx is cancelled.
+// Every request needs one of the configured bearer tokens, so one server can
+// be shared by a team while the cloud credentials stay on the server.
+func serveHTTP(ctx context.Context, s *server.MCPServer, cfg *Config) error {
+	if len(cfg.Tokens) == 0 {
+		return errors.New("the sse and http transports need bearer tokens in CLOUD_DOCTOR_MCP_TOKENS")
+	}
+
+	httpServer := &http.Server{ReadHeaderTimeout: 10 * time.Second}
+
+	var transport transportServer
+	var endpoint string
+	switch cfg.Transport {
+	case TransportSSE:
+		sseServer := server.NewSSEServer(s, server.WithHTTPServer(httpServer), server.WithKeepAlive(true))
+		httpServer.Handler = authorize(cfg.Tokens, sseServer)
+		transport, endpoint = sseServer, sseServer.CompleteSsePath()
+	default:
+		streamableServer := server.NewStreamableHTTPServer(s, server.WithStreamableHTTPServer(httpServer))
+		mux := http.NewServeMux()
+		mux.Handle("/mcp", authorize(cfg.Tokens, streamableServer))
+		httpServer.Handler = mux
+		transport, endpoint = streamableServer, "/mcp"
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- transport.Start(cfg.Address)
+	}()
+	fmt.Fprintf(os.Stderr, "Serving MCP over %s on %s%s\n", cfg.Transport, cfg.Address, endpoint)
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := transport.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	if err := <-errs; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// authorize rejects requests without one of the bearer tokens
+func authorize(tokens []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !validToken(tokens, strings.TrimSpace(token)) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="cloud-doctor-mcp"`)
+			http.Error(w, "missing or invalid bearer token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validToken compares token with every configured token in constant time
+func validToken(tokens []string, token string) bool {
+	valid := 0
+	for _, t := range tokens {
+		valid |= subtle.ConstantTimeCompare([]byte(token), []byte(t))
+	}
+	return valid == 1
+}
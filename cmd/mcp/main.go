@@ -1,30 +1,134 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/elC0mpa/aws-doctor/cmd/mcp/tools"
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/appconfig"
+	"github.com/elC0mpa/aws-doctor/service/cache"
+	"github.com/elC0mpa/aws-doctor/service/currency"
+	"github.com/elC0mpa/aws-doctor/service/engine"
+	"github.com/elC0mpa/aws-doctor/service/resilience"
 	"github.com/mark3labs/mcp-go/server"
 )
 
 func main() {
 	cfg := LoadConfig()
 
-	s := server.NewMCPServer(
-		"cloud-doctor-mcp",
-		"1.0.0",
+	fileCfg, err := appconfig.NewService(cfg.ConfigPath).Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if cfg.ReportingCurrency != "" {
+		fileCfg.Currency.ReportingCurrency = cfg.ReportingCurrency
+	}
+
+	currencyService, err := currency.NewService(fileCfg.Currency)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Currency config error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if cfg.Timeout != "" {
+		fileCfg.Resilience.Timeout = cfg.Timeout
+	}
+
+	resiliencePolicy, err := resilience.NewPolicy(fileCfg.Resilience, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Resilience config error: %v\n", err)
+		os.Exit(1)
+	}
+
+	costCache, err := newCostCache(cfg, fileCfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cache config error: %v\n", err)
+		os.Exit(1)
+	}
+
+	engineService := engine.NewService(costCache, engine.Settings{
+		Policy:       resiliencePolicy,
+		OwnerTagKeys: fileCfg.Waste.OwnerTagKeys,
+	})
+
+	requestTimeout, err := time.ParseDuration(cfg.RequestTimeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid request timeout %q: %v\n", cfg.RequestTimeout, err)
+		os.Exit(1)
+	}
+
+	options := []server.ServerOption{
 		server.WithToolCapabilities(true),
-	)
+		server.WithResourceCapabilities(false, true),
+		server.WithPromptCapabilities(true),
+		server.WithToolHandlerMiddleware(tools.WarningsMiddleware),
+	}
+	if requestTimeout > 0 {
+		options = append(options,
+			server.WithToolHandlerMiddleware(tools.TimeoutMiddleware(requestTimeout)),
+			server.WithResourceHandlerMiddleware(tools.ResourceTimeoutMiddleware(requestTimeout)),
+		)
+	}
+
+	s := server.NewMCPServer("cloud-doctor-mcp", "1.0.0", options...)
 
 	// Register tools for each provider
-	tools.RegisterAWSTools(s, cfg.AWSRegion, cfg.AWSProfile)
-	tools.RegisterGCPTools(s, cfg.GCPProjectID, cfg.GCPBillingAccount)
-	tools.RegisterAzureTools(s, cfg.AzureSubscriptionID)
-	tools.RegisterMultiCloudTools(s, cfg.AWSRegion, cfg.AWSProfile, cfg.GCPProjectID, cfg.GCPBillingAccount, cfg.AzureSubscriptionID)
+	tools.RegisterAWSTools(s, cfg.AWSRegion, cfg.AWSProfile, engineService)
+	tools.RegisterGCPTools(s, cfg.GCPProjectID, cfg.GCPBillingAccount, engineService)
+	tools.RegisterAzureTools(s, cfg.AzureSubscriptionID, engineService)
+	tools.RegisterMultiCloudTools(s, cfg.AWSRegion, cfg.AWSProfile, cfg.GCPProjectID, cfg.GCPBillingAccount, cfg.AzureSubscriptionID, currencyService, engineService)
 
-	if err := server.ServeStdio(s); err != nil {
+	// Cached results as resources, and prompts that orchestrate the tools
+	tools.RegisterResources(s, cfg.AWSRegion, cfg.AWSProfile, cfg.GCPProjectID, cfg.GCPBillingAccount, cfg.AzureSubscriptionID, currencyService, engineService)
+	tools.RegisterPrompts(s)
+
+	switch {
+	case cfg.Transport == TransportStdio:
+		err = server.ServeStdio(s)
+	case cfg.IsHTTP():
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err = serveHTTP(ctx, s, cfg)
+		stop()
+	default:
+		err = fmt.Errorf("unknown transport %q, expected %s, %s or %s", cfg.Transport, TransportStdio, TransportSSE, TransportHTTP)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// newCostCache returns the shared cost cache, or nil when caching is disabled
+func newCostCache(cfg *Config, fileCfg *model.Config) (cache.CacheService, error) {
+	if cfg.NoCache || fileCfg.Cache.Disabled {
+		return nil, nil
+	}
+
+	ttlStr := fileCfg.Cache.TTL
+	if cfg.CacheTTL != "" {
+		ttlStr = cfg.CacheTTL
+	}
+
+	var ttl time.Duration
+	if ttlStr != "" {
+		parsed, err := time.ParseDuration(ttlStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache ttl %q: %w", ttlStr, err)
+		}
+		ttl = parsed
+	}
+
+	costCache, err := cache.NewService(fileCfg.Cache.Dir, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return costCache, nil
+}
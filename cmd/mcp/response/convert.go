@@ -69,6 +69,31 @@ func ConvertCostInfo(info *model.CostInfo) *CostInfo {
 	}
 }
 
+// ConvertCostPeriod converts one period of grouped spend from model.CostInfo.
+// It also returns the currency, which is empty when the period has no spend.
+func ConvertCostPeriod(info *model.CostInfo) (CostPeriod, string) {
+	period := CostPeriod{Groups: []ServiceCost{}}
+	if info == nil {
+		return period, ""
+	}
+
+	var currency string
+	for _, group := range info.CostGroup {
+		if currency == "" {
+			currency = group.Unit
+		}
+	}
+
+	costs := ConvertCostInfo(info)
+	period.StartDate = costs.StartDate
+	period.EndDate = costs.EndDate
+	period.Total = costs.Total
+	if costs.Services != nil {
+		period.Groups = costs.Services
+	}
+	return period, currency
+}
+
 // ParseTotalCostString parses "123.45 USD" format from existing services
 func ParseTotalCostString(totalStr string) (float64, string) {
 	parts := strings.Fields(totalStr)
@@ -159,6 +184,9 @@ func ConvertUnusedVolumes(volumes []model.UnusedVolume) []UnusedVolume {
 			ID:     v.ID,
 			SizeGB: v.SizeGB,
 			Status: v.Status,
+			Region: v.Region,
+			Tags:   v.Tags,
+			Owner:  v.Owner,
 		})
 	}
 	return result
@@ -169,9 +197,15 @@ func ConvertStoppedInstances(instances []model.StoppedInstance) []StoppedInstanc
 	result := make([]StoppedInstance, 0, len(instances))
 	for _, i := range instances {
 		result = append(result, StoppedInstance{
-			ID:          i.ID,
-			Name:        i.Name,
-			StoppedDays: i.StoppedDays,
+			ID:                 i.ID,
+			Name:               i.Name,
+			StoppedDays:        i.StoppedDays,
+			StoppedAtEstimated: i.StoppedAtEstimated,
+			Region:             i.Region,
+			InstanceType:       i.InstanceType,
+			LaunchTime:         i.LaunchTime,
+			Tags:               i.Tags,
+			Owner:              i.Owner,
 		})
 	}
 	return result
@@ -184,6 +218,9 @@ func ConvertUnusedIPs(ips []model.UnusedIP) []UnusedIP {
 		result = append(result, UnusedIP{
 			Address:      ip.Address,
 			AllocationID: ip.AllocationID,
+			Region:       ip.Region,
+			Tags:         ip.Tags,
+			Owner:        ip.Owner,
 		})
 	}
 	return result
@@ -202,3 +239,37 @@ func ConvertReservations(reservations []model.Reservation) []Reservation {
 	}
 	return result
 }
+
+// ConvertWarnings converts model.Warning values to response.Warning
+func ConvertWarnings(warnings []model.Warning) []Warning {
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	result := make([]Warning, 0, len(warnings))
+	for _, w := range warnings {
+		result = append(result, Warning{
+			Provider: w.Provider,
+			Kind:     string(w.Kind),
+			Scope:    w.Scope,
+			Action:   w.Action,
+			Message:  w.Message,
+		})
+	}
+	return result
+}
+
+// ConvertWasteResult converts one provider's waste findings to response format.
+// Its error is left to the caller, which describes it for the client.
+func ConvertWasteResult(result model.ProviderWasteResult) WasteSummary {
+	return WasteSummary{
+		Provider:             result.Provider,
+		AccountID:            result.AccountID,
+		UnusedVolumes:        ConvertUnusedVolumes(result.UnusedVolumes),
+		AttachedVolumes:      ConvertUnusedVolumes(result.AttachedVolumes),
+		UnusedIPs:            ConvertUnusedIPs(result.UnusedIPs),
+		StoppedInstances:     ConvertStoppedInstances(result.StoppedInstances),
+		ExpiringReservations: ConvertReservations(result.ExpiringReservations),
+		Warnings:             ConvertWarnings(result.Warnings),
+	}
+}
@@ -1,5 +1,7 @@
 package response
 
+import "time"
+
 // AccountInfo represents cloud account/project identity
 type AccountInfo struct {
 	Provider    string `json:"provider"`
@@ -21,14 +23,16 @@ type CostInfo struct {
 	Services  []ServiceCost `json:"services"`
 	Total     float64       `json:"total"`
 	Currency  string        `json:"currency"`
+	CachedAt  *time.Time    `json:"cached_at,omitempty"`
 }
 
 // CostComparison represents cost comparison between two periods
 type CostComparison struct {
-	CurrentMonth  CostInfo `json:"current_month"`
-	LastMonth     CostInfo `json:"last_month"`
-	Difference    float64  `json:"difference"`
-	PercentChange float64  `json:"percent_change"`
+	CurrentMonth  CostInfo   `json:"current_month"`
+	LastMonth     CostInfo   `json:"last_month"`
+	Difference    float64    `json:"difference"`
+	PercentChange float64    `json:"percent_change"`
+	CachedAt      *time.Time `json:"cached_at,omitempty"`
 }
 
 // TrendSummary provides summary statistics for cost trend
@@ -43,28 +47,72 @@ type TrendSummary struct {
 
 // CostTrend represents 6-month cost trend with summary
 type CostTrend struct {
-	Months  []CostInfo   `json:"months"`
-	Summary TrendSummary `json:"summary"`
+	Months   []CostInfo   `json:"months"`
+	Summary  TrendSummary `json:"summary"`
+	CachedAt *time.Time   `json:"cached_at,omitempty"`
+}
+
+// CostPeriod represents grouped spend for one period of a cost query
+type CostPeriod struct {
+	StartDate string        `json:"start_date"`
+	EndDate   string        `json:"end_date"`
+	Groups    []ServiceCost `json:"groups"`
+	Total     float64       `json:"total"`
+}
+
+// CostQuery represents a provider's spend in a date range, grouped by
+// GroupBy and split into periods of Granularity. Converted amounts are only
+// set by multi-cloud queries and are in the reporting currency.
+type CostQuery struct {
+	Provider          string       `json:"provider"`
+	AccountID         string       `json:"account_id,omitempty"`
+	StartDate         string       `json:"start_date"`
+	EndDate           string       `json:"end_date"`
+	Granularity       string       `json:"granularity"`
+	GroupBy           string       `json:"group_by"`
+	Periods           []CostPeriod `json:"periods"`
+	Total             float64      `json:"total"`
+	Currency          string       `json:"currency"`
+	ConvertedTotal    *float64     `json:"converted_total,omitempty"`
+	ExchangeRate      *float64     `json:"exchange_rate,omitempty"`
+	ConversionError   string       `json:"conversion_error,omitempty"`
+	CachedAt          *time.Time   `json:"cached_at,omitempty"`
+	Warnings          []Warning    `json:"warnings,omitempty"`
+	Error             string       `json:"error,omitempty"`
+	ErrorKind         string       `json:"error_kind,omitempty"`
+	MissingPermission string       `json:"missing_permission,omitempty"`
 }
 
 // UnusedVolume represents an unused storage volume
 type UnusedVolume struct {
-	ID     string `json:"id"`
-	SizeGB int32  `json:"size_gb"`
-	Status string `json:"status"`
+	ID     string            `json:"id"`
+	SizeGB int32             `json:"size_gb"`
+	Status string            `json:"status"`
+	Region string            `json:"region,omitempty"`
+	Tags   map[string]string `json:"tags,omitempty"`
+	Owner  string            `json:"owner,omitempty"`
 }
 
 // StoppedInstance represents a stopped compute instance
 type StoppedInstance struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	StoppedDays int    `json:"stopped_days"`
+	ID                 string            `json:"id"`
+	Name               string            `json:"name"`
+	StoppedDays        int               `json:"stopped_days"`
+	StoppedAtEstimated bool              `json:"stopped_at_estimated,omitempty"`
+	Region             string            `json:"region,omitempty"`
+	InstanceType       string            `json:"instance_type,omitempty"`
+	LaunchTime         *time.Time        `json:"launch_time,omitempty"`
+	Tags               map[string]string `json:"tags,omitempty"`
+	Owner              string            `json:"owner,omitempty"`
 }
 
 // UnusedIP represents an unassociated IP address
 type UnusedIP struct {
-	Address      string `json:"address"`
-	AllocationID string `json:"allocation_id"`
+	Address      string            `json:"address"`
+	AllocationID string            `json:"allocation_id"`
+	Region       string            `json:"region,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+	Owner        string            `json:"owner,omitempty"`
 }
 
 // Reservation represents a reserved instance/commitment
@@ -84,6 +132,10 @@ type WasteSummary struct {
 	UnusedIPs            []UnusedIP        `json:"unused_ips"`
 	StoppedInstances     []StoppedInstance `json:"stopped_instances"`
 	ExpiringReservations []Reservation     `json:"expiring_reservations"`
+	Warnings             []Warning         `json:"warnings,omitempty"`
+	Error                string            `json:"error,omitempty"`
+	ErrorKind            string            `json:"error_kind,omitempty"`
+	MissingPermission    string            `json:"missing_permission,omitempty"`
 }
 
 // AzureSubscription represents Azure subscription details
@@ -93,26 +145,57 @@ type AzureSubscription struct {
 	State          string `json:"state"`
 }
 
-// MultiCloudCostSummary represents costs across all providers
+// MultiCloudCostSummary represents costs across all providers.
+// Total is expressed in Currency, the reporting currency.
 type MultiCloudCostSummary struct {
-	Providers []ProviderCostSummary `json:"providers"`
-	Total     float64               `json:"total"`
-	Currency  string                `json:"currency"`
+	Providers      []ProviderCostSummary `json:"providers"`
+	Total          float64               `json:"total"`
+	LastMonthTotal float64               `json:"last_month_total"`
+	Currency       string                `json:"currency"`
+	Excluded       []string              `json:"excluded_from_total,omitempty"`
 }
 
-// ProviderCostSummary represents cost summary for a single provider
+// ProviderCostSummary represents cost summary for a single provider.
+// Native amounts are in Currency; converted amounts are in the reporting currency.
 type ProviderCostSummary struct {
-	Provider         string   `json:"provider"`
-	AccountID        string   `json:"account_id"`
-	CurrentMonthCost float64  `json:"current_month_cost"`
-	LastMonthCost    float64  `json:"last_month_cost"`
-	Difference       float64  `json:"difference"`
-	PercentChange    float64  `json:"percent_change"`
-	Currency         string   `json:"currency"`
-	Error            string   `json:"error,omitempty"`
+	Provider                  string     `json:"provider"`
+	AccountID                 string     `json:"account_id"`
+	CurrentMonthCost          float64    `json:"current_month_cost"`
+	LastMonthCost             float64    `json:"last_month_cost"`
+	Difference                float64    `json:"difference"`
+	PercentChange             float64    `json:"percent_change"`
+	Currency                  string     `json:"currency"`
+	ConvertedCurrentMonthCost *float64   `json:"converted_current_month_cost,omitempty"`
+	ConvertedLastMonthCost    *float64   `json:"converted_last_month_cost,omitempty"`
+	ExchangeRate              *float64   `json:"exchange_rate,omitempty"`
+	ConversionError           string     `json:"conversion_error,omitempty"`
+	CachedAt                  *time.Time `json:"cached_at,omitempty"`
+	Warnings                  []Warning  `json:"warnings,omitempty"`
+	Error                     string     `json:"error,omitempty"`
+	ErrorKind                 string     `json:"error_kind,omitempty"`
+	MissingPermission         string     `json:"missing_permission,omitempty"`
+}
+
+// MultiCloudCostQuery represents a cost query across all providers.
+// Total is expressed in Currency, the reporting currency.
+type MultiCloudCostQuery struct {
+	Providers []CostQuery `json:"providers"`
+	Total     float64     `json:"total"`
+	Currency  string      `json:"currency"`
+	Excluded  []string    `json:"excluded_from_total,omitempty"`
 }
 
 // MultiCloudWasteSummary represents waste across all providers
 type MultiCloudWasteSummary struct {
 	Providers []WasteSummary `json:"providers"`
+	ScannedAt time.Time      `json:"scanned_at"`
+}
+
+// Warning describes a problem that made a result partial, such as a zone that could not be scanned
+type Warning struct {
+	Provider string `json:"provider"`
+	Kind     string `json:"kind"`
+	Scope    string `json:"scope,omitempty"`
+	Action   string `json:"missing_permission,omitempty"`
+	Message  string `json:"message"`
 }
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/elC0mpa/aws-doctor/cmd/mcp/response"
+	"github.com/elC0mpa/aws-doctor/service/apierror"
+	"github.com/elC0mpa/aws-doctor/service/warnings"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WarningsMiddleware collects warnings reported while a tool runs and appends them
+// to the tool result, so clients know when a result is partial
+func WarningsMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		collector := warnings.NewCollector()
+		result, err := next(warnings.WithCollector(ctx, collector), request)
+		if err != nil || result == nil {
+			return result, err
+		}
+
+		collected := collector.List()
+		if len(collected) == 0 {
+			return result, nil
+		}
+
+		data, _ := json.MarshalIndent(struct {
+			Warnings []response.Warning `json:"warnings"`
+		}{Warnings: response.ConvertWarnings(collected)}, "", "  ")
+		result.Content = append(result.Content, mcp.NewTextContent(string(data)))
+		return result, nil
+	}
+}
+
+// newParamError reports a parameter that failed validation
+func newParamError(err error) *mcp.CallToolResult {
+	return mcp.NewToolResultError(fmt.Sprintf("Invalid parameters: %v", err))
+}
+
+// newToolError reports a classified provider error to the client
+func newToolError(provider, message string, err error) *mcp.CallToolResult {
+	return mcp.NewToolResultError(fmt.Sprintf("%s: %v", message, apierror.Classify(provider, err)))
+}
@@ -0,0 +1,298 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/elC0mpa/aws-doctor/cmd/mcp/response"
+	"github.com/elC0mpa/aws-doctor/service/cache"
+	"github.com/elC0mpa/aws-doctor/service/engine"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// The handlers below serve the per-provider tools of every provider; the
+// account resolver picks the provider and its account
+
+// openSession resolves the account of a call and sets up its credentials. A
+// non-nil result reports why it could not; otherwise the caller must Close
+// the session.
+func openSession(ctx context.Context, engineService engine.EngineService, resolve accountResolver, request mcp.CallToolRequest) (*engine.Session, *mcp.CallToolResult) {
+	account, result := resolve(request)
+	if result != nil {
+		return nil, result
+	}
+
+	session, err := engineService.Open(ctx, account)
+	if err != nil {
+		return nil, newToolError(account.Provider, "Failed to configure "+providerTitles[account.Provider], err)
+	}
+	return session, nil
+}
+
+func makeAccountInfoHandler(engineService engine.EngineService, resolve accountResolver) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		session, result := openSession(ctx, engineService, resolve, request)
+		if result != nil {
+			return result, nil
+		}
+		defer session.Close()
+
+		info, err := session.Identity().GetAccountInfo(ctx)
+		if err != nil {
+			return newToolError(session.Provider(), "Failed to get account info", err), nil
+		}
+
+		resp := response.ConvertAccountInfo(info)
+		data, _ := json.MarshalIndent(resp, "", "  ")
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func makeCurrentMonthCostsHandler(engineService engine.EngineService, resolve accountResolver) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		session, result := openSession(ctx, engineService, resolve, request)
+		if result != nil {
+			return result, nil
+		}
+		defer session.Close()
+
+		costSvc, err := session.Costs(ctx)
+		if err != nil {
+			return newToolError(session.Provider(), "Failed to create cost service", err), nil
+		}
+
+		costData, err := costSvc.GetCurrentMonthCostsByService(ctx)
+		if err != nil {
+			return newToolError(session.Provider(), "Failed to get costs", err), nil
+		}
+
+		resp := response.ConvertCostInfo(costData)
+		resp.CachedAt = cache.CachedAt(costSvc)
+		data, _ := json.MarshalIndent(resp, "", "  ")
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func makeCostComparisonHandler(engineService engine.EngineService, resolve accountResolver) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		session, result := openSession(ctx, engineService, resolve, request)
+		if result != nil {
+			return result, nil
+		}
+		defer session.Close()
+
+		costSvc, err := session.Costs(ctx)
+		if err != nil {
+			return newToolError(session.Provider(), "Failed to create cost service", err), nil
+		}
+
+		currentData, err := costSvc.GetCurrentMonthCostsByService(ctx)
+		if err != nil {
+			return newToolError(session.Provider(), "Failed to get current month costs", err), nil
+		}
+
+		lastData, err := costSvc.GetLastMonthCostsByService(ctx)
+		if err != nil {
+			return newToolError(session.Provider(), "Failed to get last month costs", err), nil
+		}
+
+		currentCosts := response.ConvertCostInfo(currentData)
+		lastCosts := response.ConvertCostInfo(lastData)
+
+		diff := currentCosts.Total - lastCosts.Total
+		var percentChange float64
+		if lastCosts.Total > 0 {
+			percentChange = (diff / lastCosts.Total) * 100
+		}
+
+		resp := response.CostComparison{
+			CurrentMonth:  *currentCosts,
+			LastMonth:     *lastCosts,
+			Difference:    diff,
+			PercentChange: percentChange,
+		}
+
+		resp.CachedAt = cache.CachedAt(costSvc)
+		data, _ := json.MarshalIndent(resp, "", "  ")
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func makeCostTrendHandler(engineService engine.EngineService, resolve accountResolver) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		session, result := openSession(ctx, engineService, resolve, request)
+		if result != nil {
+			return result, nil
+		}
+		defer session.Close()
+
+		costSvc, err := session.Costs(ctx)
+		if err != nil {
+			return newToolError(session.Provider(), "Failed to create cost service", err), nil
+		}
+
+		trendData, err := costSvc.GetLastSixMonthsCosts(ctx)
+		if err != nil {
+			return newToolError(session.Provider(), "Failed to get cost trend", err), nil
+		}
+
+		resp := response.ConvertTrendData(trendData)
+		resp.CachedAt = cache.CachedAt(costSvc)
+		data, _ := json.MarshalIndent(resp, "", "  ")
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func makeCostsHandler(engineService engine.EngineService, resolve accountResolver, groupings []string) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		account, result := resolve(request)
+		if result != nil {
+			return result, nil
+		}
+		query, err := parseCostQuery(request, groupings)
+		if err != nil {
+			return newParamError(err), nil
+		}
+
+		resp := collectCostQuery(ctx, engineService, account, query)
+		if resp.Error != "" {
+			return mcp.NewToolResultError("Failed to get costs: " + resp.Error), nil
+		}
+
+		data, _ := json.MarshalIndent(resp, "", "  ")
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func makeUnusedVolumesHandler(engineService engine.EngineService, resolve accountResolver) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		session, result := openSession(ctx, engineService, resolve, request)
+		if result != nil {
+			return result, nil
+		}
+		defer session.Close()
+
+		resourceSvc, err := session.Resources(ctx)
+		if err != nil {
+			return newToolError(session.Provider(), "Failed to create resource service", err), nil
+		}
+
+		volumes, err := resourceSvc.GetUnusedVolumes(ctx)
+		if err != nil {
+			return newToolError(session.Provider(), "Failed to get unused volumes", err), nil
+		}
+
+		resp := response.ConvertUnusedVolumes(volumes)
+		data, _ := json.MarshalIndent(resp, "", "  ")
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func makeUnusedIPsHandler(engineService engine.EngineService, resolve accountResolver) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		session, result := openSession(ctx, engineService, resolve, request)
+		if result != nil {
+			return result, nil
+		}
+		defer session.Close()
+
+		resourceSvc, err := session.Resources(ctx)
+		if err != nil {
+			return newToolError(session.Provider(), "Failed to create resource service", err), nil
+		}
+
+		ips, err := resourceSvc.GetUnusedIPs(ctx)
+		if err != nil {
+			return newToolError(session.Provider(), "Failed to get unused IPs", err), nil
+		}
+
+		resp := response.ConvertUnusedIPs(ips)
+		data, _ := json.MarshalIndent(resp, "", "  ")
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func makeStoppedInstancesHandler(engineService engine.EngineService, resolve accountResolver) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		thresholds, err := parseWasteThresholds(request)
+		if err != nil {
+			return newParamError(err), nil
+		}
+		session, result := openSession(ctx, engineService, resolve, request)
+		if result != nil {
+			return result, nil
+		}
+		defer session.Close()
+
+		resourceSvc, err := session.Resources(ctx)
+		if err != nil {
+			return newToolError(session.Provider(), "Failed to create resource service", err), nil
+		}
+
+		instances, attachedVolumes, err := resourceSvc.GetStoppedInstances(ctx)
+		if err != nil {
+			return newToolError(session.Provider(), "Failed to get stopped instances", err), nil
+		}
+
+		resp := struct {
+			Instances       []response.StoppedInstance `json:"stopped_instances"`
+			AttachedVolumes []response.UnusedVolume    `json:"attached_volumes"`
+		}{
+			Instances:       response.ConvertStoppedInstances(thresholds.StoppedInstances(instances)),
+			AttachedVolumes: response.ConvertUnusedVolumes(attachedVolumes),
+		}
+
+		data, _ := json.MarshalIndent(resp, "", "  ")
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func makeExpiringReservationsHandler(engineService engine.EngineService, resolve accountResolver) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		thresholds, err := parseWasteThresholds(request)
+		if err != nil {
+			return newParamError(err), nil
+		}
+		session, result := openSession(ctx, engineService, resolve, request)
+		if result != nil {
+			return result, nil
+		}
+		defer session.Close()
+
+		resourceSvc, err := session.Resources(ctx)
+		if err != nil {
+			return newToolError(session.Provider(), "Failed to create resource service", err), nil
+		}
+
+		reservations, err := resourceSvc.GetExpiringReservations(ctx)
+		if err != nil {
+			return newToolError(session.Provider(), "Failed to get expiring reservations", err), nil
+		}
+
+		resp := response.ConvertReservations(thresholds.Reservations(reservations))
+		data, _ := json.MarshalIndent(resp, "", "  ")
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+func makeWasteSummaryHandler(engineService engine.EngineService, resolve accountResolver) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		account, result := resolve(request)
+		if result != nil {
+			return result, nil
+		}
+		thresholds, err := parseWasteThresholds(request)
+		if err != nil {
+			return newParamError(err), nil
+		}
+
+		resp := collectWasteSummary(ctx, engineService, account, thresholds)
+		if resp.Error != "" {
+			return mcp.NewToolResultError("Failed to get waste summary: " + resp.Error), nil
+		}
+
+		data, _ := json.MarshalIndent(resp, "", "  ")
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
@@ -3,423 +3,289 @@ package tools
 import (
 	"context"
 	"encoding/json"
-	"sync"
+	"errors"
+	"time"
 
 	"github.com/elC0mpa/aws-doctor/cmd/mcp/response"
-	awsconfig "github.com/elC0mpa/aws-doctor/service/aws/config"
-	awscostexplorer "github.com/elC0mpa/aws-doctor/service/aws/costexplorer"
-	awsec2 "github.com/elC0mpa/aws-doctor/service/aws/ec2"
-	awssts "github.com/elC0mpa/aws-doctor/service/aws/sts"
-	azurecompute "github.com/elC0mpa/aws-doctor/service/azure/compute"
-	azureconfig "github.com/elC0mpa/aws-doctor/service/azure/config"
-	azurecostmanagement "github.com/elC0mpa/aws-doctor/service/azure/costmanagement"
-	azureidentity "github.com/elC0mpa/aws-doctor/service/azure/identity"
-	gcpbilling "github.com/elC0mpa/aws-doctor/service/gcp/billing"
-	gcpcompute "github.com/elC0mpa/aws-doctor/service/gcp/compute"
-	gcpidentity "github.com/elC0mpa/aws-doctor/service/gcp/identity"
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service"
+	"github.com/elC0mpa/aws-doctor/service/apierror"
+	"github.com/elC0mpa/aws-doctor/service/cache"
+	"github.com/elC0mpa/aws-doctor/service/chargeback"
+	"github.com/elC0mpa/aws-doctor/service/currency"
+	"github.com/elC0mpa/aws-doctor/service/engine"
+	"github.com/elC0mpa/aws-doctor/service/warnings"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
 // RegisterMultiCloudTools registers multi-cloud aggregate tools with the MCP server
-func RegisterMultiCloudTools(s *server.MCPServer, awsRegion, awsProfile, gcpProjectID, gcpBillingAccount, azureSubscriptionID string) {
+func RegisterMultiCloudTools(s *server.MCPServer, awsRegion, awsProfile, gcpProjectID, gcpBillingAccount, azureSubscriptionID string, currencyService currency.CurrencyService, engineService engine.EngineService) {
+	defaults := accounts{
+		awsRegion:           awsRegion,
+		awsProfile:          awsProfile,
+		gcpProjectID:        gcpProjectID,
+		gcpBillingAccount:   gcpBillingAccount,
+		azureSubscriptionID: azureSubscriptionID,
+	}
+
 	// Multi-cloud cost summary
 	s.AddTool(
 		mcp.NewTool("multicloud_get_cost_summary",
-			mcp.WithDescription("Get cost summary across all configured cloud providers (AWS, GCP, Azure). Shows current month vs last month comparison for each provider."),
+			mcp.WithDescription("Get cost summary across all configured cloud providers (AWS, GCP, Azure). Shows current month vs last month comparison for each provider in its billing currency, plus totals converted to the reporting currency."),
+			withAccounts(true),
+		),
+		makeMultiCloudCostSummaryHandler(defaults, currencyService, engineService),
+	)
+
+	// Multi-cloud cost query
+	s.AddTool(
+		mcp.NewTool("multicloud_get_costs",
+			mcp.WithDescription("Get spend in a date range across all configured cloud providers (AWS, GCP, Azure), grouped by service, SKU or a tag and optionally split into months or days. Each provider is reported in its billing currency, with totals converted to the reporting currency."),
+			withCostRange([]string{model.DimensionService, model.DimensionSKU}),
+			withAccounts(true),
 		),
-		makeMultiCloudCostSummaryHandler(awsRegion, awsProfile, gcpProjectID, gcpBillingAccount, azureSubscriptionID),
+		makeMultiCloudCostsHandler(defaults, currencyService, engineService),
 	)
 
 	// Multi-cloud waste summary
 	s.AddTool(
 		mcp.NewTool("multicloud_get_waste_summary",
 			mcp.WithDescription("Get waste detection summary across all configured cloud providers (AWS, GCP, Azure). Shows unused resources for each provider."),
+			withWasteThresholds(true, true),
+			withAccounts(false),
 		),
-		makeMultiCloudWasteSummaryHandler(awsRegion, awsProfile, gcpProjectID, azureSubscriptionID),
+		makeMultiCloudWasteSummaryHandler(defaults, engineService),
 	)
 }
 
-func makeMultiCloudCostSummaryHandler(awsRegion, awsProfile, gcpProjectID, gcpBillingAccount, azureSubscriptionID string) server.ToolHandlerFunc {
+func makeMultiCloudCostSummaryHandler(defaults accounts, currencyService currency.CurrencyService, engineService engine.EngineService) server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		var results []response.ProviderCostSummary
-		var mu sync.Mutex
-		var wg sync.WaitGroup
-
-		// AWS (always available via default credential chain)
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			result := collectAWSCostSummary(ctx, awsRegion, awsProfile)
-			mu.Lock()
-			results = append(results, result)
-			mu.Unlock()
-		}()
-
-		// GCP (only if configured)
-		if gcpProjectID != "" && gcpBillingAccount != "" {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				result := collectGCPCostSummary(ctx, gcpProjectID, gcpBillingAccount)
-				mu.Lock()
-				results = append(results, result)
-				mu.Unlock()
-			}()
+		selected, err := parseAccounts(request, defaults)
+		if err != nil {
+			return newParamError(err), nil
 		}
+		results := engine.CollectAll(ctx, selected.list(true), func(ctx context.Context, account engine.Account) response.ProviderCostSummary {
+			return collectCostSummary(ctx, engineService, account)
+		})
 
-		// Azure (only if configured)
-		if azureSubscriptionID != "" {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				result := collectAzureCostSummary(ctx, azureSubscriptionID)
-				mu.Lock()
-				results = append(results, result)
-				mu.Unlock()
-			}()
+		// Calculate totals in the reporting currency
+		resp := response.MultiCloudCostSummary{
+			Currency: currencyService.GetReportingCurrency(),
 		}
+		for i := range results {
+			r := &results[i]
+			if r.Error != "" {
+				continue
+			}
 
-		wg.Wait()
-
-		// Calculate totals
-		var totalCurrent, totalLast float64
-		currency := "USD"
-		for _, r := range results {
-			if r.Error == "" {
-				totalCurrent += r.CurrentMonthCost
-				totalLast += r.LastMonthCost
-				if r.Currency != "" {
-					currency = r.Currency
-				}
+			current, rate, err := currencyService.Convert(r.CurrentMonthCost, r.Currency)
+			if err != nil {
+				r.ConversionError = err.Error()
+				resp.Excluded = append(resp.Excluded, r.Provider)
+				continue
 			}
-		}
+			last, _, _ := currencyService.Convert(r.LastMonthCost, r.Currency)
 
-		resp := response.MultiCloudCostSummary{
-			Providers: results,
-			Total:     totalCurrent,
-			Currency:  currency,
+			r.ConvertedCurrentMonthCost = &current
+			r.ConvertedLastMonthCost = &last
+			r.ExchangeRate = &rate
+
+			resp.Total += current
+			resp.LastMonthTotal += last
 		}
+		resp.Providers = results
 
 		data, _ := json.MarshalIndent(resp, "", "  ")
 		return mcp.NewToolResultText(string(data)), nil
 	}
 }
 
-func makeMultiCloudWasteSummaryHandler(awsRegion, awsProfile, gcpProjectID, azureSubscriptionID string) server.ToolHandlerFunc {
+func makeMultiCloudCostsHandler(defaults accounts, currencyService currency.CurrencyService, engineService engine.EngineService) server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		var results []response.WasteSummary
-		var mu sync.Mutex
-		var wg sync.WaitGroup
-
-		// AWS
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			result := collectAWSWasteSummary(ctx, awsRegion, awsProfile)
-			if result != nil {
-				mu.Lock()
-				results = append(results, *result)
-				mu.Unlock()
-			}
-		}()
-
-		// GCP (only if configured)
-		if gcpProjectID != "" {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				result := collectGCPWasteSummary(ctx, gcpProjectID)
-				if result != nil {
-					mu.Lock()
-					results = append(results, *result)
-					mu.Unlock()
-				}
-			}()
+		selected, err := parseAccounts(request, defaults)
+		if err != nil {
+			return newParamError(err), nil
+		}
+		query, err := parseCostQuery(request, []string{model.DimensionService, model.DimensionSKU})
+		if err != nil {
+			return newParamError(err), nil
 		}
 
-		// Azure (only if configured)
-		if azureSubscriptionID != "" {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				result := collectAzureWasteSummary(ctx, azureSubscriptionID)
-				if result != nil {
-					mu.Lock()
-					results = append(results, *result)
-					mu.Unlock()
-				}
-			}()
+		results := engine.CollectAll(ctx, selected.list(true), func(ctx context.Context, account engine.Account) response.CostQuery {
+			return collectCostQuery(ctx, engineService, account, query)
+		})
+
+		// Calculate the total in the reporting currency
+		resp := response.MultiCloudCostQuery{
+			Currency: currencyService.GetReportingCurrency(),
 		}
+		for i := range results {
+			r := &results[i]
+			if r.Error != "" {
+				continue
+			}
 
-		wg.Wait()
+			total, rate, err := currencyService.Convert(r.Total, r.Currency)
+			if err != nil {
+				r.ConversionError = err.Error()
+				resp.Excluded = append(resp.Excluded, r.Provider)
+				continue
+			}
 
-		resp := response.MultiCloudWasteSummary{
-			Providers: results,
+			r.ConvertedTotal = &total
+			r.ExchangeRate = &rate
+			resp.Total += total
 		}
+		resp.Providers = results
 
 		data, _ := json.MarshalIndent(resp, "", "  ")
 		return mcp.NewToolResultText(string(data)), nil
 	}
 }
 
-// AWS cost collection
-func collectAWSCostSummary(ctx context.Context, region, profile string) response.ProviderCostSummary {
-	result := response.ProviderCostSummary{
-		Provider: "aws",
-		Currency: "USD",
-	}
-
-	configSvc := awsconfig.NewService()
-	awsCfg, err := configSvc.GetAWSCfg(ctx, region, profile)
-	if err != nil {
-		result.Error = err.Error()
-		return result
-	}
-
-	stsSvc := awssts.NewService(awsCfg)
-	accountInfo, err := stsSvc.GetAccountInfo(ctx)
-	if err != nil {
-		result.Error = err.Error()
-		return result
-	}
-	result.AccountID = accountInfo.AccountID
+func makeMultiCloudWasteSummaryHandler(defaults accounts, engineService engine.EngineService) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		selected, err := parseAccounts(request, defaults)
+		if err != nil {
+			return newParamError(err), nil
+		}
+		thresholds, err := parseWasteThresholds(request)
+		if err != nil {
+			return newParamError(err), nil
+		}
 
-	costSvc := awscostexplorer.NewService(awsCfg)
+		results := engine.CollectAll(ctx, selected.list(false), func(ctx context.Context, account engine.Account) response.WasteSummary {
+			return collectWasteSummary(ctx, engineService, account, thresholds)
+		})
 
-	currentData, err := costSvc.GetCurrentMonthCostsByService(ctx)
-	if err != nil {
-		result.Error = err.Error()
-		return result
-	}
-	currentCosts := response.ConvertCostInfo(currentData)
-	result.CurrentMonthCost = currentCosts.Total
-	result.Currency = currentCosts.Currency
+		resp := response.MultiCloudWasteSummary{
+			Providers: results,
+			ScannedAt: time.Now(),
+		}
 
-	lastData, err := costSvc.GetLastMonthCostsByService(ctx)
-	if err != nil {
-		result.Error = err.Error()
-		return result
-	}
-	lastCosts := response.ConvertCostInfo(lastData)
-	result.LastMonthCost = lastCosts.Total
+		data, _ := json.MarshalIndent(resp, "", "  ")
 
-	result.Difference = result.CurrentMonthCost - result.LastMonthCost
-	if result.LastMonthCost > 0 {
-		result.PercentChange = (result.Difference / result.LastMonthCost) * 100
+		// Only a scan of the server's own accounts is the latest waste resource
+		if len(request.GetArguments()) == 0 {
+			latestWaste.store(string(data), resp.ScannedAt)
+		}
+		return mcp.NewToolResultText(string(data)), nil
 	}
-
-	return result
 }
 
-// GCP cost collection
-func collectGCPCostSummary(ctx context.Context, projectID, billingAccount string) response.ProviderCostSummary {
+// collectCostSummary reads an account's month-to-date and last month totals
+func collectCostSummary(ctx context.Context, engineService engine.EngineService, account engine.Account) response.ProviderCostSummary {
+	costs := engineService.CollectCosts(ctx, account, engine.Options{})
 	result := response.ProviderCostSummary{
-		Provider: "gcp",
-		Currency: "USD",
-	}
-
-	identitySvc, err := gcpidentity.NewService(ctx, projectID)
-	if err != nil {
-		result.Error = err.Error()
-		return result
-	}
-
-	accountInfo, err := identitySvc.GetAccountInfo(ctx)
-	if err != nil {
-		result.Error = err.Error()
-		return result
+		Provider:  account.Provider,
+		AccountID: costs.AccountID,
+		Currency:  "USD",
+		Warnings:  response.ConvertWarnings(costs.Warnings),
 	}
-	result.AccountID = accountInfo.AccountID
-
-	billingSvc, err := gcpbilling.NewService(ctx, projectID, billingAccount)
-	if err != nil {
-		result.Error = err.Error()
+	if costs.Error != nil {
+		result.Error, result.ErrorKind, result.MissingPermission = describeError(account.Provider, costs.Error)
 		return result
 	}
-	defer billingSvc.Close()
 
-	currentData, err := billingSvc.GetCurrentMonthCostsByService(ctx)
-	if err != nil {
-		result.Error = err.Error()
-		return result
-	}
-	currentCosts := response.ConvertCostInfo(currentData)
+	currentCosts := response.ConvertCostInfo(costs.CurrentMonthData)
 	result.CurrentMonthCost = currentCosts.Total
 	result.Currency = currentCosts.Currency
-
-	lastData, err := billingSvc.GetLastMonthCostsByService(ctx)
-	if err != nil {
-		result.Error = err.Error()
-		return result
-	}
-	lastCosts := response.ConvertCostInfo(lastData)
-	result.LastMonthCost = lastCosts.Total
+	result.LastMonthCost = response.ConvertCostInfo(costs.LastMonthData).Total
 
 	result.Difference = result.CurrentMonthCost - result.LastMonthCost
 	if result.LastMonthCost > 0 {
 		result.PercentChange = (result.Difference / result.LastMonthCost) * 100
 	}
 
+	result.CachedAt = costs.CachedAt
+
 	return result
 }
 
-// Azure cost collection
-func collectAzureCostSummary(ctx context.Context, subscriptionID string) response.ProviderCostSummary {
-	result := response.ProviderCostSummary{
-		Provider: "azure",
-		Currency: "USD",
-	}
+// collectCostQuery runs a cost query against an account
+func collectCostQuery(ctx context.Context, engineService engine.EngineService, account engine.Account, query costQuery) (result response.CostQuery) {
+	result = response.CostQuery{Provider: account.Provider}
 
-	cfgSvc, err := azureconfig.NewService(subscriptionID)
-	if err != nil {
-		result.Error = err.Error()
-		return result
-	}
+	collector := warnings.NewCollector()
+	ctx = warnings.WithCollector(ctx, collector)
+	defer func() {
+		result.Warnings = response.ConvertWarnings(collector.List())
+	}()
 
-	identitySvc, err := azureidentity.NewService(subscriptionID, cfgSvc.GetCredential())
+	session, err := engineService.Open(ctx, account)
 	if err != nil {
-		result.Error = err.Error()
+		result.Error, result.ErrorKind, result.MissingPermission = describeError(account.Provider, err)
 		return result
 	}
+	defer session.Close()
 
-	accountInfo, err := identitySvc.GetAccountInfo(ctx)
+	accountInfo, err := session.Identity().GetAccountInfo(ctx)
 	if err != nil {
-		result.Error = err.Error()
+		result.Error, result.ErrorKind, result.MissingPermission = describeError(account.Provider, err)
 		return result
 	}
 	result.AccountID = accountInfo.AccountID
 
-	costSvc, err := azurecostmanagement.NewService(subscriptionID, cfgSvc.GetCredential())
-	if err != nil {
-		result.Error = err.Error()
-		return result
+	costSvc, err := session.Costs(ctx)
+	if err == nil {
+		err = queryCosts(ctx, costSvc, query, &result)
 	}
-
-	currentData, err := costSvc.GetCurrentMonthCostsByService(ctx)
 	if err != nil {
-		result.Error = err.Error()
-		return result
+		result.Error, result.ErrorKind, result.MissingPermission = describeError(account.Provider, err)
 	}
-	currentCosts := response.ConvertCostInfo(currentData)
-	result.CurrentMonthCost = currentCosts.Total
-	result.Currency = currentCosts.Currency
-
-	lastData, err := costSvc.GetLastMonthCostsByService(ctx)
-	if err != nil {
-		result.Error = err.Error()
-		return result
-	}
-	lastCosts := response.ConvertCostInfo(lastData)
-	result.LastMonthCost = lastCosts.Total
-
-	result.Difference = result.CurrentMonthCost - result.LastMonthCost
-	if result.LastMonthCost > 0 {
-		result.PercentChange = (result.Difference / result.LastMonthCost) * 100
-	}
-
 	return result
 }
 
-// AWS waste collection
-func collectAWSWasteSummary(ctx context.Context, region, profile string) *response.WasteSummary {
-	configSvc := awsconfig.NewService()
-	awsCfg, err := configSvc.GetAWSCfg(ctx, region, profile)
-	if err != nil {
-		return nil
-	}
+// queryCosts reads each period of a cost query into result. Periods report the
+// range they were asked for, whatever the provider echoes back.
+func queryCosts(ctx context.Context, costSvc service.CostService, query costQuery, result *response.CostQuery) error {
+	result.StartDate = query.start.Format(dateLayout)
+	result.EndDate = query.end.Format(dateLayout)
+	result.Granularity = query.granularity
+	result.GroupBy = query.groupBy
+	result.Periods = []response.CostPeriod{}
+
+	for _, p := range query.periods() {
+		costs, err := chargeback.GroupedCosts(ctx, query.source, costSvc, p.start, p.end)
+		if err != nil {
+			return err
+		}
 
-	stsSvc := awssts.NewService(awsCfg)
-	accountInfo, err := stsSvc.GetAccountInfo(ctx)
-	if err != nil {
-		return nil
+		costPeriod, currency := response.ConvertCostPeriod(costs)
+		costPeriod.StartDate = p.start.Format(dateLayout)
+		costPeriod.EndDate = p.end.Format(dateLayout)
+		result.Periods = append(result.Periods, costPeriod)
+		result.Total += costPeriod.Total
+		if result.Currency == "" {
+			result.Currency = currency
+		}
 	}
 
-	ec2Svc := awsec2.NewService(awsCfg)
-
-	unusedVolumes, _ := ec2Svc.GetUnusedVolumes(ctx)
-	unusedIPs, _ := ec2Svc.GetUnusedIPs(ctx)
-	stoppedInstances, attachedVolumes, _ := ec2Svc.GetStoppedInstances(ctx)
-	expiringReservations, _ := ec2Svc.GetExpiringReservations(ctx)
-
-	return &response.WasteSummary{
-		Provider:             "aws",
-		AccountID:            accountInfo.AccountID,
-		UnusedVolumes:        response.ConvertUnusedVolumes(unusedVolumes),
-		AttachedVolumes:      response.ConvertUnusedVolumes(attachedVolumes),
-		UnusedIPs:            response.ConvertUnusedIPs(unusedIPs),
-		StoppedInstances:     response.ConvertStoppedInstances(stoppedInstances),
-		ExpiringReservations: response.ConvertReservations(expiringReservations),
+	if result.Currency == "" {
+		result.Currency = "USD"
 	}
+	result.CachedAt = cache.CachedAt(costSvc)
+	return nil
 }
 
-// GCP waste collection
-func collectGCPWasteSummary(ctx context.Context, projectID string) *response.WasteSummary {
-	identitySvc, err := gcpidentity.NewService(ctx, projectID)
-	if err != nil {
-		return nil
-	}
-
-	accountInfo, err := identitySvc.GetAccountInfo(ctx)
-	if err != nil {
-		return nil
-	}
-
-	computeSvc, err := gcpcompute.NewService(ctx, projectID)
-	if err != nil {
-		return nil
-	}
-
-	unusedVolumes, _ := computeSvc.GetUnusedVolumes(ctx)
-	unusedIPs, _ := computeSvc.GetUnusedIPs(ctx)
-	stoppedInstances, attachedVolumes, _ := computeSvc.GetStoppedInstances(ctx)
-	expiringReservations, _ := computeSvc.GetExpiringReservations(ctx)
-
-	return &response.WasteSummary{
-		Provider:             "gcp",
-		AccountID:            accountInfo.AccountID,
-		UnusedVolumes:        response.ConvertUnusedVolumes(unusedVolumes),
-		AttachedVolumes:      response.ConvertUnusedVolumes(attachedVolumes),
-		UnusedIPs:            response.ConvertUnusedIPs(unusedIPs),
-		StoppedInstances:     response.ConvertStoppedInstances(stoppedInstances),
-		ExpiringReservations: response.ConvertReservations(expiringReservations),
+// collectWasteSummary runs every waste check against an account
+func collectWasteSummary(ctx context.Context, engineService engine.EngineService, account engine.Account, opts engine.Options) response.WasteSummary {
+	waste := engineService.CollectWaste(ctx, account, opts)
+	result := response.ConvertWasteResult(waste)
+	if waste.Error != nil {
+		result.Error, result.ErrorKind, result.MissingPermission = describeError(account.Provider, waste.Error)
 	}
+	return result
 }
 
-// Azure waste collection
-func collectAzureWasteSummary(ctx context.Context, subscriptionID string) *response.WasteSummary {
-	cfgSvc, err := azureconfig.NewService(subscriptionID)
-	if err != nil {
-		return nil
-	}
-
-	identitySvc, err := azureidentity.NewService(subscriptionID, cfgSvc.GetCredential())
-	if err != nil {
-		return nil
-	}
-
-	accountInfo, err := identitySvc.GetAccountInfo(ctx)
-	if err != nil {
-		return nil
-	}
-
-	computeSvc, err := azurecompute.NewService(subscriptionID, cfgSvc.GetCredential())
-	if err != nil {
-		return nil
-	}
+// describeError splits a classified provider error into the fields used in responses
+func describeError(provider string, err error) (string, string, string) {
+	err = apierror.Classify(provider, err)
 
-	unusedVolumes, _ := computeSvc.GetUnusedVolumes(ctx)
-	unusedIPs, _ := computeSvc.GetUnusedIPs(ctx)
-	stoppedInstances, attachedVolumes, _ := computeSvc.GetStoppedInstances(ctx)
-	expiringReservations, _ := computeSvc.GetExpiringReservations(ctx)
-
-	return &response.WasteSummary{
-		Provider:             "azure",
-		AccountID:            accountInfo.AccountID,
-		UnusedVolumes:        response.ConvertUnusedVolumes(unusedVolumes),
-		AttachedVolumes:      response.ConvertUnusedVolumes(attachedVolumes),
-		UnusedIPs:            response.ConvertUnusedIPs(unusedIPs),
-		StoppedInstances:     response.ConvertStoppedInstances(stoppedInstances),
-		ExpiringReservations: response.ConvertReservations(expiringReservations),
+	var cloudErr *model.CloudError
+	if errors.As(err, &cloudErr) {
+		return err.Error(), string(cloudErr.Kind), cloudErr.Action
 	}
+	return err.Error(), string(model.ErrorKindUnknown), ""
 }
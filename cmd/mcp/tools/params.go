@@ -0,0 +1,418 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/accountid"
+	"github.com/elC0mpa/aws-doctor/service/engine"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// dateLayout is the format of date parameters
+const dateLayout = "2006-01-02"
+
+// Granularities a cost query can be split by
+const (
+	granularityTotal   = "total"
+	granularityMonthly = "monthly"
+	granularityDaily   = "daily"
+)
+
+// Most periods one cost query may be split into, since each period is a
+// separate billing query
+const (
+	maxMonthlyPeriods = 12
+	maxDailyPeriods   = 31
+)
+
+// wasteDays is the stopped-instance and reservation-expiry window of the
+// resource services. Thresholds can narrow it but not widen it.
+const wasteDays = 30
+
+// datePattern is the format of date parameters, checked before any provider
+// is called like the account formats in accountid
+var datePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// Groupings each provider supports besides tag:<key>
+var (
+	awsGroupings   = []string{model.DimensionService, model.DimensionSKU}
+	gcpGroupings   = []string{model.DimensionService, model.DimensionSKU, model.DimensionProject}
+	azureGroupings = []string{model.DimensionService, model.DimensionSKU, model.DimensionResourceGroup}
+)
+
+// costQuery is a validated date range, split into periods and grouped by source
+type costQuery struct {
+	start       time.Time
+	end         time.Time
+	granularity string
+	groupBy     string
+	source      model.AllocationSource
+}
+
+// period is one [start, end) slice of a cost query
+type period struct {
+	start time.Time
+	end   time.Time
+}
+
+// accounts are the provider accounts a multi-cloud tool queries
+type accounts struct {
+	awsRegion           string
+	awsProfile          string
+	gcpProjectID        string
+	gcpBillingAccount   string
+	azureSubscriptionID string
+}
+
+// accountResolver returns the account of a call, or the result reporting an
+// invalid or missing account parameter
+type accountResolver func(request mcp.CallToolRequest) (engine.Account, *mcp.CallToolResult)
+
+// withAWSAccount adds the parameters that override the server's AWS profile
+// and region. prefix namespaces them on multi-cloud tools.
+func withAWSAccount(prefix string) mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		mcp.WithString(prefix+"profile",
+			mcp.Description("AWS named profile to use instead of the server's AWS_PROFILE"),
+			mcp.Pattern(accountid.AWSProfile.String()),
+		)(tool)
+		mcp.WithString(prefix+"region",
+			mcp.Description("AWS region to use instead of the server's AWS_REGION, such as eu-west-1"),
+			mcp.Pattern(accountid.AWSRegion.String()),
+		)(tool)
+	}
+}
+
+// withGCPAccount adds the parameters that override the server's GCP project
+// and, for cost tools, its billing account
+func withGCPAccount(prefix string, billing bool) mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		mcp.WithString(prefix+"project_id",
+			mcp.Description("GCP project ID to use instead of the server's GCP_PROJECT_ID"),
+			mcp.Pattern(accountid.GCPProject.String()),
+		)(tool)
+		if billing {
+			mcp.WithString(prefix+"billing_account",
+				mcp.Description("GCP billing account ID whose BigQuery export is queried, as XXXXXX-XXXXXX-XXXXXX, instead of the server's GCP_BILLING_ACCOUNT"),
+				mcp.Pattern(accountid.GCPBilling.String()),
+			)(tool)
+		}
+	}
+}
+
+// withAzureAccount adds the parameter that overrides the server's Azure subscription
+func withAzureAccount(prefix string) mcp.ToolOption {
+	return mcp.WithString(prefix+"subscription_id",
+		mcp.Description("Azure subscription ID to use instead of the server's AZURE_SUBSCRIPTION_ID"),
+		mcp.Pattern(accountid.AzureSubscription.String()),
+	)
+}
+
+// withAccounts adds the account overrides of every provider to a multi-cloud tool
+func withAccounts(billing bool) mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		withAWSAccount("aws_")(tool)
+		withGCPAccount("gcp_", billing)(tool)
+		withAzureAccount("azure_")(tool)
+	}
+}
+
+// withCostRange adds the date range, granularity and grouping of a cost query.
+// groupings are the group_by values supported besides tag:<key>.
+func withCostRange(groupings []string) mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		mcp.WithString("start",
+			mcp.Required(),
+			mcp.Description("First day of the range, as YYYY-MM-DD"),
+			mcp.Pattern(datePattern.String()),
+		)(tool)
+		mcp.WithString("end",
+			mcp.Description("Day after the last day of the range, as YYYY-MM-DD. Defaults to today."),
+			mcp.Pattern(datePattern.String()),
+		)(tool)
+		mcp.WithString("granularity",
+			mcp.Description(fmt.Sprintf("Report the range as one period, or split it into calendar months (at most %d) or days (at most %d)", maxMonthlyPeriods, maxDailyPeriods)),
+			mcp.Enum(granularityTotal, granularityMonthly, granularityDaily),
+			mcp.DefaultString(granularityTotal),
+		)(tool)
+		mcp.WithString("group_by",
+			mcp.Description(fmt.Sprintf("Group spend by %s, or by the value of a tag or label as tag:<key>", strings.Join(groupings, ", "))),
+			mcp.DefaultString(model.DimensionService),
+		)(tool)
+	}
+}
+
+// withWasteThresholds adds the thresholds that narrow stopped instances and
+// expiring reservations
+func withWasteThresholds(stopped, expiring bool) mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		if stopped {
+			mcp.WithNumber("min_stopped_days",
+				mcp.Description(fmt.Sprintf("Only report instances stopped for at least this many days, %d or more. Instances whose stop time is unknown are always reported.", wasteDays)),
+				mcp.Min(wasteDays),
+				mcp.DefaultNumber(wasteDays),
+			)(tool)
+		}
+		if expiring {
+			mcp.WithNumber("expiring_within_days",
+				mcp.Description(fmt.Sprintf("Only report reservations expiring, or expired, within this many days, at most %d", wasteDays)),
+				mcp.Min(1),
+				mcp.Max(wasteDays),
+				mcp.DefaultNumber(wasteDays),
+			)(tool)
+		}
+	}
+}
+
+// awsAccount returns the profile and region of a call, falling back to the server's
+func awsAccount(request mcp.CallToolRequest, prefix, region, profile string) (string, string, error) {
+	region, err := stringParam(request, prefix+"region", region, accountid.AWSRegion)
+	if err != nil {
+		return "", "", err
+	}
+	profile, err = stringParam(request, prefix+"profile", profile, accountid.AWSProfile)
+	if err != nil {
+		return "", "", err
+	}
+	return region, profile, nil
+}
+
+// gcpAccount returns the project and billing account of a call, falling back to the server's
+func gcpAccount(request mcp.CallToolRequest, prefix, projectID, billingAccount string) (string, string, error) {
+	projectID, err := stringParam(request, prefix+"project_id", projectID, accountid.GCPProject)
+	if err != nil {
+		return "", "", err
+	}
+	billingAccount, err = stringParam(request, prefix+"billing_account", billingAccount, accountid.GCPBilling)
+	if err != nil {
+		return "", "", err
+	}
+	return projectID, billingAccount, nil
+}
+
+// azureAccount returns the subscription of a call, falling back to the server's
+func azureAccount(request mcp.CallToolRequest, prefix, subscriptionID string) (string, error) {
+	return stringParam(request, prefix+"subscription_id", subscriptionID, accountid.AzureSubscription)
+}
+
+// parseAccounts returns the accounts of a multi-cloud call, falling back to the server's
+func parseAccounts(request mcp.CallToolRequest, defaults accounts) (accounts, error) {
+	var (
+		selected = defaults
+		err      error
+	)
+	selected.awsRegion, selected.awsProfile, err = awsAccount(request, "aws_", defaults.awsRegion, defaults.awsProfile)
+	if err != nil {
+		return selected, err
+	}
+	selected.gcpProjectID, selected.gcpBillingAccount, err = gcpAccount(request, "gcp_", defaults.gcpProjectID, defaults.gcpBillingAccount)
+	if err != nil {
+		return selected, err
+	}
+	selected.azureSubscriptionID, err = azureAccount(request, "azure_", defaults.azureSubscriptionID)
+	return selected, err
+}
+
+// list returns the accounts a multi-cloud call covers: AWS always, GCP and
+// Azure when configured. GCP costs need a billing account as well.
+func (a accounts) list(costs bool) []engine.Account {
+	list := []engine.Account{{Provider: "aws", Region: a.awsRegion, Profile: a.awsProfile}}
+	if a.gcpProjectID != "" && (!costs || a.gcpBillingAccount != "") {
+		list = append(list, engine.Account{Provider: "gcp", ProjectID: a.gcpProjectID, BillingAccount: a.gcpBillingAccount})
+	}
+	if a.azureSubscriptionID != "" {
+		list = append(list, engine.Account{Provider: "azure", SubscriptionID: a.azureSubscriptionID})
+	}
+	return list
+}
+
+// awsResolver resolves the AWS account of a call, which the default
+// credential chain always provides
+func awsResolver(region, profile string) accountResolver {
+	return func(request mcp.CallToolRequest) (engine.Account, *mcp.CallToolResult) {
+		region, profile, err := awsAccount(request, "", region, profile)
+		if err != nil {
+			return engine.Account{}, newParamError(err)
+		}
+		return engine.Account{Provider: "aws", Region: region, Profile: profile}, nil
+	}
+}
+
+// gcpResolver resolves the GCP project of a call, and its billing account
+// when the tool reads costs
+func gcpResolver(projectID, billingAccount string, costs bool) accountResolver {
+	return func(request mcp.CallToolRequest) (engine.Account, *mcp.CallToolResult) {
+		projectID, billingAccount, err := gcpAccount(request, "", projectID, billingAccount)
+		if err != nil {
+			return engine.Account{}, newParamError(err)
+		}
+
+		if projectID == "" {
+			return engine.Account{}, mcp.NewToolResultError("GCP_PROJECT_ID environment variable or project_id parameter is required")
+		}
+		if costs && billingAccount == "" {
+			return engine.Account{}, mcp.NewToolResultError("GCP_BILLING_ACCOUNT environment variable or billing_account parameter is required for cost analysis")
+		}
+		return engine.Account{Provider: "gcp", ProjectID: projectID, BillingAccount: billingAccount}, nil
+	}
+}
+
+// azureResolver resolves the Azure subscription of a call
+func azureResolver(subscriptionID string) accountResolver {
+	return func(request mcp.CallToolRequest) (engine.Account, *mcp.CallToolResult) {
+		subscriptionID, err := azureAccount(request, "", subscriptionID)
+		if err != nil {
+			return engine.Account{}, newParamError(err)
+		}
+
+		if subscriptionID == "" {
+			return engine.Account{}, mcp.NewToolResultError("AZURE_SUBSCRIPTION_ID environment variable or subscription_id parameter is required")
+		}
+		return engine.Account{Provider: "azure", SubscriptionID: subscriptionID}, nil
+	}
+}
+
+// parseCostQuery reads and validates the parameters added by withCostRange
+func parseCostQuery(request mcp.CallToolRequest, groupings []string) (costQuery, error) {
+	var query costQuery
+
+	startDate, err := stringParam(request, "start", "", datePattern)
+	if err != nil {
+		return query, err
+	}
+	if startDate == "" {
+		return query, fmt.Errorf("start is required")
+	}
+	if query.start, err = time.Parse(dateLayout, startDate); err != nil {
+		return query, fmt.Errorf("start %q is not a valid date", startDate)
+	}
+
+	query.end = time.Now().UTC().Truncate(24 * time.Hour)
+	endDate, err := stringParam(request, "end", "", datePattern)
+	if err != nil {
+		return query, err
+	}
+	if endDate != "" {
+		if query.end, err = time.Parse(dateLayout, endDate); err != nil {
+			return query, fmt.Errorf("end %q is not a valid date", endDate)
+		}
+	}
+	if !query.start.Before(query.end) {
+		return query, fmt.Errorf("start must be before end")
+	}
+
+	query.granularity, err = stringParam(request, "granularity", granularityTotal, nil)
+	if err != nil {
+		return query, err
+	}
+	switch query.granularity {
+	case granularityTotal:
+	case granularityMonthly:
+		if months := len(query.periods()); months > maxMonthlyPeriods {
+			return query, fmt.Errorf("monthly granularity covers at most %d months, the range spans %d", maxMonthlyPeriods, months)
+		}
+	case granularityDaily:
+		if days := len(query.periods()); days > maxDailyPeriods {
+			return query, fmt.Errorf("daily granularity covers at most %d days, the range spans %d", maxDailyPeriods, days)
+		}
+	default:
+		return query, fmt.Errorf("unsupported granularity %q, expected %s, %s or %s", query.granularity, granularityTotal, granularityMonthly, granularityDaily)
+	}
+
+	query.groupBy, err = stringParam(request, "group_by", model.DimensionService, nil)
+	if err != nil {
+		return query, err
+	}
+	if key, ok := strings.CutPrefix(query.groupBy, model.DimensionTag+":"); ok && key != "" {
+		query.source = model.AllocationSource{Dimension: model.DimensionTag, Key: key}
+	} else if slices.Contains(groupings, query.groupBy) {
+		query.source = model.AllocationSource{Dimension: query.groupBy}
+	} else {
+		return query, fmt.Errorf("unsupported group_by %q, expected %s or tag:<key>", query.groupBy, strings.Join(groupings, ", "))
+	}
+
+	return query, nil
+}
+
+// periods splits the range by its granularity. Months follow the calendar, so
+// the first and last may be partial.
+func (q costQuery) periods() []period {
+	if q.granularity == granularityTotal {
+		return []period{{start: q.start, end: q.end}}
+	}
+
+	var periods []period
+	for start := q.start; start.Before(q.end); {
+		end := start.AddDate(0, 0, 1)
+		if q.granularity == granularityMonthly {
+			end = time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		}
+		if end.After(q.end) {
+			end = q.end
+		}
+		periods = append(periods, period{start: start, end: end})
+		start = end
+	}
+	return periods
+}
+
+// parseWasteThresholds reads the parameters added by withWasteThresholds
+func parseWasteThresholds(request mcp.CallToolRequest) (engine.Options, error) {
+	stoppedDays, err := intParam(request, "min_stopped_days", wasteDays, wasteDays, math.MaxInt32)
+	if err != nil {
+		return engine.Options{}, err
+	}
+	expiringDays, err := intParam(request, "expiring_within_days", wasteDays, 1, wasteDays)
+	if err != nil {
+		return engine.Options{}, err
+	}
+	return engine.Options{MinStoppedDays: stoppedDays, ExpiringWithinDays: expiringDays}, nil
+}
+
+// stringParam returns a string parameter, or fallback when it is unset or
+// empty. A set value must match pattern, if there is one.
+func stringParam(request mcp.CallToolRequest, name, fallback string, pattern *regexp.Regexp) (string, error) {
+	raw, ok := request.GetArguments()[name]
+	if !ok || raw == nil {
+		return fallback, nil
+	}
+
+	value, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string", name)
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fallback, nil
+	}
+	if pattern != nil && !pattern.MatchString(value) {
+		return "", fmt.Errorf("%s %q does not match %s", name, value, pattern)
+	}
+	return value, nil
+}
+
+// intParam returns a whole-number parameter in [lowest, highest], or fallback when it is unset
+func intParam(request mcp.CallToolRequest, name string, fallback, lowest, highest int) (int, error) {
+	raw, ok := request.GetArguments()[name]
+	if !ok || raw == nil {
+		return fallback, nil
+	}
+
+	value, ok := raw.(float64)
+	if !ok || value != math.Trunc(value) {
+		return 0, fmt.Errorf("%s must be a whole number", name)
+	}
+	if value < float64(lowest) || value > float64(highest) {
+		if highest == math.MaxInt32 {
+			return 0, fmt.Errorf("%s must be at least %d", name, lowest)
+		}
+		return 0, fmt.Errorf("%s must be between %d and %d", name, lowest, highest)
+	}
+	return int(value), nil
+}
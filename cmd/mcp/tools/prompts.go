@@ -0,0 +1,201 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// providerAll selects every configured provider in a prompt
+const providerAll = "all"
+
+// providerTitles name the providers in the text of a prompt
+var providerTitles = map[string]string{
+	"aws":   "AWS",
+	"gcp":   "GCP",
+	"azure": "Azure",
+}
+
+// cleanupCommands are the commands a waste cleanup plan suggests, per provider
+var cleanupCommands = map[string]string{
+	"aws": "`aws ec2 create-snapshot --volume-id <id>` then `aws ec2 delete-volume --volume-id <id>` for volumes, " +
+		"`aws ec2 release-address --allocation-id <id>` for IPs, " +
+		"`aws ec2 create-image --instance-id <id>` then `aws ec2 terminate-instances --instance-ids <id>` for instances",
+	"gcp": "`gcloud compute snapshots create <name> --source-disk <disk> --source-disk-zone <zone>` then `gcloud compute disks delete <disk> --zone <zone>` for disks, " +
+		"`gcloud compute addresses delete <name> --region <region>` for IPs, " +
+		"`gcloud compute machine-images create <name> --source-instance <vm> --source-instance-zone <zone>` then `gcloud compute instances delete <vm> --zone <zone>` for VMs",
+	"azure": "`az snapshot create --source <disk-id> --name <name> --resource-group <group>` then `az disk delete --ids <disk-id>` for disks, " +
+		"`az network public-ip delete --ids <ip-id>` for IPs, " +
+		"`az vm delete --ids <vm-id>` for VMs, keeping their disks until the owner confirms",
+}
+
+// RegisterPrompts registers prompts that walk an assistant through common cost
+// investigations with the tools of this server
+func RegisterPrompts(s *server.MCPServer) {
+	s.AddPrompt(
+		mcp.NewPrompt("monthly_cost_review",
+			mcp.WithPromptDescription("Review a month's spend against the month before: provider and service changes, what drove them, and recommendations"),
+			mcp.WithArgument("provider",
+				mcp.ArgumentDescription("aws, gcp, azure or all (default) for every configured provider"),
+			),
+			mcp.WithArgument("month",
+				mcp.ArgumentDescription("Month to review as YYYY-MM. Defaults to the current month to date."),
+			),
+		),
+		monthlyCostReviewPrompt,
+	)
+
+	s.AddPrompt(
+		mcp.NewPrompt("waste_cleanup_plan",
+			mcp.WithPromptDescription("Turn the waste findings into a reviewed cleanup plan: quick wins, resources that need their owner's approval, and expiring commitments"),
+			mcp.WithArgument("provider",
+				mcp.ArgumentDescription("aws, gcp, azure or all (default) for every configured provider"),
+			),
+			mcp.WithArgument("min_stopped_days",
+				mcp.ArgumentDescription(fmt.Sprintf("Only plan for instances stopped at least this many days, %d or more (default %d)", wasteDays, wasteDays)),
+			),
+		),
+		wasteCleanupPlanPrompt,
+	)
+}
+
+func monthlyCostReviewPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	providers, err := promptProviders(request.Params.Arguments["provider"])
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	month := strings.TrimSpace(request.Params.Arguments["month"])
+	now := time.Now().UTC()
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	if month == "" || month == currentMonth.Format("2006-01") {
+		fmt.Fprintf(&b, "Review %s spend for %s to date against the same days of last month.\n\n", providerNames(providers), currentMonth.Format("January 2006"))
+		b.WriteString("1. ")
+		if len(providers) > 1 {
+			b.WriteString("Call `multicloud_get_cost_summary` for each configured provider's month-to-date and last-month totals, then ")
+		}
+		fmt.Fprintf(&b, "call %s for the service-level comparison of every provider with spend.\n", toolNames(providers, "_get_cost_comparison"))
+		fmt.Fprintf(&b, "2. For the three services that grew the most, call %s with start %s and group_by sku to find the usage behind the growth.\n",
+			toolNames(providers, "_get_costs"), currentMonth.Format(dateLayout))
+	} else {
+		start, err := time.Parse("2006-01", month)
+		if err != nil {
+			return nil, fmt.Errorf("month %q is not a month as YYYY-MM", month)
+		}
+		if start.After(currentMonth) {
+			return nil, fmt.Errorf("month %s has not started yet", month)
+		}
+		end := start.AddDate(0, 1, 0)
+		previous := start.AddDate(0, -1, 0)
+
+		fmt.Fprintf(&b, "Review %s spend for %s against %s.\n\n", providerNames(providers), start.Format("January 2006"), previous.Format("January 2006"))
+		tool := toolNames(providers, "_get_costs")
+		if len(providers) > 1 {
+			tool = "`multicloud_get_costs`"
+		}
+		fmt.Fprintf(&b, "1. Call %s with start %s and end %s, then with start %s and end %s, both grouped by service.\n",
+			tool, start.Format(dateLayout), end.Format(dateLayout), previous.Format(dateLayout), start.Format(dateLayout))
+		fmt.Fprintf(&b, "2. For the three services that grew the most, call %s with start %s, end %s and group_by sku to find the usage behind the growth.\n",
+			toolNames(providers, "_get_costs"), start.Format(dateLayout), end.Format(dateLayout))
+	}
+	fmt.Fprintf(&b, "3. Call %s to put the month in the context of the last six months.\n\n", toolNames(providers, "_get_cost_trend"))
+
+	b.WriteString("Report:\n")
+	if len(providers) > 1 {
+		b.WriteString("- A provider table: Provider, This Period, Last Period, Change, % Change, with a total row from the converted totals in the reporting currency.\n")
+		b.WriteString("- The top 10 services across all providers: Rank, Provider, Service, This Period, Change. Keep each service under its own provider; do not merge equivalent services.\n")
+	} else {
+		b.WriteString("- A service table: Service, This Period, Last Period, Change, % Change, with a total row.\n")
+	}
+	b.WriteString("- Sort by this period's cost, highest first, and round to 2 decimals. Show \"New\" for services absent last period and \"Removed\" for services absent this period; leave out services with no cost in either.\n")
+	b.WriteString("- Recommendations: services that grew more than 20%, new services and the SKUs behind the largest increases")
+	if len(providers) > 1 {
+		b.WriteString(", and any provider with more than 80% of the spend")
+	}
+	b.WriteString(".\n\n")
+
+	b.WriteString("Show amounts in each provider's billing currency and say so when currencies differ. Note that cost data lags by 24 to 48 hours, that a month to date is partial, and when a result carries cached_at. ")
+	b.WriteString("If a provider reports an error or warnings, say what is missing and continue with the others.")
+
+	return mcp.NewGetPromptResult("Monthly cost review", []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(b.String())),
+	}), nil
+}
+
+func wasteCleanupPlanPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	providers, err := promptProviders(request.Params.Arguments["provider"])
+	if err != nil {
+		return nil, err
+	}
+
+	stoppedDays := wasteDays
+	if value := strings.TrimSpace(request.Params.Arguments["min_stopped_days"]); value != "" {
+		stoppedDays, err = strconv.Atoi(value)
+		if err != nil || stoppedDays < wasteDays {
+			return nil, fmt.Errorf("min_stopped_days must be a whole number of at least %d", wasteDays)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Build a cleanup plan for idle %s cloud resources.\n\n", providerNames(providers))
+
+	tool := toolNames(providers, "_get_waste_summary")
+	if len(providers) > 1 {
+		tool = "`multicloud_get_waste_summary`"
+	}
+	fmt.Fprintf(&b, "1. Call %s with min_stopped_days %d. If the cloud-doctor://waste/latest resource is attached and uses the default threshold, use it instead.\n", tool, stoppedDays)
+	b.WriteString("2. Sort every finding into one of three sections:\n")
+	b.WriteString("   - Quick wins: unattached volumes and unused IPs. Snapshot a volume before deleting it.\n")
+	fmt.Fprintf(&b, "   - Needs owner approval: instances stopped for %d days or more and the volumes attached to them, grouped by owner. Flag stop times that are estimated or unknown.\n", stoppedDays)
+	b.WriteString("   - Commitments: reservations expiring soon, to renew or let lapse, and recently expired ones, to check whether on-demand spend replaced them.\n")
+	b.WriteString("3. For each section, give a table with Provider, ID, Name, Region, Owner, Detail and Suggested Action, and the commands to carry the action out:\n")
+	for _, provider := range providers {
+		fmt.Fprintf(&b, "   - %s: %s.\n", providerTitles[provider], cleanupCommands[provider])
+	}
+	b.WriteString("\nStart with a summary of the finding counts per provider and section. ")
+	b.WriteString("Do not run any of the commands; present them for review, since deleting volumes and instances loses data. ")
+	b.WriteString("If a provider reports an error or warnings, say which checks are missing and continue with the others.")
+
+	return mcp.NewGetPromptResult("Waste cleanup plan", []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(b.String())),
+	}), nil
+}
+
+// promptProviders maps the provider argument of a prompt to the providers it covers
+func promptProviders(provider string) ([]string, error) {
+	switch provider = strings.ToLower(strings.TrimSpace(provider)); provider {
+	case "", providerAll:
+		return []string{"aws", "gcp", "azure"}, nil
+	case "aws", "gcp", "azure":
+		return []string{provider}, nil
+	default:
+		return nil, fmt.Errorf("provider %q is not aws, gcp, azure or %s", provider, providerAll)
+	}
+}
+
+// providerNames names the providers of a prompt in its text
+func providerNames(providers []string) string {
+	if len(providers) > 1 {
+		return "multi-cloud"
+	}
+	return providerTitles[providers[0]]
+}
+
+// toolNames lists the per-provider tools with the given suffix
+func toolNames(providers []string, suffix string) string {
+	names := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		names = append(names, "`"+provider+suffix+"`")
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
@@ -0,0 +1,153 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/service/currency"
+	"github.com/elC0mpa/aws-doctor/service/engine"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// resourceMIMEType is the type of every resource; each holds the JSON its tool returns
+const resourceMIMEType = "application/json"
+
+// wasteMaxAge is how long a waste scan is served as cloud-doctor://waste/latest
+// before reading the resource scans again
+const wasteMaxAge = time.Hour
+
+// wasteScan is the latest multi-cloud waste scan of the server's own accounts
+type wasteScan struct {
+	mu        sync.Mutex
+	data      string
+	scannedAt time.Time
+}
+
+// latestWaste is filled by multicloud_get_waste_summary calls without
+// parameters and by reads of cloud-doctor://waste/latest
+var latestWaste wasteScan
+
+func (w *wasteScan) store(data string, scannedAt time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.data = data
+	w.scannedAt = scannedAt
+}
+
+// load returns the latest scan if it is younger than maxAge
+func (w *wasteScan) load(maxAge time.Duration) (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.data == "" || time.Since(w.scannedAt) > maxAge {
+		return "", false
+	}
+	return w.data, true
+}
+
+// RegisterResources exposes cost and waste results of the server's accounts as
+// MCP resources, so clients can attach them as context without calling tools.
+// Costs are read through the engine's cost cache, so a read within its TTL makes no
+// billing API call.
+func RegisterResources(s *server.MCPServer, awsRegion, awsProfile, gcpProjectID, gcpBillingAccount, azureSubscriptionID string, currencyService currency.CurrencyService, engineService engine.EngineService) {
+	defaults := accounts{
+		awsRegion:           awsRegion,
+		awsProfile:          awsProfile,
+		gcpProjectID:        gcpProjectID,
+		gcpBillingAccount:   gcpBillingAccount,
+		azureSubscriptionID: azureSubscriptionID,
+	}
+
+	// AWS (always available via default credential chain)
+	addToolResource(s, "cloud-doctor://aws/costs/current", "AWS current month costs",
+		"AWS costs for the current month, broken down by service",
+		makeCurrentMonthCostsHandler(engineService, awsResolver(awsRegion, awsProfile)))
+	addToolResource(s, "cloud-doctor://aws/costs/trend", "AWS cost trend",
+		"AWS cost trend for the last 6 months with summary statistics",
+		makeCostTrendHandler(engineService, awsResolver(awsRegion, awsProfile)))
+
+	// GCP (only if configured)
+	if gcpProjectID != "" && gcpBillingAccount != "" {
+		addToolResource(s, "cloud-doctor://gcp/costs/current", "GCP current month costs",
+			"GCP costs for the current month, broken down by service",
+			makeCurrentMonthCostsHandler(engineService, gcpResolver(gcpProjectID, gcpBillingAccount, true)))
+		addToolResource(s, "cloud-doctor://gcp/costs/trend", "GCP cost trend",
+			"GCP cost trend for the last 6 months with summary statistics",
+			makeCostTrendHandler(engineService, gcpResolver(gcpProjectID, gcpBillingAccount, true)))
+	}
+
+	// Azure (only if configured)
+	if azureSubscriptionID != "" {
+		addToolResource(s, "cloud-doctor://azure/costs/current", "Azure current month costs",
+			"Azure costs for the current month, broken down by service",
+			makeCurrentMonthCostsHandler(engineService, azureResolver(azureSubscriptionID)))
+		addToolResource(s, "cloud-doctor://azure/costs/trend", "Azure cost trend",
+			"Azure cost trend for the last 6 months with summary statistics",
+			makeCostTrendHandler(engineService, azureResolver(azureSubscriptionID)))
+	}
+
+	addToolResource(s, "cloud-doctor://costs/summary", "Multi-cloud cost summary",
+		"Current month vs last month for every configured provider, with totals in the reporting currency",
+		makeMultiCloudCostSummaryHandler(defaults, currencyService, engineService))
+
+	s.AddResource(
+		mcp.NewResource("cloud-doctor://waste/latest", "Latest waste scan",
+			mcp.WithResourceDescription(fmt.Sprintf("The latest waste scan across every configured provider. A scan older than %s is run again when the resource is read.", wasteMaxAge)),
+			mcp.WithMIMEType(resourceMIMEType),
+		),
+		makeLatestWasteHandler(defaults, engineService),
+	)
+}
+
+// addToolResource serves the result of a tool, called without parameters, as a resource
+func addToolResource(s *server.MCPServer, uri, name, description string, handler server.ToolHandlerFunc) {
+	s.AddResource(
+		mcp.NewResource(uri, name,
+			mcp.WithResourceDescription(description),
+			mcp.WithMIMEType(resourceMIMEType),
+		),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			return readTool(ctx, uri, handler)
+		},
+	)
+}
+
+func makeLatestWasteHandler(defaults accounts, engineService engine.EngineService) server.ResourceHandlerFunc {
+	scan := makeMultiCloudWasteSummaryHandler(defaults, engineService)
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if text, ok := latestWaste.load(wasteMaxAge); ok {
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{URI: request.Params.URI, MIMEType: resourceMIMEType, Text: text},
+			}, nil
+		}
+		return readTool(ctx, request.Params.URI, scan)
+	}
+}
+
+// readTool calls a tool without parameters and returns its JSON, followed by
+// any warnings, as the contents of uri. A tool error fails the read.
+func readTool(ctx context.Context, uri string, handler server.ToolHandlerFunc) ([]mcp.ResourceContents, error) {
+	result, err := WarningsMiddleware(handler)(ctx, mcp.CallToolRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	var contents []mcp.ResourceContents
+	for _, content := range result.Content {
+		text, ok := content.(mcp.TextContent)
+		if !ok {
+			continue
+		}
+		if result.IsError {
+			return nil, errors.New(text.Text)
+		}
+		contents = append(contents, mcp.TextResourceContents{URI: uri, MIMEType: resourceMIMEType, Text: text.Text})
+	}
+	if len(contents) == 0 {
+		return nil, fmt.Errorf("%s returned no content", uri)
+	}
+	return contents, nil
+}
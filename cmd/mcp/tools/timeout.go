@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// TimeoutMiddleware cancels a tool call that runs longer than timeout, so a
+// slow provider cannot hold a shared server's request open indefinitely
+func TimeoutMiddleware(timeout time.Duration) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			result, err := next(ctx, request)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && (err != nil || result == nil || result.IsError) {
+				return mcp.NewToolResultError(fmt.Sprintf("%s timed out after %s", request.Params.Name, timeout)), nil
+			}
+			return result, err
+		}
+	}
+}
+
+// ResourceTimeoutMiddleware cancels a resource read that runs longer than
+// timeout, since reading a resource can query every provider
+func ResourceTimeoutMiddleware(timeout time.Duration) server.ResourceHandlerMiddleware {
+	return func(next server.ResourceHandlerFunc) server.ResourceHandlerFunc {
+		return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			contents, err := next(ctx, request)
+			if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%s timed out after %s", request.Params.URI, timeout)
+			}
+			return contents, err
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package model
+
+import "time"
+
+// Waste categories reported by the REST API
+const (
+	WasteUnusedVolumes        = "unused_volumes"
+	WasteAttachedVolumes      = "attached_volumes"
+	WasteUnusedIPs            = "unused_ips"
+	WasteStoppedInstances     = "stopped_instances"
+	WasteExpiringReservations = "expiring_reservations"
+)
+
+// APIConfig configures the REST API. Address is used by --serve. Requests
+// must carry one of Tokens, or the token in the TokenEnv environment
+// variable, as a bearer token.
+type APIConfig struct {
+	Address  string   `json:"address"`
+	Tokens   []string `json:"tokens"`
+	TokenEnv string   `json:"token_env"`
+}
+
+// APICostsResponse answers GET /v1/costs. Start and End are set for a date
+// range query; without one each provider compares month-to-date spend with
+// the same period last month, and Total and CompareTotal sum the providers in
+// the reporting currency. Excluded lists providers left out of the totals
+// because they could not be converted.
+type APICostsResponse struct {
+	Start             string             `json:"start,omitempty"`
+	End               string             `json:"end,omitempty"`
+	GroupBy           string             `json:"group_by"`
+	ReportingCurrency string             `json:"reporting_currency,omitempty"`
+	Total             *float64           `json:"total,omitempty"`
+	CompareTotal      *float64           `json:"compare_total,omitempty"`
+	Excluded          []string           `json:"excluded_from_total,omitempty"`
+	Providers         []APIProviderCosts `json:"providers"`
+}
+
+// APIProviderCosts is one provider's spend grouped by the requested dimension.
+// The Compare fields hold last month's figures for month-to-date queries.
+type APIProviderCosts struct {
+	Provider      string   `json:"provider"`
+	AccountID     string   `json:"account_id,omitempty"`
+	Start         string   `json:"start,omitempty"`
+	End           string   `json:"end,omitempty"`
+	CompareStart  string   `json:"compare_start,omitempty"`
+	CompareEnd    string   `json:"compare_end,omitempty"`
+	Currency      string   `json:"currency,omitempty"`
+	Total         float64  `json:"total"`
+	CompareTotal  *float64 `json:"compare_total,omitempty"`
+	ChangePercent *float64 `json:"change_percent,omitempty"`
+	// Totals in the reporting currency, for month-to-date queries
+	ConvertedTotal        *float64       `json:"converted_total,omitempty"`
+	ConvertedCompareTotal *float64       `json:"converted_compare_total,omitempty"`
+	ConversionError       string         `json:"conversion_error,omitempty"`
+	Groups                []APICostGroup `json:"groups"`
+	Budgets               []APIBudget    `json:"budgets,omitempty"`
+	CachedAt              *time.Time     `json:"cached_at,omitempty"`
+	Warnings              []APIWarning   `json:"warnings,omitempty"`
+	Error                 *APIError      `json:"error,omitempty"`
+}
+
+// APICostGroup is the spend of one service, tag value, project or resource group
+type APICostGroup struct {
+	Name          string   `json:"name"`
+	Amount        float64  `json:"amount"`
+	CompareAmount *float64 `json:"compare_amount,omitempty"`
+}
+
+// APIBudget is a budget compared with month-to-date and forecast spend
+type APIBudget struct {
+	Name     string  `json:"name"`
+	Scope    string  `json:"scope,omitempty"`
+	Imported bool    `json:"imported"`
+	Amount   float64 `json:"amount"`
+	Actual   float64 `json:"actual"`
+	Forecast float64 `json:"forecast"`
+	Currency string  `json:"currency"`
+	Status   string  `json:"status"`
+}
+
+// APITrendResponse answers GET /v1/trend
+type APITrendResponse struct {
+	Providers []APIProviderTrend `json:"providers"`
+}
+
+// APIProviderTrend is one provider's total spend of each of the last months
+type APIProviderTrend struct {
+	Provider  string          `json:"provider"`
+	AccountID string          `json:"account_id,omitempty"`
+	Currency  string          `json:"currency,omitempty"`
+	Months    []APITrendMonth `json:"months"`
+	CachedAt  *time.Time      `json:"cached_at,omitempty"`
+	Warnings  []APIWarning    `json:"warnings,omitempty"`
+	Error     *APIError       `json:"error,omitempty"`
+}
+
+// APITrendMonth is the total spend of one month
+type APITrendMonth struct {
+	Start string  `json:"start"`
+	End   string  `json:"end"`
+	Total float64 `json:"total"`
+}
+
+// APIWasteResponse answers GET /v1/waste. Owners is set when the findings
+// are grouped by owner.
+type APIWasteResponse struct {
+	Providers []APIProviderWaste `json:"providers"`
+	Owners    []APIOwnerWaste    `json:"owners,omitempty"`
+}
+
+// APIProviderWaste is one provider's waste findings
+type APIProviderWaste struct {
+	Provider  string            `json:"provider"`
+	AccountID string            `json:"account_id,omitempty"`
+	Counts    map[string]int    `json:"counts"`
+	Findings  []APIWasteFinding `json:"findings"`
+	Warnings  []APIWarning      `json:"warnings,omitempty"`
+	Error     *APIError         `json:"error,omitempty"`
+}
+
+// APIWasteFinding is a single wasted resource. Category is one of the waste
+// categories accepted by the category query parameter.
+type APIWasteFinding struct {
+	Category string            `json:"category"`
+	ID       string            `json:"id"`
+	Name     string            `json:"name,omitempty"`
+	Region   string            `json:"region,omitempty"`
+	Detail   string            `json:"detail,omitempty"`
+	Owner    string            `json:"owner,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+}
+
+// APIOwnerWaste counts the findings of one owner across providers
+type APIOwnerWaste struct {
+	Owner     string         `json:"owner"`
+	Counts    map[string]int `json:"counts"`
+	Resources []string       `json:"resources"`
+}
+
+// APIProvidersResponse answers GET /v1/providers
+type APIProvidersResponse struct {
+	Providers []APIProvider `json:"providers"`
+}
+
+// APIProvider describes the account a provider is queried with when a
+// request does not name one. Default providers are queried when a request
+// names no provider; a provider without an account can only be queried by
+// naming one.
+type APIProvider struct {
+	Name           string `json:"name"`
+	Default        bool   `json:"default"`
+	Account        string `json:"account,omitempty"`
+	Region         string `json:"region,omitempty"`
+	BillingAccount bool   `json:"billing_account,omitempty"`
+}
+
+// APIWarning is a problem that made a provider's result partial
+type APIWarning struct {
+	Kind    string `json:"kind"`
+	Scope   string `json:"scope,omitempty"`
+	Action  string `json:"missing_permission,omitempty"`
+	Message string `json:"message"`
+}
+
+// APIError is a failed provider, or the body of a failed request
+type APIError struct {
+	Kind    string `json:"kind,omitempty"`
+	Action  string `json:"missing_permission,omitempty"`
+	Message string `json:"message"`
+}
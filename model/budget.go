@@ -0,0 +1,42 @@
+package model
+
+// Budget statuses
+const (
+	BudgetOK      = "ok"
+	BudgetWarn    = "warn"
+	BudgetBreach  = "breach"
+	BudgetUnknown = "unknown"
+)
+
+// DefaultBudgetWarnPercent is the share of a budget that triggers a warning
+// when no warn_percent is configured
+const DefaultBudgetWarnPercent = 80
+
+// BudgetConfig declares a monthly budget in the provider's billing currency.
+// Account, Service and TagKey/TagValue narrow what the budget covers; with
+// none of them set it covers the provider's total spend.
+type BudgetConfig struct {
+	Name        string  `json:"name"`
+	Provider    string  `json:"provider"`
+	Account     string  `json:"account"`
+	Service     string  `json:"service"`
+	TagKey      string  `json:"tag_key"`
+	TagValue    string  `json:"tag_value"`
+	Amount      float64 `json:"amount"`
+	WarnPercent float64 `json:"warn_percent"`
+}
+
+// BudgetStatus compares a budget with month-to-date and forecast spend.
+// Imported budgets come from the provider's own budget API; Actual and
+// Forecast are zero with BudgetUnknown status when the API reports no spend.
+type BudgetStatus struct {
+	Name     string
+	Provider string
+	Scope    string
+	Imported bool
+	Amount   float64
+	Actual   float64
+	Forecast float64
+	Unit     string
+	Status   string
+}
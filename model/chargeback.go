@@ -0,0 +1,77 @@
+package model
+
+import "time"
+
+// Cost allocation dimensions a chargeback source can group spend by
+const (
+	DimensionTag           = "tag"
+	DimensionResourceGroup = "resource_group" // Azure only
+	DimensionProject       = "project"        // GCP only
+	DimensionService       = "service"        // every provider, not for chargeback
+	DimensionSKU           = "sku"            // every provider, not for chargeback
+)
+
+// Rules for distributing shared and unallocated spend across teams
+const (
+	SplitEven         = "even"
+	SplitProportional = "proportional"
+	SplitNone         = "none"
+)
+
+// UnallocatedTeam collects spend that is not mapped to a team and not split
+const UnallocatedTeam = "(unallocated)"
+
+// ChargebackMapping is the mapping file that assigns spend to teams.
+// Teams maps a team to the tag values, resource groups or projects that belong
+// to it; Shared lists values whose spend is split across every team.
+// SharedSplit defaults to proportional and UnallocatedSplit to none.
+type ChargebackMapping struct {
+	Sources          []AllocationSource  `json:"sources"`
+	Teams            map[string][]string `json:"teams"`
+	Shared           []string            `json:"shared"`
+	SharedSplit      string              `json:"shared_split"`
+	UnallocatedSplit string              `json:"unallocated_split"`
+}
+
+// AllocationSource selects how one provider's spend is grouped. An empty
+// Provider applies to every provider; Key is the tag or label key for the tag
+// dimension.
+type AllocationSource struct {
+	Provider  string `json:"provider"`
+	Dimension string `json:"dimension"`
+	Key       string `json:"key"`
+}
+
+// ProviderChargeback is one provider's spend grouped by its allocation source
+type ProviderChargeback struct {
+	Provider        string
+	AccountID       string
+	Source          AllocationSource
+	Costs           *CostInfo
+	Currency        string
+	Rate            float64
+	ConversionError error
+	CachedAt        *time.Time
+	Warnings        []Warning
+	Error           error
+}
+
+// TeamCharge is a team's spend in the reporting currency. Direct holds the
+// spend mapped to the team per provider; Shared and Unallocated are the team's
+// portion of split spend.
+type TeamCharge struct {
+	Team        string
+	Direct      map[string]float64
+	Shared      float64
+	Unallocated float64
+	Total       float64
+}
+
+// ChargebackReport is the spend of every team for one period
+type ChargebackReport struct {
+	Start     string
+	End       string
+	Currency  string
+	Teams     []TeamCharge
+	Providers []ProviderChargeback
+}
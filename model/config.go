@@ -0,0 +1,73 @@
+package model
+
+// Config holds settings loaded from the cloud-doctor config file
+type Config struct {
+	Currency      CurrencyConfig      `json:"currency"`
+	History       HistoryConfig       `json:"history"`
+	Cache         CacheConfig         `json:"cache"`
+	Resilience    ResilienceConfig    `json:"resilience"`
+	Waste         WasteConfig         `json:"waste"`
+	Tagging       TaggingConfig       `json:"tagging"`
+	Chargeback    ChargebackConfig    `json:"chargeback"`
+	Budgets       []BudgetConfig      `json:"budgets"`
+	Notifications NotificationsConfig `json:"notifications"`
+	Email         EmailConfig         `json:"email"`
+	Daemon        DaemonConfig        `json:"daemon"`
+	Metrics       MetricsConfig       `json:"metrics"`
+	API           APIConfig           `json:"api"`
+	Dashboard     DashboardConfig     `json:"dashboard"`
+}
+
+// CurrencyConfig controls how multi-cloud totals are normalized to a single currency.
+// Rates are expressed as units of each currency per one unit of Base, which matches
+// the layout of most exchange rate feeds (e.g. {"base": "USD", "rates": {"CAD": 1.37}}).
+type CurrencyConfig struct {
+	ReportingCurrency string             `json:"reporting_currency"`
+	Base              string             `json:"base"`
+	Rates             map[string]float64 `json:"rates"`
+	RatesFile         string             `json:"rates_file"`
+}
+
+// HistoryConfig controls where run snapshots are stored
+type HistoryConfig struct {
+	Dir string `json:"dir"`
+}
+
+// CacheConfig controls the on-disk cache for cost API responses.
+// TTL is a Go duration string such as "30m" or "6h".
+type CacheConfig struct {
+	Dir      string `json:"dir"`
+	TTL      string `json:"ttl"`
+	Disabled bool   `json:"disabled"`
+}
+
+// ResilienceConfig controls retries, rate limits and timeouts for provider API calls.
+// Durations are Go duration strings; RateLimits maps a provider (aws, gcp, azure)
+// to the maximum requests per second sent to that provider. MaxRetries is nil
+// when unset, which keeps the default; 0 turns retries off.
+type ResilienceConfig struct {
+	MaxRetries *int               `json:"max_retries"`
+	BaseDelay  string             `json:"base_delay"`
+	MaxDelay   string             `json:"max_delay"`
+	Timeout    string             `json:"timeout"`
+	RateLimits map[string]float64 `json:"rate_limits"`
+}
+
+// WasteConfig controls how waste findings are attributed. OwnerTagKeys are tag
+// or label keys checked in order to resolve a resource's owner.
+type WasteConfig struct {
+	OwnerTagKeys []string `json:"owner_tag_keys"`
+}
+
+// TaggingConfig is the tag policy checked by the tag audit. AllowedValues maps
+// a required key to its permitted values; keys without an entry accept any
+// non-empty value.
+type TaggingConfig struct {
+	RequiredKeys  []string            `json:"required_keys"`
+	AllowedValues map[string][]string `json:"allowed_values"`
+}
+
+// ChargebackConfig points at the mapping file used by the chargeback report
+type ChargebackConfig struct {
+	MappingFile string `json:"mapping_file"`
+}
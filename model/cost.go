@@ -6,6 +6,12 @@ type DateInterval struct {
 	End   *string
 }
 
+// Breakdowns of a single service's spend, for drilling into a service
+const (
+	BreakdownDaily    = "daily"    // grouped by day as YYYY-MM-DD
+	BreakdownResource = "resource" // grouped by resource ID
+)
+
 // CostInfo contains cost data for a time period
 type CostInfo struct {
 	DateInterval
@@ -24,3 +30,11 @@ type ServiceCost struct {
 	Amount float64
 	Unit   string
 }
+
+// ConvertedCost holds provider totals expressed in the reporting currency
+type ConvertedCost struct {
+	Currency         string
+	Rate             float64
+	CurrentTotalCost float64
+	LastTotalCost    float64
+}
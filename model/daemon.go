@@ -0,0 +1,39 @@
+package model
+
+// Scheduled check modes. An anomalies check is a cost comparison that also
+// flags services whose spend grew unusually fast.
+const (
+	CheckCost      = "cost"
+	CheckTrend     = "trend"
+	CheckWaste     = "waste"
+	CheckAnomalies = "anomalies"
+)
+
+// Defaults for anomaly detection: a service is anomalous when its
+// month-to-date spend grew by at least this percent and this amount compared
+// with the same period last month
+const (
+	DefaultAnomalyPercent   = 50
+	DefaultAnomalyMinAmount = 10
+)
+
+// DaemonConfig lists the checks run by --serve. With RunOnStart every check
+// also runs once when the daemon starts.
+type DaemonConfig struct {
+	RunOnStart bool             `json:"run_on_start"`
+	Checks     []ScheduledCheck `json:"checks"`
+}
+
+// ScheduledCheck is a report run on a cron schedule such as "0 8 * * 1-5" or
+// "@every 6h". Provider overrides --provider. Notify and EmailEnv deliver the
+// report, but only when its alerts changed since the check's previous run.
+type ScheduledCheck struct {
+	Name             string  `json:"name"`
+	Mode             string  `json:"mode"`
+	Schedule         string  `json:"schedule"`
+	Provider         string  `json:"provider"`
+	Notify           bool    `json:"notify"`
+	EmailEnv         string  `json:"email_env"`
+	AnomalyPercent   float64 `json:"anomaly_percent"`
+	AnomalyMinAmount float64 `json:"anomaly_min_amount"`
+}
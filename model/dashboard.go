@@ -0,0 +1,8 @@
+package model
+
+// DashboardConfig configures the web dashboard. Address is used by --serve.
+// The dashboard reads the reports without authentication, so it must
+// listen on a loopback address such as localhost:8081.
+type DashboardConfig struct {
+	Address string `json:"address"`
+}
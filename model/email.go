@@ -0,0 +1,28 @@
+package model
+
+// SMTP connection security modes
+const (
+	SMTPStartTLS = "starttls"
+	SMTPTLS      = "tls"
+	SMTPNone     = "none"
+)
+
+// DefaultEmailEnvironment is the recipient list used when --email-env is not set
+const DefaultEmailEnvironment = "default"
+
+// EmailConfig configures report delivery over SMTP. Security is starttls
+// (default), tls for implicit TLS such as port 465, or none for local relays.
+// PasswordEnv names an environment variable holding the password so it does
+// not have to be stored in the config file. Recipients maps an environment
+// name to the addresses that receive its reports.
+type EmailConfig struct {
+	Host        string              `json:"host"`
+	Port        int                 `json:"port"`
+	Security    string              `json:"security"`
+	Username    string              `json:"username"`
+	Password    string              `json:"password"`
+	PasswordEnv string              `json:"password_env"`
+	From        string              `json:"from"`
+	Recipients  map[string][]string `json:"recipients"`
+	AttachCSV   bool                `json:"attach_csv"`
+}
@@ -0,0 +1,64 @@
+package model
+
+import "fmt"
+
+// ErrorKind classifies provider API failures so they can be reported consistently
+type ErrorKind string
+
+const (
+	ErrorKindAuth             ErrorKind = "auth_failure"
+	ErrorKindPermissionDenied ErrorKind = "permission_denied"
+	ErrorKindAPINotEnabled    ErrorKind = "api_not_enabled"
+	ErrorKindThrottled        ErrorKind = "throttled"
+	ErrorKindEmptyData        ErrorKind = "empty_data"
+	ErrorKindUnknown          ErrorKind = "unknown"
+)
+
+// CloudError is a classified provider error. Action holds the missing IAM
+// action or permission when the provider reports it.
+type CloudError struct {
+	Provider string
+	Kind     ErrorKind
+	Action   string
+	Message  string
+	Err      error
+}
+
+func (e *CloudError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = string(e.Kind)
+	}
+	if e.Action != "" {
+		msg = fmt.Sprintf("%s (missing %s)", msg, e.Action)
+	}
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+func (e *CloudError) Unwrap() error {
+	return e.Err
+}
+
+// Warning describes a non-fatal problem, such as a zone or region that could not be
+// scanned, that makes a result partial rather than failed
+type Warning struct {
+	Provider string
+	Kind     ErrorKind
+	Scope    string
+	Action   string
+	Message  string
+}
+
+func (w Warning) String() string {
+	msg := w.Message
+	if w.Scope != "" {
+		msg = fmt.Sprintf("%s: %s", w.Scope, msg)
+	}
+	if w.Action != "" {
+		msg = fmt.Sprintf("%s (missing %s)", msg, w.Action)
+	}
+	return msg
+}
@@ -1,10 +1,67 @@
 package model
 
+import "time"
+
+// GroupByOwner groups the waste report by the owner resolved from tags
+const GroupByOwner = "owner"
+
 type Flags struct {
 	// Common flags
 	Provider string
 	Trend    bool
 	Waste    bool
+	TagAudit bool
+	GroupBy  string
+
+	// Compare spend with the budgets defined in each provider's budget API
+	ImportBudgets bool
+
+	// Comma-separated policy rules that make the run exit non-zero
+	FailOn string
+
+	// Send the run's digest to the configured notification channels
+	Notify bool
+
+	// Email the report to the recipients of an environment in the config file
+	SendEmail bool
+	EmailEnv  string
+
+	// Run the scheduled checks from the config file as a long-running daemon
+	Serve bool
+
+	// Address of the Prometheus metrics endpoint, alone or alongside --serve
+	MetricsAddr string
+
+	// Address of the REST API, alone or alongside --serve
+	APIAddr string
+
+	// Address of the web dashboard, alone or alongside --serve
+	DashboardAddr string
+
+	// Browse the reports in an interactive terminal UI
+	TUI bool
+
+	// Chargeback flags
+	Chargeback  bool
+	MappingPath string
+	Month       string
+	CSVPath     string
+
+	// Config file and reporting flags
+	ConfigPath string
+	Currency   string
+
+	// History flags
+	Diff      bool
+	DiffFrom  string
+	NoHistory bool
+
+	// Cache flags
+	NoCache  bool
+	CacheTTL time.Duration
+
+	// Per-call timeout for provider API requests
+	Timeout time.Duration
 
 	// AWS-specific flags
 	Region  string
@@ -0,0 +1,66 @@
+package model
+
+import "time"
+
+// Snapshot kinds stored in the history
+const (
+	SnapshotKindCost  = "cost"
+	SnapshotKindWaste = "waste"
+)
+
+// Waste finding categories
+const (
+	FindingUnusedVolume    = "unused_volume"
+	FindingAttachedVolume  = "attached_volume"
+	FindingUnusedIP        = "unused_ip"
+	FindingStoppedInstance = "stopped_instance"
+	FindingReservation     = "reservation"
+)
+
+// Snapshot is a persisted record of one provider's results from a single run
+type Snapshot struct {
+	ID        string         `json:"id"`
+	Timestamp time.Time      `json:"timestamp"`
+	Provider  string         `json:"provider"`
+	AccountID string         `json:"account_id"`
+	Kind      string         `json:"kind"`
+	Cost      *CostSnapshot  `json:"cost,omitempty"`
+	Waste     []WasteFinding `json:"waste,omitempty"`
+}
+
+// CostSnapshot holds the month-to-date cost figures captured by a run
+type CostSnapshot struct {
+	PeriodStart  string             `json:"period_start"`
+	PeriodEnd    string             `json:"period_end"`
+	Currency     string             `json:"currency"`
+	CurrentTotal float64            `json:"current_total"`
+	LastTotal    float64            `json:"last_total"`
+	Services     map[string]float64 `json:"services"`
+}
+
+// WasteFinding identifies a single waste item so it can be tracked across runs
+type WasteFinding struct {
+	Category string `json:"category"`
+	ID       string `json:"id"`
+	Detail   string `json:"detail"`
+}
+
+// ServiceCostDelta is the change in one service's cost between two snapshots
+type ServiceCostDelta struct {
+	Name     string
+	Previous float64
+	Current  float64
+}
+
+// SnapshotDiff compares a run's results against an earlier snapshot
+type SnapshotDiff struct {
+	Provider   string
+	AccountID  string
+	Kind       string
+	Baseline   *Snapshot
+	Current    *Snapshot
+	New        []WasteFinding
+	Resolved   []WasteFinding
+	Persisting []WasteFinding
+	Services   []ServiceCostDelta
+}
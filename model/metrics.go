@@ -0,0 +1,17 @@
+package model
+
+// DefaultMetricsInterval is how often the Prometheus exporter collects
+const DefaultMetricsInterval = "15m"
+
+// MetricsConfig configures the Prometheus exporter. Address is used by
+// --serve; Interval is a Go duration string for how often metrics are
+// collected again. VolumeGBPrice and IPMonthPrice estimate waste cost per
+// GiB-month of unused storage and per unused IP address a month, for the
+// exporter and the waste-cost --fail-on rule. The prices are nil when unset,
+// which keeps the defaults; 0 leaves that kind of waste unpriced.
+type MetricsConfig struct {
+	Address       string   `json:"address"`
+	Interval      string   `json:"interval"`
+	VolumeGBPrice *float64 `json:"volume_gb_price"`
+	IPMonthPrice  *float64 `json:"ip_month_price"`
+}
@@ -0,0 +1,79 @@
+package model
+
+import "time"
+
+// Notification channel types
+const (
+	ChannelSlack   = "slack"
+	ChannelTeams   = "teams"
+	ChannelWebhook = "webhook"
+)
+
+// DefaultTopMovers is how many services with the largest cost change a digest lists
+const DefaultTopMovers = 5
+
+// NotificationsConfig lists where run digests are sent with --notify
+type NotificationsConfig struct {
+	Channels  []NotificationChannel `json:"channels"`
+	TopMovers int                   `json:"top_movers"`
+}
+
+// NotificationChannel is a Slack or Teams incoming webhook, or any HTTP endpoint
+// accepting JSON. Template is a Go text/template executed with the Digest: for
+// Slack and Teams it replaces the message text, for webhooks it is the request
+// body, which otherwise is the Digest as JSON. Headers are sent with webhook
+// requests, e.g. for an Authorization token.
+type NotificationChannel struct {
+	Name     string            `json:"name"`
+	Type     string            `json:"type"`
+	URL      string            `json:"url"`
+	Template string            `json:"template"`
+	Headers  map[string]string `json:"headers"`
+}
+
+// Digest is the summary of a run sent to notification channels
+type Digest struct {
+	Title             string        `json:"title"`
+	Mode              string        `json:"mode"`
+	Timestamp         time.Time     `json:"timestamp"`
+	ReportingCurrency string        `json:"reporting_currency,omitempty"`
+	TotalCurrent      float64       `json:"total_current,omitempty"`
+	TotalLast         float64       `json:"total_last,omitempty"`
+	Costs             []DigestCost  `json:"costs,omitempty"`
+	TopMovers         []CostMover   `json:"top_movers,omitempty"`
+	Anomalies         []CostMover   `json:"anomalies,omitempty"`
+	Waste             []DigestWaste `json:"waste,omitempty"`
+}
+
+// DigestCost is one provider's month-to-date cost against the same period last month
+type DigestCost struct {
+	Provider      string  `json:"provider"`
+	AccountID     string  `json:"account_id"`
+	Current       float64 `json:"current"`
+	Last          float64 `json:"last"`
+	Unit          string  `json:"unit"`
+	ChangePercent float64 `json:"change_percent"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// CostMover is a service whose cost changed between the two periods
+type CostMover struct {
+	Provider string  `json:"provider"`
+	Service  string  `json:"service"`
+	Current  float64 `json:"current"`
+	Last     float64 `json:"last"`
+	Change   float64 `json:"change"`
+	Unit     string  `json:"unit"`
+}
+
+// DigestWaste counts one provider's waste findings by category
+type DigestWaste struct {
+	Provider             string `json:"provider"`
+	AccountID            string `json:"account_id"`
+	UnusedVolumes        int    `json:"unused_volumes"`
+	UnusedIPs            int    `json:"unused_ips"`
+	StoppedInstances     int    `json:"stopped_instances"`
+	ExpiringReservations int    `json:"expiring_reservations"`
+	Total                int    `json:"total"`
+	Error                string `json:"error,omitempty"`
+}
@@ -0,0 +1,57 @@
+package model
+
+// Exit codes set by the CLI. Policy codes are only used with --fail-on; when
+// several rules fail the first one listed decides the code.
+const (
+	ExitOK            = 0
+	ExitError         = 1
+	ExitUsage         = 2
+	ExitBudget        = 3
+	ExitWaste         = 4
+	ExitNewWaste      = 5
+	ExitCostIncrease  = 6
+	ExitProviderError = 7
+)
+
+// Policy rule kinds accepted by --fail-on
+const (
+	PolicyBudgetBreach  = "budget"
+	PolicyBudgetWarn    = "budget-warn"
+	PolicyWaste         = "waste"
+	PolicyWasteCost     = "waste-cost"
+	PolicyNewWaste      = "new-waste"
+	PolicyCostIncrease  = "increase"
+	PolicyProviderError = "error"
+)
+
+// PolicyRule is one --fail-on condition. Threshold is the finding count for
+// waste, the estimated monthly USD for waste-cost and the percentage for
+// increase.
+type PolicyRule struct {
+	Kind      string
+	Threshold float64
+}
+
+// PolicyViolation is a rule that failed for a run
+type PolicyViolation struct {
+	Rule     string
+	Message  string
+	ExitCode int
+}
+
+// PolicyReport summarizes a run for CI pipelines. WasteCost is the estimated
+// monthly USD cost of the waste findings and MaxIncreasePercent the largest
+// month-over-month increase of any provider.
+type PolicyReport struct {
+	Mode               string
+	Providers          int
+	ProviderErrors     int
+	BudgetBreaches     int
+	BudgetWarnings     int
+	WasteFindings      int
+	WasteCost          float64
+	NewWasteFindings   int
+	MaxIncreasePercent float64
+	Violations         []PolicyViolation
+	ExitCode           int
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // AccountInfo represents cloud account/project identity
 type AccountInfo struct {
 	Provider    string
@@ -8,23 +10,39 @@ type AccountInfo struct {
 }
 
 // UnusedVolume represents an unused storage volume
+// Region holds the region or zone; Owner is resolved from Tags.
 type UnusedVolume struct {
 	ID     string
 	SizeGB int32
 	Status string // "available", "attached_stopped"
+	Region string
+	Tags   map[string]string
+	Owner  string
 }
 
 // StoppedInstance represents a stopped compute instance
+// StoppedDays is -1 when the stop time is unknown; StoppedAtEstimated marks a
+// stop time derived from indirect data, such as the last launch time on AWS or
+// the last status change on Azure, so StoppedDays is approximate.
 type StoppedInstance struct {
-	ID          string
-	Name        string
-	StoppedDays int
+	ID                 string
+	Name               string
+	StoppedDays        int
+	StoppedAtEstimated bool
+	Region             string
+	InstanceType       string
+	LaunchTime         *time.Time
+	Tags               map[string]string
+	Owner              string
 }
 
 // UnusedIP represents an unassociated IP address
 type UnusedIP struct {
 	Address      string
 	AllocationID string
+	Region       string
+	Tags         map[string]string
+	Owner        string
 }
 
 // Reservation represents a reserved instance/commitment
@@ -44,9 +62,29 @@ type ProviderCostResult struct {
 	CurrentTotalCost string
 	LastTotalCost    string
 	TrendData        []CostInfo
+	Currency         string
+	Converted        *ConvertedCost
+	ConversionError  error
+	CachedAt         *time.Time
+	Budgets          []BudgetStatus
+	Anomalies        []CostMover
+	Warnings         []Warning
 	Error            error
 }
 
+// ServiceCosts is one provider's spend on a single service, broken down by
+// day or by resource
+type ServiceCosts struct {
+	Provider  string
+	AccountID string
+	Service   string
+	Breakdown string
+	Costs     *CostInfo
+	CachedAt  *time.Time
+	Warnings  []Warning
+	Error     error
+}
+
 // ProviderWasteResult represents waste detection results for a single provider
 type ProviderWasteResult struct {
 	Provider             string
@@ -56,5 +94,16 @@ type ProviderWasteResult struct {
 	UnusedIPs            []UnusedIP
 	StoppedInstances     []StoppedInstance
 	ExpiringReservations []Reservation
+	Warnings             []Warning
 	Error                error
 }
+
+// OwnerWaste groups waste findings from every provider by resolved owner
+type OwnerWaste struct {
+	Owner            string
+	UnusedVolumes    int
+	AttachedVolumes  int
+	UnusedIPs        int
+	StoppedInstances int
+	Resources        []string // "provider:id" for each finding
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// Run modes recorded on a RunResult
+const (
+	RunModeCost       = "cost"
+	RunModeTrend      = "trend"
+	RunModeWaste      = "waste"
+	RunModeTags       = "tags"
+	RunModeChargeback = "chargeback"
+)
+
+// RunResult collects the provider results produced by a single invocation
+type RunResult struct {
+	Mode       string
+	Timestamp  time.Time
+	Costs      []ProviderCostResult
+	Waste      []ProviderWasteResult
+	TagAudits  []ProviderTagAudit
+	Chargeback *ChargebackReport
+}
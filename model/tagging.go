@@ -0,0 +1,54 @@
+package model
+
+// Untagged is the cost group key for spend that carries no value for a tag key
+const Untagged = "(untagged)"
+
+// Tag violation reasons
+const (
+	TagViolationMissing = "missing"
+	TagViolationInvalid = "invalid"
+)
+
+// TaggedResource is a resource and the tags or labels found on it
+type TaggedResource struct {
+	ID     string
+	Name   string
+	Type   string
+	Region string
+	Tags   map[string]string
+}
+
+// TagViolation is a required key that is missing from a resource or holds a
+// value outside the allowed list
+type TagViolation struct {
+	ResourceID   string
+	ResourceType string
+	Key          string
+	Reason       string // "missing", "invalid"
+	Value        string
+}
+
+// TagSpend breaks one required key's spend down by compliance. InvalidCost is
+// spend whose value is not in the allowed list for the key.
+type TagSpend struct {
+	Key          string
+	TotalCost    float64
+	UntaggedCost float64
+	InvalidCost  float64
+	Unit         string
+}
+
+// ProviderTagAudit represents tag compliance results for a single provider.
+// SpendError is set when costs by tag could not be read; resource results are
+// still reported.
+type ProviderTagAudit struct {
+	Provider              string
+	AccountID             string
+	ResourceCount         int
+	NonCompliantResources int
+	Violations            []TagViolation
+	Spend                 []TagSpend
+	SpendError            error
+	Warnings              []Warning
+	Error                 error
+}
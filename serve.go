@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/anomaly"
+	"github.com/elC0mpa/aws-doctor/service/api"
+	"github.com/elC0mpa/aws-doctor/service/currency"
+	"github.com/elC0mpa/aws-doctor/service/daemon"
+	"github.com/elC0mpa/aws-doctor/service/dashboard"
+	"github.com/elC0mpa/aws-doctor/service/email"
+	"github.com/elC0mpa/aws-doctor/service/engine"
+	"github.com/elC0mpa/aws-doctor/service/history"
+	"github.com/elC0mpa/aws-doctor/service/metrics"
+	"github.com/elC0mpa/aws-doctor/service/notify"
+	"github.com/elC0mpa/aws-doctor/utils"
+	"golang.org/x/sync/errgroup"
+)
+
+// daemonStateFile holds the alerts of each check's last run, next to the history
+const daemonStateFile = "daemon-state.json"
+
+// shutdownTimeout bounds how long the HTTP server waits for open requests on exit
+const shutdownTimeout = 5 * time.Second
+
+// runServe runs until the process is interrupted: the scheduled checks from
+// the config file with --serve, and the Prometheus exporter, the REST API and
+// the dashboard when their addresses are set. Credentials and the cost cache
+// live for the whole process.
+func runServe(flags model.Flags, cfg *model.Config, engineService engine.EngineService) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	group, ctx := errgroup.WithContext(ctx)
+
+	if flags.Serve {
+		daemonService, err := newDaemon(flags, cfg, engineService)
+		if err != nil {
+			return err
+		}
+		log.Printf("serving %d scheduled check(s)", len(cfg.Daemon.Checks))
+		group.Go(func() error { return daemonService.Run(ctx) })
+	}
+
+	// The exporter and the API share a server when their addresses match
+	muxes := make(map[string]*http.ServeMux)
+	muxFor := func(addr string) *http.ServeMux {
+		if muxes[addr] == nil {
+			muxes[addr] = http.NewServeMux()
+		}
+		return muxes[addr]
+	}
+
+	metricsAddr := flags.MetricsAddr
+	if metricsAddr == "" && flags.Serve {
+		metricsAddr = cfg.Metrics.Address
+	}
+	if metricsAddr != "" {
+		collect := func(ctx context.Context, mode string) (*model.RunResult, error) {
+			return collectCheck(ctx, flags, cfg, engineService, model.ScheduledCheck{Mode: mode})
+		}
+
+		metricsService, err := metrics.NewService(cfg.Metrics, collect)
+		if err != nil {
+			return err
+		}
+
+		muxFor(metricsAddr).Handle("/metrics", metricsService.Handler())
+
+		log.Printf("serving metrics on %s/metrics", metricsAddr)
+		group.Go(func() error { return metricsService.Run(ctx) })
+	}
+
+	apiAddr := flags.APIAddr
+	dashboardAddr := flags.DashboardAddr
+	if flags.Serve {
+		if apiAddr == "" {
+			apiAddr = cfg.API.Address
+		}
+		if dashboardAddr == "" {
+			dashboardAddr = cfg.Dashboard.Address
+		}
+	}
+
+	report, groupCosts := apiCollectors(cfg, engineService)
+	if apiAddr != "" {
+		apiService, err := api.NewService(cfg.API, flags, report, groupCosts)
+		if err != nil {
+			return err
+		}
+
+		muxFor(apiAddr).Handle("/", apiService.Handler())
+		log.Printf("serving the REST API on %s/v1", apiAddr)
+	}
+
+	if dashboardAddr != "" {
+		// The dashboard reads the API routes without a token, so only this
+		// host may reach it
+		if dashboardAddr == apiAddr {
+			return fmt.Errorf("the dashboard and the REST API cannot share the address %s", dashboardAddr)
+		}
+		if !isLoopback(dashboardAddr) {
+			return fmt.Errorf("the dashboard has no authentication and must listen on a loopback address such as localhost:8081, not %s", dashboardAddr)
+		}
+
+		dashboardService, err := dashboard.NewService(dashboardAddr, api.Routes(flags, report, groupCosts))
+		if err != nil {
+			return err
+		}
+
+		muxFor(dashboardAddr).Handle("/", dashboardService.Handler())
+		log.Printf("serving the dashboard on %s", dashboardAddr)
+	}
+
+	for addr, mux := range muxes {
+		group.Go(func() error { return listenAndServe(ctx, addr, mux) })
+	}
+
+	err := group.Wait()
+	log.Printf("stopped")
+	return err
+}
+
+// isLoopback reports whether addr only accepts connections from this host.
+// An empty host listens on every interface.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	return host == "localhost" || net.ParseIP(host).IsLoopback()
+}
+
+// listenAndServe serves handler on addr until ctx is cancelled
+func listenAndServe(ctx context.Context, addr string, handler http.Handler) error {
+	server := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve on %s: %w", addr, err)
+	}
+	return nil
+}
+
+// apiCollectors answers the REST API and the dashboard with the same
+// collectors as the CLI reports, for the providers and accounts a request selects
+func apiCollectors(cfg *model.Config, engineService engine.EngineService) (api.Reporter, api.Grouper) {
+	report := func(ctx context.Context, flags model.Flags, mode string) (*model.RunResult, error) {
+		return collectCheck(ctx, flags, cfg, engineService, model.ScheduledCheck{Mode: mode})
+	}
+
+	groupCosts := func(ctx context.Context, flags model.Flags, source model.AllocationSource, start, end time.Time) ([]model.ProviderChargeback, error) {
+		accounts, err := flagAccounts(flags, true)
+		if err != nil {
+			return nil, err
+		}
+
+		results := engine.CollectAll(ctx, accounts, func(ctx context.Context, account engine.Account) model.ProviderChargeback {
+			return collectChargeback(ctx, engineService, account, source, start, end)
+		})
+		utils.SortProviderChargebacks(results)
+		return results, nil
+	}
+
+	return report, groupCosts
+}
+
+// newDaemon sets up the scheduled checks from the config file. Cost and
+// waste runs are saved to the local history.
+func newDaemon(flags model.Flags, cfg *model.Config, engineService engine.EngineService) (daemon.DaemonService, error) {
+	historyService, err := history.NewService(cfg.History.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var notifyService notify.NotifyService
+	var emailService email.EmailService
+	for _, check := range cfg.Daemon.Checks {
+		if check.Notify && notifyService == nil {
+			if len(cfg.Notifications.Channels) == 0 {
+				return nil, fmt.Errorf("check %s notifies but notifications.channels is empty", check.Name)
+			}
+			notifyService, err = notify.NewService(cfg.Notifications)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if check.EmailEnv != "" {
+			if len(cfg.Email.Recipients[check.EmailEnv]) == 0 {
+				return nil, fmt.Errorf("check %s needs email.recipients.%s in the config file", check.Name, check.EmailEnv)
+			}
+			if emailService == nil {
+				emailService, err = email.NewService(cfg.Email, cfg.Notifications.TopMovers)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	run := func(ctx context.Context, check model.ScheduledCheck) (*model.RunResult, error) {
+		checkFlags := flags
+		if check.Provider != "" {
+			checkFlags.Provider = check.Provider
+		}
+
+		result, err := collectCheck(ctx, checkFlags, cfg, engineService, check)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := recordHistory(checkFlags, cfg, result, false); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	deliver := func(ctx context.Context, check model.ScheduledCheck, result *model.RunResult) error {
+		var errs []error
+		if check.Notify {
+			errs = append(errs, notifyService.Notify(ctx, result))
+		}
+		if check.EmailEnv != "" {
+			errs = append(errs, emailService.Send(ctx, result, check.EmailEnv))
+		}
+		return errors.Join(errs...)
+	}
+
+	statePath := filepath.Join(historyService.GetDir(), daemonStateFile)
+	daemonService, err := daemon.NewService(cfg.Daemon, cfg.Notifications.TopMovers, statePath, run, deliver)
+	if err != nil {
+		return nil, err
+	}
+	return daemonService, nil
+}
+
+// collectCheck gathers the report of a scheduled check from every provider
+// selected by the flags, without drawing it
+func collectCheck(ctx context.Context, flags model.Flags, cfg *model.Config, engineService engine.EngineService, check model.ScheduledCheck) (*model.RunResult, error) {
+	opts := engine.Options{ImportBudgets: flags.ImportBudgets}
+
+	switch check.Mode {
+	case model.CheckWaste:
+		accounts, err := flagAccounts(flags, false)
+		if err != nil {
+			return nil, err
+		}
+
+		results := engine.CollectAll(ctx, accounts, func(ctx context.Context, account engine.Account) model.ProviderWasteResult {
+			return engineService.CollectWaste(ctx, account, opts)
+		})
+		utils.SortProviderWasteResults(results)
+		return &model.RunResult{Mode: model.RunModeWaste, Timestamp: time.Now(), Waste: results}, nil
+
+	case model.CheckTrend:
+		accounts, err := flagAccounts(flags, true)
+		if err != nil {
+			return nil, err
+		}
+
+		results := engine.CollectAll(ctx, accounts, func(ctx context.Context, account engine.Account) model.ProviderCostResult {
+			return engineService.CollectTrend(ctx, account, opts)
+		})
+		utils.SortProviderCostResults(results)
+		return &model.RunResult{Mode: model.RunModeTrend, Timestamp: time.Now(), Costs: results}, nil
+
+	default:
+		currencyService, err := currency.NewService(cfg.Currency)
+		if err != nil {
+			return nil, err
+		}
+
+		accounts, err := flagAccounts(flags, true)
+		if err != nil {
+			return nil, err
+		}
+
+		results := engine.CollectAll(ctx, accounts, func(ctx context.Context, account engine.Account) model.ProviderCostResult {
+			return engineService.CollectCosts(ctx, account, opts)
+		})
+
+		if check.Mode == model.CheckAnomalies {
+			for i := range results {
+				results[i].Anomalies = anomaly.Detect(results[i], check.AnomalyPercent, check.AnomalyMinAmount)
+			}
+		}
+
+		utils.SortProviderCostResults(results)
+		currencyService.ConvertCostResults(results)
+		return &model.RunResult{Mode: model.RunModeCost, Timestamp: time.Now(), Costs: results}, nil
+	}
+}
@@ -0,0 +1,14 @@
+package accountid
+
+import "regexp"
+
+// Formats of the accounts a request may select instead of the server's own,
+// checked before any provider is called. GCP IDs end up in BigQuery table
+// names and AWS profiles select credentials, so anything else is refused.
+var (
+	AWSProfile        = regexp.MustCompile(`^[A-Za-z0-9_.@+-]+$`)
+	AWSRegion         = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+	GCPProject        = regexp.MustCompile(`^([a-z0-9.-]+:)?[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+	GCPBilling        = regexp.MustCompile(`^[0-9A-F]{6}-[0-9A-F]{6}-[0-9A-F]{6}$`)
+	AzureSubscription = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
@@ -0,0 +1,56 @@
+package anomaly
+
+import (
+	"sort"
+
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+// Detect returns the services of a cost comparison whose month-to-date spend
+// grew by at least percent and minAmount over the same period last month,
+// largest increase first. A new service is anomalous once its spend reaches
+// minAmount. Zero thresholds use the defaults.
+func Detect(result model.ProviderCostResult, percent, minAmount float64) []model.CostMover {
+	if percent <= 0 {
+		percent = model.DefaultAnomalyPercent
+	}
+	if minAmount <= 0 {
+		minAmount = model.DefaultAnomalyMinAmount
+	}
+	if result.Error != nil || result.CurrentMonthData == nil {
+		return nil
+	}
+
+	var anomalies []model.CostMover
+	for name, cost := range result.CurrentMonthData.CostGroup {
+		var last float64
+		if result.LastMonthData != nil {
+			last = result.LastMonthData.CostGroup[name].Amount
+		}
+
+		change := cost.Amount - last
+		if change < minAmount {
+			continue
+		}
+		if last > 0 && change/last*100 < percent {
+			continue
+		}
+
+		anomalies = append(anomalies, model.CostMover{
+			Provider: result.Provider,
+			Service:  name,
+			Current:  cost.Amount,
+			Last:     last,
+			Change:   change,
+			Unit:     cost.Unit,
+		})
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		if anomalies[i].Change == anomalies[j].Change {
+			return anomalies[i].Service < anomalies[j].Service
+		}
+		return anomalies[i].Change > anomalies[j].Change
+	})
+	return anomalies
+}
@@ -0,0 +1,293 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/utils"
+)
+
+// convertCosts converts a month-to-date comparison grouped by service
+func convertCosts(result model.ProviderCostResult) model.APIProviderCosts {
+	costs := model.APIProviderCosts{
+		Provider:  result.Provider,
+		AccountID: result.AccountID,
+		Groups:    []model.APICostGroup{},
+		CachedAt:  result.CachedAt,
+		Warnings:  convertWarnings(result.Warnings),
+		Error:     convertError(result.Error),
+	}
+	if result.Error != nil {
+		return costs
+	}
+
+	var compareTotal float64
+	costs.Total, costs.Currency = utils.ParseTotal(result.CurrentTotalCost)
+	compareTotal, _ = utils.ParseTotal(result.LastTotalCost)
+	costs.CompareTotal = &compareTotal
+	if compareTotal > 0 {
+		change := (costs.Total - compareTotal) / compareTotal * 100
+		costs.ChangePercent = &change
+	}
+
+	if result.Converted != nil {
+		costs.ConvertedTotal = &result.Converted.CurrentTotalCost
+		costs.ConvertedCompareTotal = &result.Converted.LastTotalCost
+	}
+	if result.ConversionError != nil {
+		costs.ConversionError = result.ConversionError.Error()
+	}
+
+	if result.CurrentMonthData != nil {
+		costs.Start, costs.End = interval(result.CurrentMonthData.DateInterval)
+	}
+	if result.LastMonthData != nil {
+		costs.CompareStart, costs.CompareEnd = interval(result.LastMonthData.DateInterval)
+	}
+
+	byName := make(map[string]*model.APICostGroup)
+	group := func(name string) *model.APICostGroup {
+		g, ok := byName[name]
+		if !ok {
+			zero := 0.0
+			g = &model.APICostGroup{Name: name, CompareAmount: &zero}
+			byName[name] = g
+		}
+		return g
+	}
+	if result.CurrentMonthData != nil {
+		for name, cost := range result.CurrentMonthData.CostGroup {
+			group(name).Amount = cost.Amount
+		}
+	}
+	if result.LastMonthData != nil {
+		for name, cost := range result.LastMonthData.CostGroup {
+			amount := cost.Amount
+			group(name).CompareAmount = &amount
+		}
+	}
+	for _, g := range byName {
+		costs.Groups = append(costs.Groups, *g)
+	}
+	sortGroups(costs.Groups)
+
+	for _, budget := range result.Budgets {
+		costs.Budgets = append(costs.Budgets, model.APIBudget{
+			Name:     budget.Name,
+			Scope:    budget.Scope,
+			Imported: budget.Imported,
+			Amount:   budget.Amount,
+			Actual:   budget.Actual,
+			Forecast: budget.Forecast,
+			Currency: budget.Unit,
+			Status:   budget.Status,
+		})
+	}
+
+	return costs
+}
+
+// summarizeCosts sums the converted totals of every provider like the CLI's
+// multi-cloud summary. Failed providers are skipped; providers that could not
+// be converted are listed as excluded.
+func summarizeCosts(response *model.APICostsResponse, results []model.ProviderCostResult) {
+	var total, compareTotal float64
+	for _, result := range results {
+		switch {
+		case result.Error != nil:
+			continue
+		case result.Converted == nil:
+			response.Excluded = append(response.Excluded, result.Provider)
+		default:
+			response.ReportingCurrency = result.Converted.Currency
+			total += result.Converted.CurrentTotalCost
+			compareTotal += result.Converted.LastTotalCost
+		}
+	}
+
+	if response.ReportingCurrency != "" {
+		response.Total = &total
+		response.CompareTotal = &compareTotal
+	}
+}
+
+// convertGroupedCosts converts the spend of a date range query
+func convertGroupedCosts(result model.ProviderChargeback) model.APIProviderCosts {
+	costs := model.APIProviderCosts{
+		Provider:  result.Provider,
+		AccountID: result.AccountID,
+		Groups:    []model.APICostGroup{},
+		CachedAt:  result.CachedAt,
+		Warnings:  convertWarnings(result.Warnings),
+		Error:     convertError(result.Error),
+	}
+	if result.Error != nil || result.Costs == nil {
+		return costs
+	}
+
+	costs.Start, costs.End = interval(result.Costs.DateInterval)
+	for name, cost := range result.Costs.CostGroup {
+		costs.Groups = append(costs.Groups, model.APICostGroup{Name: name, Amount: cost.Amount})
+		costs.Total += cost.Amount
+		if costs.Currency == "" {
+			costs.Currency = cost.Unit
+		}
+	}
+	sortGroups(costs.Groups)
+
+	return costs
+}
+
+// convertTrend converts the monthly totals of a trend report
+func convertTrend(result model.ProviderCostResult) model.APIProviderTrend {
+	trend := model.APIProviderTrend{
+		Provider:  result.Provider,
+		AccountID: result.AccountID,
+		Months:    []model.APITrendMonth{},
+		CachedAt:  result.CachedAt,
+		Warnings:  convertWarnings(result.Warnings),
+		Error:     convertError(result.Error),
+	}
+
+	for _, month := range result.TrendData {
+		var total float64
+		for name, cost := range month.CostGroup {
+			if trend.Currency == "" {
+				trend.Currency = cost.Unit
+			}
+			if name == "Total" {
+				total = cost.Amount
+			}
+		}
+
+		start, end := interval(month.DateInterval)
+		trend.Months = append(trend.Months, model.APITrendMonth{Start: start, End: end, Total: total})
+	}
+
+	return trend
+}
+
+// convertWaste converts one provider's findings of the selected categories
+func convertWaste(result model.ProviderWasteResult, categories map[string]bool) model.APIProviderWaste {
+	waste := model.APIProviderWaste{
+		Provider:  result.Provider,
+		AccountID: result.AccountID,
+		Counts:    make(map[string]int),
+		Findings:  []model.APIWasteFinding{},
+		Warnings:  convertWarnings(result.Warnings),
+		Error:     convertError(result.Error),
+	}
+	if result.Error != nil {
+		return waste
+	}
+
+	for category := range categories {
+		waste.Counts[category] = 0
+	}
+	add := func(finding model.APIWasteFinding) {
+		waste.Counts[finding.Category]++
+		waste.Findings = append(waste.Findings, finding)
+	}
+
+	for _, v := range result.UnusedVolumes {
+		add(model.APIWasteFinding{Category: model.WasteUnusedVolumes, ID: v.ID, Region: v.Region, Detail: fmt.Sprintf("%d GiB", v.SizeGB), Owner: v.Owner, Tags: v.Tags})
+	}
+	for _, v := range result.AttachedVolumes {
+		add(model.APIWasteFinding{Category: model.WasteAttachedVolumes, ID: v.ID, Region: v.Region, Detail: fmt.Sprintf("%d GiB attached to a stopped instance", v.SizeGB), Owner: v.Owner, Tags: v.Tags})
+	}
+	for _, ip := range result.UnusedIPs {
+		add(model.APIWasteFinding{Category: model.WasteUnusedIPs, ID: ip.Address, Name: ip.AllocationID, Region: ip.Region, Owner: ip.Owner, Tags: ip.Tags})
+	}
+	for _, inst := range result.StoppedInstances {
+		detail := fmt.Sprintf("stopped %d days", inst.StoppedDays)
+		if inst.StoppedDays < 0 {
+			detail = "stopped, stop time unknown"
+		} else if inst.StoppedAtEstimated {
+			detail = fmt.Sprintf("stopped ~%d days, estimated", inst.StoppedDays)
+		}
+		if inst.InstanceType != "" {
+			detail = inst.InstanceType + ", " + detail
+		}
+		add(model.APIWasteFinding{Category: model.WasteStoppedInstances, ID: inst.ID, Name: inst.Name, Region: inst.Region, Detail: detail, Owner: inst.Owner, Tags: inst.Tags})
+	}
+	for _, r := range result.ExpiringReservations {
+		detail := fmt.Sprintf("%s, expires in %d days", r.InstanceType, r.DaysUntilExpiry)
+		if r.Status == "expired" {
+			detail = fmt.Sprintf("%s, expired %d days ago", r.InstanceType, -r.DaysUntilExpiry)
+		}
+		add(model.APIWasteFinding{Category: model.WasteExpiringReservations, ID: r.ID, Detail: detail})
+	}
+
+	return waste
+}
+
+// convertOwners converts findings grouped by owner. Reservations have no
+// owner, so they are not counted.
+func convertOwners(owners []model.OwnerWaste, categories map[string]bool) []model.APIOwnerWaste {
+	converted := make([]model.APIOwnerWaste, 0, len(owners))
+	for _, owner := range owners {
+		counts := map[string]int{
+			model.WasteUnusedVolumes:    owner.UnusedVolumes,
+			model.WasteAttachedVolumes:  owner.AttachedVolumes,
+			model.WasteUnusedIPs:        owner.UnusedIPs,
+			model.WasteStoppedInstances: owner.StoppedInstances,
+		}
+		for category := range counts {
+			if !categories[category] {
+				delete(counts, category)
+			}
+		}
+
+		converted = append(converted, model.APIOwnerWaste{Owner: owner.Owner, Counts: counts, Resources: owner.Resources})
+	}
+	return converted
+}
+
+func convertWarnings(warnings []model.Warning) []model.APIWarning {
+	var converted []model.APIWarning
+	for _, w := range warnings {
+		converted = append(converted, model.APIWarning{
+			Kind:    string(w.Kind),
+			Scope:   w.Scope,
+			Action:  w.Action,
+			Message: w.Message,
+		})
+	}
+	return converted
+}
+
+// convertError describes a failed provider; errors are classified by the collectors
+func convertError(err error) *model.APIError {
+	if err == nil {
+		return nil
+	}
+
+	var cloudErr *model.CloudError
+	if errors.As(err, &cloudErr) {
+		return &model.APIError{Kind: string(cloudErr.Kind), Action: cloudErr.Action, Message: err.Error()}
+	}
+	return &model.APIError{Kind: string(model.ErrorKindUnknown), Message: err.Error()}
+}
+
+// sortGroups orders groups by amount, largest first
+func sortGroups(groups []model.APICostGroup) {
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Amount != groups[j].Amount {
+			return groups[i].Amount > groups[j].Amount
+		}
+		return groups[i].Name < groups[j].Name
+	})
+}
+
+func interval(dates model.DateInterval) (string, string) {
+	var start, end string
+	if dates.Start != nil {
+		start = *dates.Start
+	}
+	if dates.End != nil {
+		end = *dates.End
+	}
+	return start, end
+}
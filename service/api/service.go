@@ -0,0 +1,447 @@
+package api
+
+import (
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/accountid"
+	"github.com/elC0mpa/aws-doctor/service/apierror"
+	"github.com/elC0mpa/aws-doctor/service/ownership"
+)
+
+// openAPI documents every endpoint; it is served without authentication
+//
+//go:embed openapi.json
+var openAPI []byte
+
+// dateLayout is the format of the start and end query parameters
+const dateLayout = "2006-01-02"
+
+var wasteCategories = []string{
+	model.WasteUnusedVolumes,
+	model.WasteAttachedVolumes,
+	model.WasteUnusedIPs,
+	model.WasteStoppedInstances,
+	model.WasteExpiringReservations,
+}
+
+// NewService creates the REST API. Requests are answered with report and
+// group, using flags for whatever provider or account a request leaves out.
+func NewService(cfg model.APIConfig, flags model.Flags, report Reporter, group Grouper) (*service, error) {
+	var tokens [][]byte
+	for _, token := range cfg.Tokens {
+		if token != "" {
+			tokens = append(tokens, []byte(token))
+		}
+	}
+	if cfg.TokenEnv != "" {
+		token := os.Getenv(cfg.TokenEnv)
+		if token == "" {
+			return nil, fmt.Errorf("api.token_env names %s but it is not set", cfg.TokenEnv)
+		}
+		tokens = append(tokens, []byte(token))
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("the REST API needs api.tokens or api.token_env in the config file")
+	}
+
+	return &service{
+		tokens: tokens,
+		routes: Routes(flags, report, group),
+	}, nil
+}
+
+// Routes serves the /v1 endpoints without authentication, for the dashboard
+// on a local address. Requests are answered like NewService's.
+func Routes(flags model.Flags, report Reporter, group Grouper) http.Handler {
+	r := &router{flags: flags, report: report, group: group}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /v1/costs", r.serve(r.handleCosts))
+	mux.Handle("GET /v1/trend", r.serve(r.handleTrend))
+	mux.Handle("GET /v1/waste", r.serve(r.handleWaste))
+	mux.Handle("GET /v1/providers", r.serve(r.handleProviders))
+	return mux
+}
+
+// Handler serves the API under /v1, the OpenAPI document on /openapi.json
+// and a health check on /healthz
+func (s *service) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPI)
+	})
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.Handle("/v1/", s.authorize(s.routes))
+	return mux
+}
+
+// authorize rejects requests without one of the configured bearer tokens
+func (s *service) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !s.validToken([]byte(strings.TrimSpace(token))) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="cloud-doctor"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validToken compares token with every configured token in constant time
+func (s *service) validToken(token []byte) bool {
+	valid := 0
+	for _, t := range s.tokens {
+		valid |= subtle.ConstantTimeCompare(token, t)
+	}
+	return valid == 1
+}
+
+// serve answers request errors with 400 and any other error with 500. The
+// detail of a 500 may name accounts, hosts or local paths, so it is only
+// logged and the client gets the error's kind.
+func (rt *router) serve(handle func(w http.ResponseWriter, r *http.Request) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := handle(w, r); err != nil {
+			var reqErr *requestError
+			if errors.As(err, &reqErr) {
+				writeError(w, http.StatusBadRequest, reqErr.Error())
+				return
+			}
+			log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+
+			apiErr := model.APIError{
+				Kind:    string(model.ErrorKindUnknown),
+				Message: "the report could not be collected, see the server log for details",
+			}
+			var cloudErr *model.CloudError
+			if errors.As(apierror.Classify("", err), &cloudErr) {
+				apiErr.Kind = string(cloudErr.Kind)
+				apiErr.Action = cloudErr.Action
+			}
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: apiErr})
+		}
+	})
+}
+
+func (rt *router) handleCosts(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+
+	source, err := parseGroupBy(query.Get("group_by"))
+	if err != nil {
+		return err
+	}
+	start, end, ranged, err := parseRange(query)
+	if err != nil {
+		return err
+	}
+	if !ranged && source.Dimension != model.DimensionService {
+		return &requestError{msg: "group_by " + query.Get("group_by") + " needs a start date"}
+	}
+
+	flags, err := rt.requestFlags(query, true)
+	if err != nil {
+		return err
+	}
+
+	response := model.APICostsResponse{GroupBy: query.Get("group_by"), Providers: []model.APIProviderCosts{}}
+	if response.GroupBy == "" {
+		response.GroupBy = model.DimensionService
+	}
+
+	if !ranged {
+		result, err := rt.report(r.Context(), flags, model.RunModeCost)
+		if err != nil {
+			return err
+		}
+		for _, cost := range result.Costs {
+			response.Providers = append(response.Providers, convertCosts(cost))
+		}
+		summarizeCosts(&response, result.Costs)
+		writeJSON(w, http.StatusOK, response)
+		return nil
+	}
+
+	results, err := rt.group(r.Context(), flags, source, start, end)
+	if err != nil {
+		return err
+	}
+	response.Start = start.Format(dateLayout)
+	response.End = end.Format(dateLayout)
+	for _, result := range results {
+		response.Providers = append(response.Providers, convertGroupedCosts(result))
+	}
+	writeJSON(w, http.StatusOK, response)
+	return nil
+}
+
+func (rt *router) handleTrend(w http.ResponseWriter, r *http.Request) error {
+	flags, err := rt.requestFlags(r.URL.Query(), true)
+	if err != nil {
+		return err
+	}
+
+	result, err := rt.report(r.Context(), flags, model.RunModeTrend)
+	if err != nil {
+		return err
+	}
+
+	response := model.APITrendResponse{Providers: []model.APIProviderTrend{}}
+	for _, cost := range result.Costs {
+		response.Providers = append(response.Providers, convertTrend(cost))
+	}
+	writeJSON(w, http.StatusOK, response)
+	return nil
+}
+
+func (rt *router) handleWaste(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+
+	categories, err := parseCategories(query.Get("category"))
+	if err != nil {
+		return err
+	}
+	groupBy := query.Get("group_by")
+	if groupBy != "" && groupBy != model.GroupByOwner {
+		return &requestError{msg: fmt.Sprintf("unsupported group_by %q for waste, expected %s", groupBy, model.GroupByOwner)}
+	}
+
+	flags, err := rt.requestFlags(query, false)
+	if err != nil {
+		return err
+	}
+
+	result, err := rt.report(r.Context(), flags, model.RunModeWaste)
+	if err != nil {
+		return err
+	}
+
+	response := model.APIWasteResponse{Providers: []model.APIProviderWaste{}}
+	for i := range result.Waste {
+		filterWaste(&result.Waste[i], categories)
+		response.Providers = append(response.Providers, convertWaste(result.Waste[i], categories))
+	}
+	if groupBy == model.GroupByOwner {
+		response.Owners = convertOwners(ownership.GroupWaste(result.Waste), categories)
+	}
+	writeJSON(w, http.StatusOK, response)
+	return nil
+}
+
+func (rt *router) handleProviders(w http.ResponseWriter, r *http.Request) error {
+	all := rt.flags.Provider == "all"
+	profile := rt.flags.Profile
+	if profile == "" {
+		profile = "default"
+	}
+
+	writeJSON(w, http.StatusOK, model.APIProvidersResponse{
+		Providers: []model.APIProvider{
+			{
+				Name:    "aws",
+				Default: rt.flags.Provider == "aws" || all,
+				Account: profile,
+				Region:  rt.flags.Region,
+			},
+			{
+				Name:           "gcp",
+				Default:        rt.flags.Provider == "gcp" || (all && rt.flags.Project != ""),
+				Account:        rt.flags.Project,
+				BillingAccount: rt.flags.BillingAccount != "",
+			},
+			{
+				Name:    "azure",
+				Default: rt.flags.Provider == "azure" || (all && rt.flags.Subscription != ""),
+				Account: rt.flags.Subscription,
+			},
+		},
+	})
+	return nil
+}
+
+// requestFlags applies the provider and account query parameters to the
+// server's flags. account is the AWS profile, GCP project or Azure
+// subscription, so it needs a single provider.
+func (rt *router) requestFlags(query url.Values, needsBilling bool) (model.Flags, error) {
+	flags := rt.flags
+
+	if provider := query.Get("provider"); provider != "" {
+		switch provider {
+		case "aws", "gcp", "azure", "all":
+			flags.Provider = provider
+		default:
+			return flags, &requestError{msg: fmt.Sprintf("unknown provider %q, expected aws, gcp, azure or all", provider)}
+		}
+	}
+
+	if account := query.Get("account"); account != "" {
+		var pattern *regexp.Regexp
+		switch flags.Provider {
+		case "aws":
+			flags.Profile, pattern = account, accountid.AWSProfile
+		case "gcp":
+			flags.Project, pattern = account, accountid.GCPProject
+		case "azure":
+			flags.Subscription, pattern = account, accountid.AzureSubscription
+		default:
+			return flags, &requestError{msg: "account needs provider aws, gcp or azure"}
+		}
+		if err := checkParam("account", account, pattern); err != nil {
+			return flags, err
+		}
+	}
+	if region := query.Get("region"); region != "" {
+		if err := checkParam("region", region, accountid.AWSRegion); err != nil {
+			return flags, err
+		}
+		flags.Region = region
+	}
+	if billingAccount := query.Get("billing_account"); billingAccount != "" {
+		if err := checkParam("billing_account", strings.TrimPrefix(billingAccount, "billingAccounts/"), accountid.GCPBilling); err != nil {
+			return flags, err
+		}
+		flags.BillingAccount = billingAccount
+	}
+
+	switch {
+	case flags.Provider == "gcp" && flags.Project == "":
+		return flags, &requestError{msg: "provider gcp needs account, the GCP project ID"}
+	case flags.Provider == "gcp" && needsBilling && flags.BillingAccount == "":
+		return flags, &requestError{msg: "provider gcp needs billing_account for cost queries"}
+	case flags.Provider == "azure" && flags.Subscription == "":
+		return flags, &requestError{msg: "provider azure needs account, the Azure subscription ID"}
+	}
+	return flags, nil
+}
+
+// checkParam refuses a query value that does not match pattern
+func checkParam(name, value string, pattern *regexp.Regexp) error {
+	if !pattern.MatchString(value) {
+		return &requestError{msg: fmt.Sprintf("%s %q does not match %s", name, value, pattern)}
+	}
+	return nil
+}
+
+// parseGroupBy maps the group_by parameter of a cost query to an allocation
+// source: service (the default), project, resource_group or tag:<key>
+func parseGroupBy(groupBy string) (model.AllocationSource, error) {
+	switch groupBy {
+	case "", model.DimensionService:
+		return model.AllocationSource{Dimension: model.DimensionService}, nil
+	case model.DimensionProject, model.DimensionResourceGroup:
+		return model.AllocationSource{Dimension: groupBy}, nil
+	}
+
+	if key, ok := strings.CutPrefix(groupBy, model.DimensionTag+":"); ok && key != "" {
+		return model.AllocationSource{Dimension: model.DimensionTag, Key: key}, nil
+	}
+	return model.AllocationSource{}, &requestError{msg: fmt.Sprintf("unsupported group_by %q, expected service, project, resource_group or tag:<key>", groupBy)}
+}
+
+// parseRange reads the start and end dates of a cost query. end is exclusive
+// and defaults to today; ranged is false when neither is set.
+func parseRange(query url.Values) (start, end time.Time, ranged bool, err error) {
+	if query.Get("start") == "" {
+		if query.Get("end") != "" {
+			return start, end, false, &requestError{msg: "end needs a start date"}
+		}
+		return start, end, false, nil
+	}
+
+	start, err = time.Parse(dateLayout, query.Get("start"))
+	if err != nil {
+		return start, end, false, &requestError{msg: "start must be a date as YYYY-MM-DD"}
+	}
+
+	end = time.Now().UTC().Truncate(24 * time.Hour)
+	if query.Get("end") != "" {
+		end, err = time.Parse(dateLayout, query.Get("end"))
+		if err != nil {
+			return start, end, false, &requestError{msg: "end must be a date as YYYY-MM-DD"}
+		}
+	}
+
+	if !start.Before(end) {
+		return start, end, false, &requestError{msg: "start must be before end"}
+	}
+	return start, end, true, nil
+}
+
+// parseCategories reads the comma-separated category parameter of a waste
+// query; empty selects every category
+func parseCategories(category string) (map[string]bool, error) {
+	selected := make(map[string]bool, len(wasteCategories))
+	if category == "" {
+		for _, c := range wasteCategories {
+			selected[c] = true
+		}
+		return selected, nil
+	}
+
+	for _, c := range strings.Split(category, ",") {
+		c = strings.TrimSpace(c)
+		known := false
+		for _, k := range wasteCategories {
+			known = known || c == k
+		}
+		if !known {
+			return nil, &requestError{msg: fmt.Sprintf("unknown category %q, expected %s", c, strings.Join(wasteCategories, ", "))}
+		}
+		selected[c] = true
+	}
+	return selected, nil
+}
+
+// filterWaste drops the findings of every category that is not selected
+func filterWaste(result *model.ProviderWasteResult, categories map[string]bool) {
+	if !categories[model.WasteUnusedVolumes] {
+		result.UnusedVolumes = nil
+	}
+	if !categories[model.WasteAttachedVolumes] {
+		result.AttachedVolumes = nil
+	}
+	if !categories[model.WasteUnusedIPs] {
+		result.UnusedIPs = nil
+	}
+	if !categories[model.WasteStoppedInstances] {
+		result.StoppedInstances = nil
+	}
+	if !categories[model.WasteExpiringReservations] {
+		result.ExpiringReservations = nil
+	}
+}
+
+func (e *requestError) Error() string {
+	return e.msg
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{Error: model.APIError{Message: msg}})
+}
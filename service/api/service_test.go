@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+func TestRequestFlagsRejectsHostileAccounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   url.Values
+		wantErr string
+		want    func(flags model.Flags) bool
+	}{
+		{
+			name:  "aws profile",
+			query: url.Values{"provider": {"aws"}, "account": {"prod-readonly"}, "region": {"eu-west-1"}},
+			want:  func(flags model.Flags) bool { return flags.Profile == "prod-readonly" && flags.Region == "eu-west-1" },
+		},
+		{
+			name:  "gcp project and billing account",
+			query: url.Values{"provider": {"gcp"}, "account": {"my-project"}, "billing_account": {"billingAccounts/012345-6789AB-CDEF01"}},
+			want: func(flags model.Flags) bool {
+				return flags.Project == "my-project" && flags.BillingAccount == "billingAccounts/012345-6789AB-CDEF01"
+			},
+		},
+		{
+			name:  "azure subscription",
+			query: url.Values{"provider": {"azure"}, "account": {"00000000-1111-2222-3333-444444444444"}},
+			want:  func(flags model.Flags) bool { return flags.Subscription == "00000000-1111-2222-3333-444444444444" },
+		},
+		{
+			name:    "gcp project closing the table name",
+			query:   url.Values{"provider": {"gcp"}, "account": {"my-project.x.y` WHERE 1=1; DROP TABLE t; --"}, "billing_account": {"012345-6789AB-CDEF01"}},
+			wantErr: "account",
+		},
+		{
+			name:    "billing account with a union",
+			query:   url.Values{"provider": {"gcp"}, "account": {"my-project"}, "billing_account": {"012345_6789AB_CDEF01 UNION ALL SELECT * FROM secrets.t"}},
+			wantErr: "billing_account",
+		},
+		{
+			name:    "aws profile with a path",
+			query:   url.Values{"provider": {"aws"}, "account": {"../../etc/passwd"}},
+			wantErr: "account",
+		},
+		{
+			name:    "aws region with spaces",
+			query:   url.Values{"provider": {"aws"}, "region": {"us-east-1 --endpoint evil"}},
+			wantErr: "region",
+		},
+		{
+			name:    "azure subscription that is not an ID",
+			query:   url.Values{"provider": {"azure"}, "account": {"subscriptions/x/../y"}},
+			wantErr: "account",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *model.Flags
+			report := func(ctx context.Context, flags model.Flags, mode string) (*model.RunResult, error) {
+				got = &flags
+				return &model.RunResult{}, nil
+			}
+			routes := Routes(model.Flags{Provider: "aws"}, report, nil)
+
+			w := httptest.NewRecorder()
+			routes.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/trend?"+tt.query.Encode(), nil))
+
+			if tt.wantErr == "" {
+				if w.Code != http.StatusOK {
+					t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+				}
+				if got == nil || !tt.want(*got) {
+					t.Errorf("report flags = %+v, want the query's accounts", got)
+				}
+				return
+			}
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", w.Code, w.Body)
+			}
+			if got != nil {
+				t.Errorf("report called with %+v, want no provider call", *got)
+			}
+			var body errorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("error body is not JSON: %v", err)
+			}
+			if !strings.HasPrefix(body.Error.Message, tt.wantErr+" ") {
+				t.Errorf("error = %q, want it to name %s", body.Error.Message, tt.wantErr)
+			}
+		})
+	}
+}
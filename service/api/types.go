@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+// Reporter gathers one report for a request: model.RunModeCost,
+// model.RunModeTrend or model.RunModeWaste. flags selects the providers and
+// accounts the request asked for.
+type Reporter func(ctx context.Context, flags model.Flags, mode string) (*model.RunResult, error)
+
+// Grouper gathers the spend of every provider selected by flags between
+// start and end, grouped as described by source
+type Grouper func(ctx context.Context, flags model.Flags, source model.AllocationSource, start, end time.Time) ([]model.ProviderChargeback, error)
+
+type service struct {
+	tokens [][]byte
+	routes http.Handler
+}
+
+// router answers the /v1 endpoints
+type router struct {
+	// flags are the server's provider flags, which requests narrow down
+	flags  model.Flags
+	report Reporter
+	group  Grouper
+}
+
+// errorResponse is the body of every error answer
+type errorResponse struct {
+	Error model.APIError `json:"error"`
+}
+
+// requestError is a problem with the request itself, answered with 400
+type requestError struct {
+	msg string
+}
+
+type APIService interface {
+	Handler() http.Handler
+}
@@ -0,0 +1,206 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/aws/smithy-go"
+	"github.com/elC0mpa/aws-doctor/model"
+	"google.golang.org/api/googleapi"
+)
+
+var (
+	// "is not authorized to perform: ce:GetCostAndUsage on resource"
+	awsActionPattern = regexp.MustCompile(`perform: ([A-Za-z0-9-]+:[A-Za-z0-9*]+)`)
+	// "Permission 'compute.disks.list' denied" or "Required 'compute.disks.list' permission"
+	gcpActionPattern = regexp.MustCompile(`(?:[Pp]ermission|Required) '([A-Za-z0-9_.]+)'`)
+	// "does not have authorization to perform action 'Microsoft.Compute/disks/read'"
+	azureActionPattern = regexp.MustCompile(`perform action '([^']+)'`)
+)
+
+// Classify wraps err in a *model.CloudError describing what went wrong.
+// Errors that are already classified, and nil, are returned unchanged.
+func Classify(provider string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var cloudErr *model.CloudError
+	if errors.As(err, &cloudErr) {
+		return err
+	}
+
+	kind, action := classifyKind(err)
+	if kind == model.ErrorKindUnknown {
+		return err
+	}
+
+	return &model.CloudError{
+		Provider: provider,
+		Kind:     kind,
+		Action:   action,
+		Message:  describe(provider, kind),
+		Err:      err,
+	}
+}
+
+// NewWarning turns a failure for a single zone, region or other scope into a warning
+func NewWarning(provider, scope string, err error) model.Warning {
+	kind, action := classifyKind(err)
+	return model.Warning{
+		Provider: provider,
+		Kind:     kind,
+		Scope:    scope,
+		Action:   action,
+		Message:  err.Error(),
+	}
+}
+
+// NewEmptyDataError reports that a provider returned no data for a query
+func NewEmptyDataError(provider, message string) error {
+	return &model.CloudError{
+		Provider: provider,
+		Kind:     model.ErrorKindEmptyData,
+		Message:  message,
+	}
+}
+
+func classifyKind(err error) (model.ErrorKind, string) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return model.ErrorKindThrottled, ""
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return classifyAWS(apiErr)
+	}
+
+	var googleErr *googleapi.Error
+	if errors.As(err, &googleErr) {
+		return classifyGCP(googleErr)
+	}
+
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) {
+		return classifyAzure(respErr)
+	}
+
+	var authErr *azidentity.AuthenticationFailedError
+	if errors.As(err, &authErr) {
+		return model.ErrorKindAuth, ""
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "could not find default credentials"),
+		strings.Contains(msg, "failed to retrieve credentials"),
+		strings.Contains(msg, "no valid providers in chain"),
+		strings.Contains(msg, "failed to acquire a token"),
+		strings.Contains(msg, "oauth2: cannot fetch token"):
+		return model.ErrorKindAuth, ""
+	}
+
+	return model.ErrorKindUnknown, ""
+}
+
+func classifyAWS(apiErr smithy.APIError) (model.ErrorKind, string) {
+	msg := apiErr.ErrorMessage()
+
+	switch apiErr.ErrorCode() {
+	case "ExpiredToken", "ExpiredTokenException", "InvalidClientTokenId", "UnrecognizedClientException",
+		"AuthFailure", "SignatureDoesNotMatch", "InvalidSignatureException", "MissingAuthenticationToken":
+		return model.ErrorKindAuth, ""
+	case "ThrottlingException", "Throttling", "RequestLimitExceeded", "TooManyRequestsException",
+		"LimitExceededException", "RequestThrottled":
+		return model.ErrorKindThrottled, ""
+	case "OptInRequired":
+		return model.ErrorKindAPINotEnabled, ""
+	case "DataUnavailableException":
+		return model.ErrorKindEmptyData, ""
+	case "AccessDeniedException", "AccessDenied", "UnauthorizedOperation", "UnauthorizedAccess":
+		// Cost Explorer reports a disabled service as an access error
+		if strings.Contains(strings.ToLower(msg), "not enabled for cost explorer") {
+			return model.ErrorKindAPINotEnabled, ""
+		}
+		return model.ErrorKindPermissionDenied, matchAction(awsActionPattern, msg)
+	}
+
+	return model.ErrorKindUnknown, ""
+}
+
+func classifyGCP(googleErr *googleapi.Error) (model.ErrorKind, string) {
+	reasons := make([]string, 0, len(googleErr.Errors))
+	for _, item := range googleErr.Errors {
+		reasons = append(reasons, item.Reason)
+	}
+	details := strings.Join(reasons, " ") + " " + googleErr.Message
+
+	switch {
+	case googleErr.Code == http.StatusUnauthorized:
+		return model.ErrorKindAuth, ""
+	case googleErr.Code == http.StatusTooManyRequests,
+		strings.Contains(details, "rateLimitExceeded"),
+		strings.Contains(details, "userRateLimitExceeded"),
+		strings.Contains(details, "quotaExceeded"):
+		return model.ErrorKindThrottled, ""
+	case strings.Contains(details, "accessNotConfigured"),
+		strings.Contains(details, "SERVICE_DISABLED"),
+		strings.Contains(details, "has not been used in project"),
+		strings.Contains(details, "it is disabled"):
+		return model.ErrorKindAPINotEnabled, ""
+	case googleErr.Code == http.StatusForbidden:
+		return model.ErrorKindPermissionDenied, matchAction(gcpActionPattern, googleErr.Message)
+	}
+
+	return model.ErrorKindUnknown, ""
+}
+
+func classifyAzure(respErr *azcore.ResponseError) (model.ErrorKind, string) {
+	switch {
+	case respErr.StatusCode == http.StatusUnauthorized,
+		respErr.ErrorCode == "InvalidAuthenticationToken",
+		respErr.ErrorCode == "ExpiredAuthenticationToken":
+		return model.ErrorKindAuth, ""
+	case respErr.StatusCode == http.StatusTooManyRequests:
+		return model.ErrorKindThrottled, ""
+	case respErr.ErrorCode == "MissingSubscriptionRegistration",
+		respErr.ErrorCode == "SubscriptionNotRegistered":
+		return model.ErrorKindAPINotEnabled, ""
+	case respErr.StatusCode == http.StatusForbidden,
+		respErr.ErrorCode == "AuthorizationFailed":
+		return model.ErrorKindPermissionDenied, matchAction(azureActionPattern, respErr.Error())
+	}
+
+	return model.ErrorKindUnknown, ""
+}
+
+func matchAction(pattern *regexp.Regexp, msg string) string {
+	if match := pattern.FindStringSubmatch(msg); len(match) > 1 {
+		return match[1]
+	}
+	return ""
+}
+
+func describe(provider string, kind model.ErrorKind) string {
+	name := strings.ToUpper(provider)
+
+	switch kind {
+	case model.ErrorKindAuth:
+		return name + " authentication failed; check your credentials"
+	case model.ErrorKindPermissionDenied:
+		return name + " permission denied"
+	case model.ErrorKindAPINotEnabled:
+		return name + " API is not enabled for this account"
+	case model.ErrorKindThrottled:
+		return name + " API request was throttled or timed out"
+	case model.ErrorKindEmptyData:
+		return name + " returned no data"
+	}
+
+	return name + " request failed"
+}
@@ -0,0 +1,58 @@
+package appconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+// NewService creates a config file loader. An empty path falls back to the
+// default location under the user config directory, which is allowed to be missing.
+func NewService(path string) *service {
+	if path != "" {
+		return &service{path: path, explicit: true}
+	}
+
+	return &service{path: DefaultPath()}
+}
+
+// DefaultPath returns the default config file location
+// e.g. ~/.config/cloud-doctor/config.json on Linux
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "cloud-doctor", "config.json")
+}
+
+// Load reads and parses the config file
+func (s *service) Load() (*model.Config, error) {
+	cfg := &model.Config{}
+	if s.path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !s.explicit {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config file %s: %w", s.path, err)
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", s.path, err)
+	}
+
+	return cfg, nil
+}
+
+func (s *service) GetPath() string {
+	return s.path
+}
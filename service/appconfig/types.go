@@ -0,0 +1,13 @@
+package appconfig
+
+import "github.com/elC0mpa/aws-doctor/model"
+
+type service struct {
+	path     string
+	explicit bool
+}
+
+type ConfigService interface {
+	Load() (*model.Config, error)
+	GetPath() string
+}
@@ -0,0 +1,80 @@
+package awsbudgets
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/budgets"
+	"github.com/aws/aws-sdk-go-v2/service/budgets/types"
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service"
+	"github.com/elC0mpa/aws-doctor/service/budget"
+)
+
+// NewService creates a client for AWS Budgets. The account whose budgets are
+// listed is resolved through identityService.
+func NewService(awsconfig aws.Config, identityService service.IdentityService) *budgetsService {
+	client := budgets.NewFromConfig(awsconfig)
+	return &budgetsService{
+		client:          client,
+		identityService: identityService,
+	}
+}
+
+// GetBudgets implements service.BudgetService
+// Only monthly cost budgets are returned since the other kinds track usage,
+// reservations or periods that do not line up with month-to-date spend.
+func (s *budgetsService) GetBudgets(ctx context.Context) ([]model.BudgetStatus, error) {
+	accountInfo, err := s.identityService.GetAccountInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []model.BudgetStatus
+
+	paginator := budgets.NewDescribeBudgetsPaginator(s.client, &budgets.DescribeBudgetsInput{
+		AccountId: aws.String(accountInfo.AccountID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, b := range page.Budgets {
+			if b.BudgetType != types.BudgetTypeCost || b.TimeUnit != types.TimeUnitMonthly || b.BudgetLimit == nil {
+				continue
+			}
+
+			amount, unit := parseSpend(b.BudgetLimit)
+			status := model.BudgetStatus{
+				Name:     aws.ToString(b.BudgetName),
+				Provider: "aws",
+				Scope:    "AWS Budgets",
+				Imported: true,
+				Amount:   amount,
+				Unit:     unit,
+				Status:   model.BudgetUnknown,
+			}
+
+			if b.CalculatedSpend != nil && b.CalculatedSpend.ActualSpend != nil {
+				status.Actual, _ = parseSpend(b.CalculatedSpend.ActualSpend)
+				status.Forecast = status.Actual
+				if b.CalculatedSpend.ForecastedSpend != nil {
+					status.Forecast, _ = parseSpend(b.CalculatedSpend.ForecastedSpend)
+				}
+				status.Status = budget.Status(status.Actual, status.Forecast, status.Amount, model.DefaultBudgetWarnPercent)
+			}
+
+			statuses = append(statuses, status)
+		}
+	}
+
+	return statuses, nil
+}
+
+func parseSpend(spend *types.Spend) (float64, string) {
+	amount, _ := strconv.ParseFloat(aws.ToString(spend.Amount), 64)
+	return amount, aws.ToString(spend.Unit)
+}
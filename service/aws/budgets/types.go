@@ -0,0 +1,18 @@
+package awsbudgets
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/budgets"
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service"
+)
+
+type budgetsService struct {
+	client          *budgets.Client
+	identityService service.IdentityService
+}
+
+type BudgetsService interface {
+	GetBudgets(ctx context.Context) ([]model.BudgetStatus, error)
+}
@@ -0,0 +1,73 @@
+package awscloudtrail
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+)
+
+// eventHistoryRetention is how far back CloudTrail event history can be queried
+const eventHistoryRetention = 90 * 24 * time.Hour
+
+func NewService(awsconfig aws.Config) *service {
+	client := cloudtrail.NewFromConfig(awsconfig)
+	return &service{
+		client: client,
+	}
+}
+
+// GetLastStopTimes returns the time of the most recent StopInstances event for
+// each of the given instances. Instances stopped before the 90-day event
+// history window are missing from the result.
+func (s *service) GetLastStopTimes(ctx context.Context, instanceIDs []string) (map[string]time.Time, error) {
+	stopTimes := make(map[string]time.Time, len(instanceIDs))
+	if len(instanceIDs) == 0 {
+		return stopTimes, nil
+	}
+
+	wanted := make(map[string]bool, len(instanceIDs))
+	for _, id := range instanceIDs {
+		wanted[id] = true
+	}
+
+	now := time.Now()
+	paginator := cloudtrail.NewLookupEventsPaginator(s.client, &cloudtrail.LookupEventsInput{
+		LookupAttributes: []types.LookupAttribute{
+			{
+				AttributeKey:   types.LookupAttributeKeyEventName,
+				AttributeValue: aws.String("StopInstances"),
+			},
+		},
+		StartTime: aws.Time(now.Add(-eventHistoryRetention)),
+		EndTime:   aws.Time(now),
+	})
+
+	// Events are returned newest first, so the first event seen for an
+	// instance is its last stop and paging ends once every instance is found
+	for paginator.HasMorePages() && len(stopTimes) < len(wanted) {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range page.Events {
+			if event.EventTime == nil {
+				continue
+			}
+			for _, resource := range event.Resources {
+				id := aws.ToString(resource.ResourceName)
+				if !wanted[id] {
+					continue
+				}
+				if _, found := stopTimes[id]; !found {
+					stopTimes[id] = *event.EventTime
+				}
+			}
+		}
+	}
+
+	return stopTimes, nil
+}
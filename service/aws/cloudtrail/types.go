@@ -0,0 +1,16 @@
+package awscloudtrail
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+)
+
+type service struct {
+	client *cloudtrail.Client
+}
+
+type CloudTrailService interface {
+	GetLastStopTimes(ctx context.Context, instanceIDs []string) (map[string]time.Time, error)
+}
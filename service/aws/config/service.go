@@ -5,12 +5,36 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/elC0mpa/aws-doctor/service/resilience"
 )
 
-func NewService() *service {
-	return &service{}
+// NewService creates a config loader whose clients call AWS through policy.
+// It keeps each region and profile's config so that its credentials cache is
+// shared by every client, and across runs in --serve mode.
+func NewService(policy resilience.Policy) *service {
+	return &service{
+		policy: policy,
+		loaded: make(map[string]aws.Config),
+	}
 }
 
 func (s *service) GetAWSCfg(ctx context.Context, region, profile string) (aws.Config, error) {
-	return config.LoadDefaultConfig(ctx, config.WithRegion(region), config.WithSharedConfigProfile(profile))
+	key := region + "|" + profile
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cfg, ok := s.loaded[key]; ok {
+		return cfg, nil
+	}
+
+	opts := []func(*config.LoadOptions) error{config.WithRegion(region), config.WithSharedConfigProfile(profile)}
+	opts = append(opts, s.policy.AWSConfigOptions()...)
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	s.loaded[key] = cfg
+	return cfg, nil
 }
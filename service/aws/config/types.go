@@ -2,11 +2,18 @@ package awsconfig
 
 import (
 	"context"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/elC0mpa/aws-doctor/service/resilience"
 )
 
-type service struct{}
+type service struct {
+	policy resilience.Policy
+
+	mu     sync.Mutex
+	loaded map[string]aws.Config
+}
 
 type ConfigService interface {
 	GetAWSCfg(ctx context.Context, region, profile string) (aws.Config, error)
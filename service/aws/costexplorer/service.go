@@ -4,18 +4,27 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
 	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/apierror"
 )
 
+// defaultUnit is Cost Explorer's reporting currency when a response carries no unit
+const defaultUnit = "USD"
+
+// resourceDataDays is how far back Cost Explorer keeps resource-level data
+const resourceDataDays = 14
+
 func NewService(awsconfig aws.Config) *service {
 	client := costexplorer.NewFromConfig(awsconfig)
 	return &service{
-		client: client,
+		client:     client,
+		monthCosts: make(map[string]*model.CostInfo),
 	}
 }
 
@@ -33,6 +42,27 @@ func (s *service) GetMonthCostsByService(ctx context.Context, endDate time.Time)
 	firstOfMonthStr := firstOfMonth.Format("2006-01-02")
 	costsAggregation := "UnblendedCost"
 
+	endDateStr := endDate.Format("2006-01-02")
+
+	// On the first day of a month the period is empty and Cost Explorer rejects it
+	if firstOfMonthStr == endDateStr {
+		return &model.CostInfo{
+			CostGroup: model.CostGroup{},
+			DateInterval: model.DateInterval{
+				Start: aws.String(firstOfMonthStr),
+				End:   aws.String(endDateStr),
+			},
+		}, nil
+	}
+
+	period := firstOfMonthStr + "/" + endDateStr
+	s.mu.Lock()
+	cached, ok := s.monthCosts[period]
+	s.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
 	input := &costexplorer.GetCostAndUsageInput{
 		Granularity: types.GranularityMonthly,
 		TimePeriod: &types.DateInterval{
@@ -48,18 +78,28 @@ func (s *service) GetMonthCostsByService(ctx context.Context, endDate time.Time)
 		},
 	}
 
-	output, err := s.client.GetCostAndUsage(ctx, input)
+	resultsByTime, err := s.getCostAndUsage(ctx, input)
 	if err != nil {
 		return nil, err
 	}
 
-	return &model.CostInfo{
-		CostGroup: s.filterGroups(output.ResultsByTime[0].Groups, costsAggregation),
+	if len(resultsByTime) == 0 {
+		return nil, apierror.NewEmptyDataError("aws", fmt.Sprintf("Cost Explorer returned no results for %s", period))
+	}
+
+	costInfo := &model.CostInfo{
+		CostGroup: s.filterGroups(resultsByTime[0].Groups, costsAggregation),
 		DateInterval: model.DateInterval{
-			Start: output.ResultsByTime[0].TimePeriod.Start,
-			End:   output.ResultsByTime[0].TimePeriod.End,
+			Start: resultsByTime[0].TimePeriod.Start,
+			End:   resultsByTime[0].TimePeriod.End,
 		},
-	}, nil
+	}
+
+	s.mu.Lock()
+	s.monthCosts[period] = costInfo
+	s.mu.Unlock()
+
+	return costInfo, nil
 }
 
 func (s *service) GetCurrentMonthTotalCosts(ctx context.Context) (*string, error) {
@@ -84,15 +124,21 @@ func (s *service) GetLastSixMonthsCosts(ctx context.Context) ([]model.CostInfo,
 		Metrics: []string{costsAggregation},
 	}
 
-	output, err := s.client.GetCostAndUsage(ctx, input)
+	resultsByTime, err := s.getCostAndUsage(ctx, input)
 	if err != nil {
 		return nil, err
 	}
 
-	monthlyCosts := make([]model.CostInfo, 0, len(output.ResultsByTime))
+	monthlyCosts := make([]model.CostInfo, 0, len(resultsByTime))
+
+	for _, timeResult := range resultsByTime {
+		totalInfo := timeResult.Total[costsAggregation]
+		amount, _ := strconv.ParseFloat(aws.ToString(totalInfo.Amount), 64)
+		unit := aws.ToString(totalInfo.Unit)
+		if unit == "" {
+			unit = defaultUnit
+		}
 
-	for _, timeResult := range output.ResultsByTime {
-		amount, _ := strconv.ParseFloat(*timeResult.Total[costsAggregation].Amount, 64)
 		costGroups := make(map[string]struct {
 			Amount float64
 			Unit   string
@@ -103,7 +149,7 @@ func (s *service) GetLastSixMonthsCosts(ctx context.Context) ([]model.CostInfo,
 			Unit   string
 		}{
 			Amount: amount,
-			Unit:   *timeResult.Total[costsAggregation].Unit,
+			Unit:   unit,
 		}
 
 		var monthlyCost model.CostInfo = model.CostInfo{
@@ -119,7 +165,240 @@ func (s *service) GetLastSixMonthsCosts(ctx context.Context) ([]model.CostInfo,
 	return monthlyCosts, nil
 }
 
+// GetCostsByTag groups spend in [start, end) by the value of tagKey. Cost
+// Explorer returns keys as "key$value", with an empty value for untagged spend.
+func (s *service) GetCostsByTag(ctx context.Context, tagKey string, start, end time.Time) (*model.CostInfo, error) {
+	group := types.GroupDefinition{Key: aws.String(tagKey), Type: types.GroupDefinitionTypeTag}
+	return s.getGroupedCosts(ctx, group, start, end, func(value string) string {
+		value = strings.TrimPrefix(value, tagKey+"$")
+		if value == "" {
+			value = model.Untagged
+		}
+		return value
+	})
+}
+
+// GetCostsByDimension groups spend in [start, end) by service or by usage
+// type, the closest AWS has to a SKU. AWS has neither resource groups nor
+// projects to allocate spend by; use GetCostsByTag instead.
+func (s *service) GetCostsByDimension(ctx context.Context, dimension string, start, end time.Time) (*model.CostInfo, error) {
+	var key string
+	switch dimension {
+	case model.DimensionService:
+		key = "SERVICE"
+	case model.DimensionSKU:
+		key = "USAGE_TYPE"
+	default:
+		return nil, fmt.Errorf("aws cannot group costs by %s", dimension)
+	}
+
+	group := types.GroupDefinition{Key: aws.String(key), Type: types.GroupDefinitionTypeDimension}
+	return s.getGroupedCosts(ctx, group, start, end, func(value string) string { return value })
+}
+
+// GetServiceCosts breaks down one service's spend in [start, end) by day or
+// by resource ID. Cost Explorer only has resource-level data for the last 14
+// days, once it is enabled in the Cost Explorer preferences, so a resource
+// breakdown never starts earlier than that.
+func (s *service) GetServiceCosts(ctx context.Context, serviceName, breakdown string, start, end time.Time) (*model.CostInfo, error) {
+	costsAggregation := "UnblendedCost"
+	filter := &types.Expression{
+		Dimensions: &types.DimensionValues{
+			Key:    types.DimensionService,
+			Values: []string{serviceName},
+		},
+	}
+
+	if breakdown == model.BreakdownResource {
+		earliest := time.Now().AddDate(0, 0, -(resourceDataDays - 1))
+		if start.Before(earliest) {
+			start = earliest
+		}
+	}
+
+	startStr := start.Format("2006-01-02")
+	endStr := end.Format("2006-01-02")
+	costInfo := &model.CostInfo{
+		CostGroup: model.CostGroup{},
+		DateInterval: model.DateInterval{
+			Start: aws.String(startStr),
+			End:   aws.String(endStr),
+		},
+	}
+
+	// Cost Explorer rejects an empty period
+	if startStr >= endStr {
+		return costInfo, nil
+	}
+
+	switch breakdown {
+	case model.BreakdownDaily:
+		resultsByTime, err := s.getCostAndUsage(ctx, &costexplorer.GetCostAndUsageInput{
+			Granularity: types.GranularityDaily,
+			TimePeriod: &types.DateInterval{
+				Start: aws.String(startStr),
+				End:   aws.String(endStr),
+			},
+			Metrics: []string{costsAggregation},
+			Filter:  filter,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, result := range resultsByTime {
+			total := result.Total[costsAggregation]
+			amount, _ := strconv.ParseFloat(aws.ToString(total.Amount), 64)
+			if amount == 0 || result.TimePeriod == nil {
+				continue
+			}
+			unit := aws.ToString(total.Unit)
+			if unit == "" {
+				unit = defaultUnit
+			}
+
+			costInfo.CostGroup[aws.ToString(result.TimePeriod.Start)] = struct {
+				Amount float64
+				Unit   string
+			}{
+				Amount: amount,
+				Unit:   unit,
+			}
+		}
+
+	case model.BreakdownResource:
+		input := &costexplorer.GetCostAndUsageWithResourcesInput{
+			Granularity: types.GranularityDaily,
+			TimePeriod: &types.DateInterval{
+				Start: aws.String(startStr),
+				End:   aws.String(endStr),
+			},
+			Metrics: []string{costsAggregation},
+			Filter:  filter,
+			GroupBy: []types.GroupDefinition{
+				{
+					Key:  aws.String("RESOURCE_ID"),
+					Type: types.GroupDefinitionTypeDimension,
+				},
+			},
+		}
+
+		for {
+			output, err := s.client.GetCostAndUsageWithResources(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+
+			// Each day is a separate result, so a resource's spend is summed over them
+			for _, result := range output.ResultsByTime {
+				for resourceID, cost := range s.filterGroups(result.Groups, costsAggregation) {
+					costInfo.CostGroup[resourceID] = struct {
+						Amount float64
+						Unit   string
+					}{
+						Amount: costInfo.CostGroup[resourceID].Amount + cost.Amount,
+						Unit:   cost.Unit,
+					}
+				}
+			}
+
+			if aws.ToString(output.NextPageToken) == "" {
+				break
+			}
+			next := *input
+			next.NextPageToken = output.NextPageToken
+			input = &next
+		}
+
+	default:
+		return nil, fmt.Errorf("aws cannot break down service costs by %s", breakdown)
+	}
+
+	return costInfo, nil
+}
+
+// getGroupedCosts sums spend in [start, end) by group, naming each group with
+// the value returned by name
+func (s *service) getGroupedCosts(ctx context.Context, group types.GroupDefinition, start, end time.Time, name func(string) string) (*model.CostInfo, error) {
+	startStr := start.Format("2006-01-02")
+	endStr := end.Format("2006-01-02")
+	costsAggregation := "UnblendedCost"
+
+	costInfo := &model.CostInfo{
+		CostGroup: model.CostGroup{},
+		DateInterval: model.DateInterval{
+			Start: aws.String(startStr),
+			End:   aws.String(endStr),
+		},
+	}
+
+	// Cost Explorer rejects an empty period
+	if startStr >= endStr {
+		return costInfo, nil
+	}
+
+	input := &costexplorer.GetCostAndUsageInput{
+		Granularity: types.GranularityMonthly,
+		TimePeriod: &types.DateInterval{
+			Start: aws.String(startStr),
+			End:   aws.String(endStr),
+		},
+		Metrics: []string{costsAggregation},
+		GroupBy: []types.GroupDefinition{group},
+	}
+
+	resultsByTime, err := s.getCostAndUsage(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// A range spanning several months returns one result per month
+	for _, result := range resultsByTime {
+		for value, cost := range s.filterGroups(result.Groups, costsAggregation) {
+			value = name(value)
+			existing := costInfo.CostGroup[value]
+			costInfo.CostGroup[value] = struct {
+				Amount float64
+				Unit   string
+			}{
+				Amount: existing.Amount + cost.Amount,
+				Unit:   cost.Unit,
+			}
+		}
+	}
+
+	return costInfo, nil
+}
+
+// GetMonthTotalCosts sums the by-service costs for the period, which are usually
+// already fetched for the comparison table. The period total is only queried
+// directly when there are no service groups to derive it from.
 func (s *service) GetMonthTotalCosts(ctx context.Context, endDate time.Time) (*string, error) {
+	costInfo, err := s.GetMonthCostsByService(ctx, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	var amount float64
+	var unit string
+	for _, group := range costInfo.CostGroup {
+		amount += group.Amount
+		unit = group.Unit
+	}
+
+	if unit == "" {
+		if len(costInfo.CostGroup) == 0 && aws.ToString(costInfo.DateInterval.Start) == aws.ToString(costInfo.DateInterval.End) {
+			total := fmt.Sprintf("%.2f %s", 0.0, defaultUnit)
+			return &total, nil
+		}
+		return s.getMonthTotalCostsFromTotal(ctx, endDate)
+	}
+
+	total := fmt.Sprintf("%.2f %s", amount, unit)
+	return &total, nil
+}
+
+func (s *service) getMonthTotalCostsFromTotal(ctx context.Context, endDate time.Time) (*string, error) {
 	firstOfMonth := s.getFirstDayOfMonth(endDate)
 	firstOfMonthStr := firstOfMonth.Format("2006-01-02")
 	costsAggregation := "UnblendedCost"
@@ -133,21 +412,68 @@ func (s *service) GetMonthTotalCosts(ctx context.Context, endDate time.Time) (*s
 		Metrics: []string{costsAggregation},
 	}
 
-	output, err := s.client.GetCostAndUsage(ctx, input)
+	resultsByTime, err := s.getCostAndUsage(ctx, input)
 	if err != nil {
 		return nil, err
 	}
 
-	totalInfo := output.ResultsByTime[0].Total[costsAggregation]
-	amount, err := strconv.ParseFloat(*totalInfo.Amount, 64)
-	if err != nil || amount == 0 {
-		panic("Could not parse total amount")
+	// A brand-new account has no spend yet, which is a valid zero total
+	amount, unit := 0.0, defaultUnit
+	if len(resultsByTime) > 0 {
+		totalInfo := resultsByTime[0].Total[costsAggregation]
+		if totalInfo.Amount != nil {
+			amount, err = strconv.ParseFloat(*totalInfo.Amount, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse total amount %q: %w", *totalInfo.Amount, err)
+			}
+		}
+		if totalInfo.Unit != nil {
+			unit = *totalInfo.Unit
+		}
 	}
 
-	total := fmt.Sprintf("%.2f %s", amount, *totalInfo.Unit)
+	total := fmt.Sprintf("%.2f %s", amount, unit)
 	return &total, nil
 }
 
+// getCostAndUsage follows NextPageToken and merges the pages. Grouped queries
+// can split one period's groups across pages, so groups are appended to the
+// period they belong to rather than repeating the period.
+func (s *service) getCostAndUsage(ctx context.Context, input *costexplorer.GetCostAndUsageInput) ([]types.ResultByTime, error) {
+	var results []types.ResultByTime
+	periodIndex := make(map[string]int)
+
+	for {
+		output, err := s.client.GetCostAndUsage(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, result := range output.ResultsByTime {
+			var key string
+			if result.TimePeriod != nil {
+				key = aws.ToString(result.TimePeriod.Start) + "/" + aws.ToString(result.TimePeriod.End)
+			}
+
+			if i, ok := periodIndex[key]; ok {
+				results[i].Groups = append(results[i].Groups, result.Groups...)
+				continue
+			}
+
+			periodIndex[key] = len(results)
+			results = append(results, result)
+		}
+
+		if aws.ToString(output.NextPageToken) == "" {
+			return results, nil
+		}
+
+		next := *input
+		next.NextPageToken = output.NextPageToken
+		input = &next
+	}
+}
+
 func (s *service) getFirstDayOfMonth(month time.Time) time.Time {
 	return time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
 }
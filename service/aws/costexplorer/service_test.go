@@ -0,0 +1,198 @@
+package awscostexplorer
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+// fakeCostExplorer serves results in pages linked by NextPageToken. A page
+// listed in failPage returns errPage instead.
+type fakeCostExplorer struct {
+	pages    [][]types.ResultByTime
+	failPage int
+	calls    int
+}
+
+var errPage = errors.New("throttled")
+
+func (f *fakeCostExplorer) page(token *string) (int, *string, error) {
+	f.calls++
+	i := 0
+	if token != nil {
+		i = int((*token)[0] - '0')
+	}
+	if i > 0 && i == f.failPage {
+		return 0, nil, errPage
+	}
+
+	var next *string
+	if i+1 < len(f.pages) {
+		next = aws.String(string(rune('0' + i + 1)))
+	}
+	return i, next, nil
+}
+
+func (f *fakeCostExplorer) GetCostAndUsage(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+	i, next, err := f.page(params.NextPageToken)
+	if err != nil {
+		return nil, err
+	}
+	return &costexplorer.GetCostAndUsageOutput{ResultsByTime: f.pages[i], NextPageToken: next}, nil
+}
+
+func (f *fakeCostExplorer) GetCostAndUsageWithResources(ctx context.Context, params *costexplorer.GetCostAndUsageWithResourcesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageWithResourcesOutput, error) {
+	i, next, err := f.page(params.NextPageToken)
+	if err != nil {
+		return nil, err
+	}
+	return &costexplorer.GetCostAndUsageWithResourcesOutput{ResultsByTime: f.pages[i], NextPageToken: next}, nil
+}
+
+// result returns one period's groups with their unblended cost in USD
+func result(start, end string, groups map[string]string) types.ResultByTime {
+	r := types.ResultByTime{TimePeriod: &types.DateInterval{Start: aws.String(start), End: aws.String(end)}}
+	for _, key := range slices.Sorted(maps.Keys(groups)) {
+		r.Groups = append(r.Groups, types.Group{
+			Keys: []string{key},
+			Metrics: map[string]types.MetricValue{
+				"UnblendedCost": {Amount: aws.String(groups[key]), Unit: aws.String("USD")},
+			},
+		})
+	}
+	return r
+}
+
+func amounts(costInfo *model.CostInfo) map[string]float64 {
+	result := make(map[string]float64, len(costInfo.CostGroup))
+	for name, cost := range costInfo.CostGroup {
+		result[name] = cost.Amount
+	}
+	return result
+}
+
+func TestGetCostsByDimensionFollowsNextPageToken(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		pages    [][]types.ResultByTime
+		failPage int
+		want     map[string]float64
+		wantErr  bool
+	}{
+		{
+			name: "groups of one period split across pages",
+			pages: [][]types.ResultByTime{
+				{result("2026-01-01", "2026-02-01", map[string]string{"Amazon EC2": "10", "Amazon S3": "2"})},
+				{result("2026-01-01", "2026-02-01", map[string]string{"AWS Lambda": "1"})},
+				{
+					result("2026-01-01", "2026-02-01", map[string]string{"Amazon RDS": "5"}),
+					result("2026-02-01", "2026-03-01", map[string]string{"Amazon EC2": "20"}),
+				},
+			},
+			want: map[string]float64{"Amazon EC2": 30, "Amazon S3": 2, "AWS Lambda": 1, "Amazon RDS": 5},
+		},
+		{
+			name: "error on a later page",
+			pages: [][]types.ResultByTime{
+				{result("2026-01-01", "2026-02-01", map[string]string{"Amazon EC2": "10"})},
+				{result("2026-01-01", "2026-02-01", map[string]string{"Amazon S3": "2"})},
+				{result("2026-02-01", "2026-03-01", map[string]string{"Amazon EC2": "20"})},
+			},
+			failPage: 2,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeCostExplorer{pages: tt.pages, failPage: tt.failPage}
+			s := &service{client: client, monthCosts: make(map[string]*model.CostInfo)}
+
+			got, err := s.GetCostsByDimension(context.Background(), model.DimensionService, start, end)
+			if tt.wantErr {
+				if !errors.Is(err, errPage) {
+					t.Fatalf("GetCostsByDimension() error = %v, want %v", err, errPage)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetCostsByDimension() error = %v", err)
+			}
+
+			if !maps.Equal(amounts(got), tt.want) {
+				t.Errorf("GetCostsByDimension() = %v, want %v", amounts(got), tt.want)
+			}
+			if client.calls != len(tt.pages) {
+				t.Errorf("GetCostAndUsage called %d times, want %d", client.calls, len(tt.pages))
+			}
+		})
+	}
+}
+
+func TestGetServiceCostsByResourceFollowsNextPageToken(t *testing.T) {
+	end := time.Now().UTC()
+	start := end.AddDate(0, 0, -7)
+
+	tests := []struct {
+		name     string
+		pages    [][]types.ResultByTime
+		failPage int
+		want     map[string]float64
+		wantErr  bool
+	}{
+		{
+			name: "resources summed over days and pages",
+			pages: [][]types.ResultByTime{
+				{result("d1", "d2", map[string]string{"i-1": "1.5", "i-2": "2"})},
+				{result("d2", "d3", map[string]string{"i-1": "1.5"})},
+				{result("d3", "d4", map[string]string{"i-3": "4"})},
+			},
+			want: map[string]float64{"i-1": 3, "i-2": 2, "i-3": 4},
+		},
+		{
+			name: "error on a later page",
+			pages: [][]types.ResultByTime{
+				{result("d1", "d2", map[string]string{"i-1": "1"})},
+				{result("d2", "d3", map[string]string{"i-1": "1"})},
+			},
+			failPage: 1,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeCostExplorer{pages: tt.pages, failPage: tt.failPage}
+			s := &service{client: client, monthCosts: make(map[string]*model.CostInfo)}
+
+			got, err := s.GetServiceCosts(context.Background(), "Amazon Elastic Compute Cloud - Compute", model.BreakdownResource, start, end)
+			if tt.wantErr {
+				if !errors.Is(err, errPage) {
+					t.Fatalf("GetServiceCosts() error = %v, want %v", err, errPage)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetServiceCosts() error = %v", err)
+			}
+
+			if !maps.Equal(amounts(got), tt.want) {
+				t.Errorf("GetServiceCosts() = %v, want %v", amounts(got), tt.want)
+			}
+			if client.calls != len(tt.pages) {
+				t.Errorf("GetCostAndUsageWithResources called %d times, want %d", client.calls, len(tt.pages))
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package awscostexplorer
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
@@ -9,7 +10,18 @@ import (
 )
 
 type service struct {
-	client *costexplorer.Client
+	client costExplorerAPI
+
+	// monthCosts memoizes by-service results per period so totals can be
+	// derived from them without another GetCostAndUsage request
+	mu         sync.Mutex
+	monthCosts map[string]*model.CostInfo
+}
+
+// costExplorerAPI is the part of the Cost Explorer client the service calls
+type costExplorerAPI interface {
+	GetCostAndUsage(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error)
+	GetCostAndUsageWithResources(ctx context.Context, params *costexplorer.GetCostAndUsageWithResourcesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageWithResourcesOutput, error)
 }
 
 type CostService interface {
@@ -19,4 +31,7 @@ type CostService interface {
 	GetCurrentMonthTotalCosts(ctx context.Context) (*string, error)
 	GetLastMonthTotalCosts(ctx context.Context) (*string, error)
 	GetLastSixMonthsCosts(ctx context.Context) ([]model.CostInfo, error)
+	GetCostsByTag(ctx context.Context, tagKey string, start, end time.Time) (*model.CostInfo, error)
+	GetCostsByDimension(ctx context.Context, dimension string, start, end time.Time) (*model.CostInfo, error)
+	GetServiceCosts(ctx context.Context, serviceName, breakdown string, start, end time.Time) (*model.CostInfo, error)
 }
@@ -10,18 +10,28 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/apierror"
+	awscloudtrail "github.com/elC0mpa/aws-doctor/service/aws/cloudtrail"
+	"github.com/elC0mpa/aws-doctor/service/ownership"
+	"github.com/elC0mpa/aws-doctor/service/warnings"
 	"github.com/elC0mpa/aws-doctor/utils"
 )
 
-func NewService(awsconfig aws.Config) *service {
+// NewService creates the EC2 waste checks. Owners are read from the first of
+// ownerTagKeys a resource is tagged with.
+func NewService(awsconfig aws.Config, ownerTagKeys []string) *service {
 	client := ec2.NewFromConfig(awsconfig)
 	return &service{
-		client: client,
+		client:     client,
+		stopEvents: awscloudtrail.NewService(awsconfig),
+		owners:     ownership.NewResolver(ownerTagKeys),
 	}
 }
 
 var transitionReasonRegex = regexp.MustCompile(`\(([^)]+)\)`)
 
+// DescribeAddresses and DescribeReservedInstances are not paginated by EC2;
+// a single call returns every address and reservation in the region.
 func (s *service) GetElasticIpAddressesInfo(ctx context.Context) (*model.ElasticIpInfo, error) {
 	output, err := s.client.DescribeAddresses(ctx, nil)
 	if err != nil {
@@ -49,12 +59,14 @@ func (s *service) GetElasticIpAddressesInfo(ctx context.Context) (*model.Elastic
 				return nil, err
 			}
 
-			interfaceType := networkInterface.NetworkInterfaces[0].InterfaceType
-			if interfaceType == types.NetworkInterfaceTypeInterface {
-				interfaceType = s.getResourceTypeFromDescription(aws.ToString(networkInterface.NetworkInterfaces[0].Description))
-			}
+			if len(networkInterface.NetworkInterfaces) > 0 {
+				interfaceType := networkInterface.NetworkInterfaces[0].InterfaceType
+				if interfaceType == types.NetworkInterfaceTypeInterface {
+					interfaceType = s.getResourceTypeFromDescription(aws.ToString(networkInterface.NetworkInterfaces[0].Description))
+				}
 
-			attachedIp.ResourceType = string(interfaceType)
+				attachedIp.ResourceType = string(interfaceType)
+			}
 		}
 
 		attachedEips = append(attachedEips, attachedIp)
@@ -84,7 +96,7 @@ func (s *service) GetUnusedElasticIpAddressesInfo(ctx context.Context) ([]types.
 }
 
 func (s *service) GetUnusedEBSVolumes(ctx context.Context) ([]types.Volume, error) {
-	output, err := s.client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+	return s.describeVolumes(ctx, &ec2.DescribeVolumesInput{
 		Filters: []types.Filter{
 			{
 				Name:   aws.String("status"),
@@ -92,14 +104,59 @@ func (s *service) GetUnusedEBSVolumes(ctx context.Context) ([]types.Volume, erro
 			},
 		},
 	})
-	if err != nil {
-		return nil, err
+}
+
+// describeVolumes follows NextToken until every matching volume is returned
+func (s *service) describeVolumes(ctx context.Context, input *ec2.DescribeVolumesInput) ([]types.Volume, error) {
+	var volumes []types.Volume
+
+	paginator := ec2.NewDescribeVolumesPaginator(s.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, page.Volumes...)
+	}
+
+	return volumes, nil
+}
+
+// describeInstances follows NextToken until every matching reservation is returned
+func (s *service) describeInstances(ctx context.Context, input *ec2.DescribeInstancesInput) ([]types.Reservation, error) {
+	var reservations []types.Reservation
+
+	paginator := ec2.NewDescribeInstancesPaginator(s.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, page.Reservations...)
 	}
 
-	return output.Volumes, nil
+	return reservations, nil
 }
 
 func (s *service) GetStoppedInstancesInfo(ctx context.Context) ([]types.Instance, []types.Volume, error) {
+	stopped, volumes, err := s.getStoppedInstances(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	instances := make([]types.Instance, 0, len(stopped))
+	for _, inst := range stopped {
+		instances = append(instances, inst.instance)
+	}
+	return instances, volumes, nil
+}
+
+// getStoppedInstances returns instances stopped for more than 30 days with the
+// volumes of every stopped instance. The stop time is read from the state
+// transition reason, then from CloudTrail StopInstances events, and is
+// otherwise estimated from the root volume attach time or launch time, which
+// the stop cannot precede. Instances with no usable time at all are kept.
+func (s *service) getStoppedInstances(ctx context.Context) ([]stoppedInstance, []types.Volume, error) {
 	input := &ec2.DescribeInstancesInput{
 		Filters: []types.Filter{
 			{
@@ -109,51 +166,104 @@ func (s *service) GetStoppedInstancesInfo(ctx context.Context) ([]types.Instance
 		},
 	}
 
-	output, err := s.client.DescribeInstances(ctx, input)
+	reservations, err := s.describeInstances(ctx, input)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	var stoppedInstanceVolumeIDs []string
-	var stoppedInstanceForMoreThan30Days []types.Instance
-
-	thresholdTime := time.Now().Add(-30 * 24 * time.Hour)
+	var candidates []stoppedInstance
+	var unresolvedIDs []string
 
-	for _, reservation := range output.Reservations {
+	for _, reservation := range reservations {
 		for _, instance := range reservation.Instances {
 			for _, mapping := range instance.BlockDeviceMappings {
 				if mapping.Ebs != nil {
 					stoppedInstanceVolumeIDs = append(stoppedInstanceVolumeIDs, aws.ToString(mapping.Ebs.VolumeId))
 				}
 			}
+
+			candidate := stoppedInstance{instance: instance}
 			reason := aws.ToString(instance.StateTransitionReason)
-			stoppedAt, err := utils.ParseTransitionDate(reason)
-			if err != nil {
-				continue
+			if stoppedAt, err := utils.ParseTransitionDate(reason); err == nil {
+				candidate.stoppedAt = &stoppedAt
+			} else {
+				unresolvedIDs = append(unresolvedIDs, aws.ToString(instance.InstanceId))
 			}
+			candidates = append(candidates, candidate)
+		}
+	}
 
-			if stoppedAt.Before(thresholdTime) {
-				stoppedInstanceForMoreThan30Days = append(stoppedInstanceForMoreThan30Days, instance)
+	if len(unresolvedIDs) > 0 {
+		stopTimes, err := s.stopEvents.GetLastStopTimes(ctx, unresolvedIDs)
+		if err != nil {
+			// Without cloudtrail:LookupEvents the estimates below are still usable
+			warnings.Add(ctx, apierror.NewWarning("aws", "cloudtrail stop events", err))
+		}
+
+		for i := range candidates {
+			if candidates[i].stoppedAt != nil {
+				continue
+			}
+			if stoppedAt, ok := stopTimes[aws.ToString(candidates[i].instance.InstanceId)]; ok {
+				candidates[i].stoppedAt = &stoppedAt
+				continue
 			}
+			if estimate := estimateStopTime(candidates[i].instance); estimate != nil {
+				candidates[i].stoppedAt = estimate
+				candidates[i].estimated = true
+			}
+		}
+	}
+
+	// An estimate bounds how long the instance has been stopped from above, so
+	// one after the threshold means it stopped too recently
+	thresholdTime := time.Now().Add(-30 * 24 * time.Hour)
+
+	var stoppedInstanceForMoreThan30Days []stoppedInstance
+	for _, candidate := range candidates {
+		if candidate.stoppedAt != nil && !candidate.stoppedAt.Before(thresholdTime) {
+			continue
 		}
+		stoppedInstanceForMoreThan30Days = append(stoppedInstanceForMoreThan30Days, candidate)
 	}
 
 	var stoppedInstanceVolumes []types.Volume
 
 	if len(stoppedInstanceVolumeIDs) > 0 {
-		outputEBS, err := s.client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+		stoppedInstanceVolumes, err = s.describeVolumes(ctx, &ec2.DescribeVolumesInput{
 			VolumeIds: stoppedInstanceVolumeIDs,
 		})
 		if err != nil {
 			return nil, nil, err
 		}
-
-		stoppedInstanceVolumes = outputEBS.Volumes
 	}
 
 	return stoppedInstanceForMoreThan30Days, stoppedInstanceVolumes, nil
 }
 
+// estimateStopTime returns the latest of the launch time and the root volume
+// attach time. Both are refreshed when an instance starts, so the instance
+// cannot have stopped before then.
+func estimateStopTime(instance types.Instance) *time.Time {
+	var estimate *time.Time
+	if instance.LaunchTime != nil {
+		estimate = instance.LaunchTime
+	}
+
+	rootDevice := aws.ToString(instance.RootDeviceName)
+	for _, mapping := range instance.BlockDeviceMappings {
+		if mapping.Ebs == nil || mapping.Ebs.AttachTime == nil || aws.ToString(mapping.DeviceName) != rootDevice {
+			continue
+		}
+		if estimate == nil || mapping.Ebs.AttachTime.After(*estimate) {
+			estimate = mapping.Ebs.AttachTime
+		}
+	}
+
+	return estimate
+}
+
 func (s *service) GetReservedInstanceExpiringOrExpired30DaysWaste(ctx context.Context) ([]model.RiExpirationInfo, error) {
 	input := &ec2.DescribeReservedInstancesInput{
 		Filters: []types.Filter{
@@ -218,10 +328,14 @@ func (s *service) GetUnusedVolumes(ctx context.Context) ([]model.UnusedVolume, e
 
 	result := make([]model.UnusedVolume, 0, len(volumes))
 	for _, v := range volumes {
+		tags := tagMap(v.Tags)
 		result = append(result, model.UnusedVolume{
 			ID:     aws.ToString(v.VolumeId),
 			SizeGB: aws.ToInt32(v.Size),
 			Status: "available",
+			Region: aws.ToString(v.AvailabilityZone),
+			Tags:   tags,
+			Owner:  s.owners.Resolve(tags),
 		})
 	}
 	return result, nil
@@ -236,9 +350,13 @@ func (s *service) GetUnusedIPs(ctx context.Context) ([]model.UnusedIP, error) {
 
 	result := make([]model.UnusedIP, 0, len(addresses))
 	for _, addr := range addresses {
+		tags := tagMap(addr.Tags)
 		result = append(result, model.UnusedIP{
 			Address:      aws.ToString(addr.PublicIp),
 			AllocationID: aws.ToString(addr.AllocationId),
+			Region:       aws.ToString(addr.NetworkBorderGroup),
+			Tags:         tags,
+			Owner:        s.owners.Resolve(tags),
 		})
 	}
 	return result, nil
@@ -246,17 +364,20 @@ func (s *service) GetUnusedIPs(ctx context.Context) ([]model.UnusedIP, error) {
 
 // GetStoppedInstances implements service.ResourceService
 func (s *service) GetStoppedInstances(ctx context.Context) ([]model.StoppedInstance, []model.UnusedVolume, error) {
-	instances, volumes, err := s.GetStoppedInstancesInfo(ctx)
+	instances, volumes, err := s.getStoppedInstances(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	now := time.Now()
 	stoppedInstances := make([]model.StoppedInstance, 0, len(instances))
-	for _, inst := range instances {
-		reason := aws.ToString(inst.StateTransitionReason)
-		stoppedAt, _ := utils.ParseTransitionDate(reason)
-		days := int(now.Sub(stoppedAt).Hours() / 24)
+	instanceOwners := make(map[string]string, len(instances))
+	for _, stopped := range instances {
+		inst := stopped.instance
+		days := -1
+		if stopped.stoppedAt != nil {
+			days = int(now.Sub(*stopped.stoppedAt).Hours() / 24)
+		}
 
 		name := aws.ToString(inst.InstanceId)
 		for _, tag := range inst.Tags {
@@ -266,19 +387,44 @@ func (s *service) GetStoppedInstances(ctx context.Context) ([]model.StoppedInsta
 			}
 		}
 
+		var region string
+		if inst.Placement != nil {
+			region = aws.ToString(inst.Placement.AvailabilityZone)
+		}
+
+		tags := tagMap(inst.Tags)
+		owner := s.owners.Resolve(tags)
+		instanceOwners[aws.ToString(inst.InstanceId)] = owner
+
 		stoppedInstances = append(stoppedInstances, model.StoppedInstance{
-			ID:          aws.ToString(inst.InstanceId),
-			Name:        name,
-			StoppedDays: days,
+			ID:                 aws.ToString(inst.InstanceId),
+			Name:               name,
+			StoppedDays:        days,
+			StoppedAtEstimated: stopped.estimated,
+			Region:             region,
+			InstanceType:       string(inst.InstanceType),
+			LaunchTime:         inst.LaunchTime,
+			Tags:               tags,
+			Owner:              owner,
 		})
 	}
 
 	unusedVolumes := make([]model.UnusedVolume, 0, len(volumes))
 	for _, v := range volumes {
+		tags := tagMap(v.Tags)
+		owner := s.owners.Resolve(tags)
+		// Untagged volumes belong to whoever owns the instance they are attached to
+		if owner == "" && len(v.Attachments) > 0 {
+			owner = instanceOwners[aws.ToString(v.Attachments[0].InstanceId)]
+		}
+
 		unusedVolumes = append(unusedVolumes, model.UnusedVolume{
 			ID:     aws.ToString(v.VolumeId),
 			SizeGB: aws.ToInt32(v.Size),
 			Status: "attached_stopped",
+			Region: aws.ToString(v.AvailabilityZone),
+			Tags:   tags,
+			Owner:  owner,
 		})
 	}
 
@@ -308,6 +454,19 @@ func (s *service) GetExpiringReservations(ctx context.Context) ([]model.Reservat
 	return result, nil
 }
 
+// tagMap converts EC2 tags to a map, returning nil when there are none
+func tagMap(tags []types.Tag) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	result := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		result[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return result
+}
+
 func (s *service) getResourceTypeFromDescription(description string) types.NetworkInterfaceType {
 	desc := strings.ToLower(description)
 
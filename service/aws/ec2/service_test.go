@@ -0,0 +1,292 @@
+package awsec2
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// fakeEC2 serves volumes and instances in pages linked by NextToken. A page
+// listed in failPage returns errPage instead.
+type fakeEC2 struct {
+	ec2API
+
+	volumePages   [][]types.Volume
+	instancePages [][]types.Instance
+	failPage      int
+
+	volumeTokens   []string
+	instanceTokens []string
+}
+
+var errPage = errors.New("throttled")
+
+// page returns the index of the page a NextToken points to
+func page(token *string) int {
+	if token == nil {
+		return 0
+	}
+	return int((*token)[0] - '0')
+}
+
+// nextToken links to the page after i, or ends the listing
+func nextToken(i, pages int) *string {
+	if i+1 >= pages {
+		return nil
+	}
+	return aws.String(string(rune('0' + i + 1)))
+}
+
+func (f *fakeEC2) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	f.volumeTokens = append(f.volumeTokens, aws.ToString(params.NextToken))
+	i := page(params.NextToken)
+	if i > 0 && i == f.failPage {
+		return nil, errPage
+	}
+	return &ec2.DescribeVolumesOutput{Volumes: f.volumePages[i], NextToken: nextToken(i, len(f.volumePages))}, nil
+}
+
+func (f *fakeEC2) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	f.instanceTokens = append(f.instanceTokens, aws.ToString(params.NextToken))
+	i := page(params.NextToken)
+	if i > 0 && i == f.failPage {
+		return nil, errPage
+	}
+	return &ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{{Instances: f.instancePages[i]}},
+		NextToken:    nextToken(i, len(f.instancePages)),
+	}, nil
+}
+
+func volumes(ids ...string) []types.Volume {
+	result := make([]types.Volume, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, types.Volume{VolumeId: aws.String(id), Size: aws.Int32(8)})
+	}
+	return result
+}
+
+// stoppedInstances returns instances stopped long ago, each with a root volume
+// named after it
+func stoppedInstances(ids ...string) []types.Instance {
+	result := make([]types.Instance, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, types.Instance{
+			InstanceId:            aws.String(id),
+			StateTransitionReason: aws.String("User initiated (2020-01-01 00:00:00 GMT)"),
+			BlockDeviceMappings: []types.InstanceBlockDeviceMapping{
+				{Ebs: &types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-" + id)}},
+			},
+		})
+	}
+	return result
+}
+
+// fakeStopEvents finds no StopInstances event, or fails with err
+type fakeStopEvents struct {
+	err error
+}
+
+func (f fakeStopEvents) GetLastStopTimes(ctx context.Context, instanceIDs []string) (map[string]time.Time, error) {
+	return nil, f.err
+}
+
+// unresolvedInstance returns an instance whose transition reason has no date,
+// launched and with its root volume attached the given number of days ago
+func unresolvedInstance(id string, launchedDays, attachedDays int) types.Instance {
+	now := time.Now()
+	instance := types.Instance{
+		InstanceId:            aws.String(id),
+		StateTransitionReason: aws.String("User initiated"),
+		LaunchTime:            aws.Time(now.AddDate(0, 0, -launchedDays)),
+		RootDeviceName:        aws.String("/dev/xvda"),
+	}
+	if attachedDays >= 0 {
+		instance.BlockDeviceMappings = []types.InstanceBlockDeviceMapping{{
+			DeviceName: aws.String("/dev/xvda"),
+			Ebs:        &types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-" + id), AttachTime: aws.Time(now.AddDate(0, 0, -attachedDays))},
+		}}
+	}
+	return instance
+}
+
+func TestGetStoppedInstancesEstimatesUnknownStopTimes(t *testing.T) {
+	tests := []struct {
+		name          string
+		instance      types.Instance
+		stopEventsErr error
+		wantDays      int
+		wantDropped   bool
+	}{
+		{
+			name:        "launched recently",
+			instance:    unresolvedInstance("i-recent", 2, -1),
+			wantDropped: true,
+		},
+		{
+			name:        "root volume attached recently",
+			instance:    unresolvedInstance("i-reattached", 100, 3),
+			wantDropped: true,
+		},
+		{
+			name:     "launched long ago",
+			instance: unresolvedInstance("i-old", 60, 60),
+			wantDays: 60,
+		},
+		{
+			name:          "cloudtrail unavailable",
+			instance:      unresolvedInstance("i-old", 90, 45),
+			stopEventsErr: errors.New("AccessDenied"),
+			wantDays:      45,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeEC2{instancePages: [][]types.Instance{{tt.instance}}, volumePages: [][]types.Volume{volumes("vol-" + aws.ToString(tt.instance.InstanceId))}}
+			s := &service{client: client, stopEvents: fakeStopEvents{err: tt.stopEventsErr}}
+
+			instances, _, err := s.GetStoppedInstances(context.Background())
+			if err != nil {
+				t.Fatalf("GetStoppedInstances() error = %v", err)
+			}
+
+			if tt.wantDropped {
+				if len(instances) != 0 {
+					t.Errorf("GetStoppedInstances() = %+v, want the instance dropped", instances)
+				}
+				return
+			}
+			if len(instances) != 1 {
+				t.Fatalf("GetStoppedInstances() returned %d instances, want 1", len(instances))
+			}
+			if got := instances[0]; got.StoppedDays != tt.wantDays || !got.StoppedAtEstimated {
+				t.Errorf("stopped %d days, estimated %v, want %d days estimated", got.StoppedDays, got.StoppedAtEstimated, tt.wantDays)
+			}
+		})
+	}
+}
+
+func TestGetUnusedVolumesFollowsNextToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		pages    [][]types.Volume
+		failPage int
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:  "single page",
+			pages: [][]types.Volume{volumes("vol-1", "vol-2")},
+			want:  []string{"vol-1", "vol-2"},
+		},
+		{
+			name:  "every page is aggregated",
+			pages: [][]types.Volume{volumes("vol-1", "vol-2"), volumes("vol-3"), volumes("vol-4")},
+			want:  []string{"vol-1", "vol-2", "vol-3", "vol-4"},
+		},
+		{
+			name:     "error on a later page",
+			pages:    [][]types.Volume{volumes("vol-1"), volumes("vol-2"), volumes("vol-3")},
+			failPage: 2,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeEC2{volumePages: tt.pages, failPage: tt.failPage}
+			s := &service{client: client}
+
+			got, err := s.GetUnusedVolumes(context.Background())
+			if tt.wantErr {
+				if !errors.Is(err, errPage) {
+					t.Fatalf("GetUnusedVolumes() error = %v, want %v", err, errPage)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetUnusedVolumes() error = %v", err)
+			}
+
+			var ids []string
+			for _, volume := range got {
+				ids = append(ids, volume.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("GetUnusedVolumes() = %v, want %v", ids, tt.want)
+			}
+			if len(client.volumeTokens) != len(tt.pages) {
+				t.Errorf("DescribeVolumes called %d times, want %d", len(client.volumeTokens), len(tt.pages))
+			}
+		})
+	}
+}
+
+func TestGetStoppedInstancesFollowsNextToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		instancePages [][]types.Instance
+		volumePages   [][]types.Volume
+		failPage      int
+		wantInstances []string
+		wantVolumes   []string
+		wantErr       bool
+	}{
+		{
+			name:          "every page is aggregated",
+			instancePages: [][]types.Instance{stoppedInstances("i-1", "i-2"), stoppedInstances("i-3")},
+			volumePages:   [][]types.Volume{volumes("vol-i-1"), volumes("vol-i-2", "vol-i-3")},
+			wantInstances: []string{"i-1", "i-2", "i-3"},
+			wantVolumes:   []string{"vol-i-1", "vol-i-2", "vol-i-3"},
+		},
+		{
+			name:          "error on a later page",
+			instancePages: [][]types.Instance{stoppedInstances("i-1"), stoppedInstances("i-2")},
+			volumePages:   [][]types.Volume{volumes("vol-i-1"), volumes("vol-i-2")},
+			failPage:      1,
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeEC2{instancePages: tt.instancePages, volumePages: tt.volumePages, failPage: tt.failPage}
+			s := &service{client: client}
+
+			instances, attached, err := s.GetStoppedInstances(context.Background())
+			if tt.wantErr {
+				if !errors.Is(err, errPage) {
+					t.Fatalf("GetStoppedInstances() error = %v, want %v", err, errPage)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetStoppedInstances() error = %v", err)
+			}
+
+			var instanceIDs, volumeIDs []string
+			for _, instance := range instances {
+				instanceIDs = append(instanceIDs, instance.ID)
+			}
+			for _, volume := range attached {
+				volumeIDs = append(volumeIDs, volume.ID)
+			}
+			if !slices.Equal(instanceIDs, tt.wantInstances) {
+				t.Errorf("stopped instances = %v, want %v", instanceIDs, tt.wantInstances)
+			}
+			if !slices.Equal(volumeIDs, tt.wantVolumes) {
+				t.Errorf("attached volumes = %v, want %v", volumeIDs, tt.wantVolumes)
+			}
+			if len(client.instanceTokens) != len(tt.instancePages) {
+				t.Errorf("DescribeInstances called %d times, want %d", len(client.instanceTokens), len(tt.instancePages))
+			}
+		})
+	}
+}
@@ -2,14 +2,36 @@ package awsec2
 
 import (
 	"context"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/elC0mpa/aws-doctor/model"
+	awscloudtrail "github.com/elC0mpa/aws-doctor/service/aws/cloudtrail"
+	"github.com/elC0mpa/aws-doctor/service/ownership"
 )
 
 type service struct {
-	client *ec2.Client
+	client     ec2API
+	stopEvents awscloudtrail.CloudTrailService
+	owners     ownership.Resolver
+}
+
+// stoppedInstance is a stopped instance with its resolved stop time, which is
+// nil when no source had one and estimated when it is the last start
+type stoppedInstance struct {
+	instance  types.Instance
+	stoppedAt *time.Time
+	estimated bool
+}
+
+// ec2API is the part of the EC2 client the service calls
+type ec2API interface {
+	ec2.DescribeVolumesAPIClient
+	ec2.DescribeInstancesAPIClient
+	DescribeAddresses(ctx context.Context, params *ec2.DescribeAddressesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAddressesOutput, error)
+	DescribeNetworkInterfaces(ctx context.Context, params *ec2.DescribeNetworkInterfacesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeNetworkInterfacesOutput, error)
+	DescribeReservedInstances(ctx context.Context, params *ec2.DescribeReservedInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeReservedInstancesOutput, error)
 }
 
 type EC2Service interface {
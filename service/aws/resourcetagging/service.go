@@ -0,0 +1,71 @@
+package awsresourcetagging
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+func NewService(awsconfig aws.Config) *service {
+	client := resourcegroupstaggingapi.NewFromConfig(awsconfig)
+	return &service{
+		client: client,
+	}
+}
+
+// GetTaggedResources implements service.TagService
+// The Resource Groups Tagging API is regional and only returns resources that
+// have, or once had, at least one tag. Resources never tagged are not listed;
+// their spend still shows up as untagged in the cost breakdown.
+func (s *service) GetTaggedResources(ctx context.Context) ([]model.TaggedResource, error) {
+	var resources []model.TaggedResource
+
+	paginator := resourcegroupstaggingapi.NewGetResourcesPaginator(s.client, &resourcegroupstaggingapi.GetResourcesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mapping := range page.ResourceTagMappingList {
+			resourceARN := aws.ToString(mapping.ResourceARN)
+
+			tags := make(map[string]string, len(mapping.Tags))
+			for _, tag := range mapping.Tags {
+				tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+			}
+
+			resource := model.TaggedResource{
+				ID:   resourceARN,
+				Name: resourceARN,
+				Tags: tags,
+			}
+			if parsed, err := arn.Parse(resourceARN); err == nil {
+				resource.Name = parsed.Resource
+				resource.Type = resourceType(parsed)
+				resource.Region = parsed.Region
+			}
+
+			resources = append(resources, resource)
+		}
+	}
+
+	return resources, nil
+}
+
+// resourceType combines the service with the resource type prefix of an ARN,
+// e.g. "ec2:volume" for arn:aws:ec2:us-east-1:123456789012:volume/vol-0abc
+func resourceType(parsed arn.ARN) string {
+	kind := parsed.Resource
+	if i := strings.IndexAny(kind, "/:"); i >= 0 {
+		kind = kind[:i]
+	} else {
+		// Resources such as S3 buckets have no type prefix
+		return parsed.Service
+	}
+	return parsed.Service + ":" + kind
+}
@@ -0,0 +1,16 @@
+package awsresourcetagging
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+type service struct {
+	client *resourcegroupstaggingapi.Client
+}
+
+type ResourceTaggingService interface {
+	GetTaggedResources(ctx context.Context) ([]model.TaggedResource, error)
+}
@@ -6,39 +6,65 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v5"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/monitor/armmonitor"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v5"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/reservations/armreservations"
 	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/apierror"
+	"github.com/elC0mpa/aws-doctor/service/ownership"
+	"github.com/elC0mpa/aws-doctor/service/resilience"
+	"github.com/elC0mpa/aws-doctor/service/warnings"
 )
 
-func NewService(subscriptionID string, credential *Credential) (*service, error) {
-	disksClient, err := armcompute.NewDisksClient(subscriptionID, credential, nil)
+// activityLogRetention is how far back the Activity Log can be queried
+const activityLogRetention = 90 * 24 * time.Hour
+
+// stopOperations are the Activity Log operations that leave a VM stopped
+var stopOperations = map[string]bool{
+	"microsoft.compute/virtualmachines/deallocate/action": true,
+	"microsoft.compute/virtualmachines/poweroff/action":   true,
+}
+
+// NewService creates the Azure waste checks. Owners are read from the first
+// of ownerTagKeys a resource is tagged with.
+func NewService(subscriptionID string, credential *Credential, policy resilience.Policy, ownerTagKeys []string) (*service, error) {
+	clientOptions := policy.AzureClientOptions()
+
+	disksClient, err := armcompute.NewDisksClient(subscriptionID, credential, clientOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create disks client: %w", err)
 	}
 
-	vmClient, err := armcompute.NewVirtualMachinesClient(subscriptionID, credential, nil)
+	vmClient, err := armcompute.NewVirtualMachinesClient(subscriptionID, credential, clientOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create VM client: %w", err)
 	}
 
-	publicIPClient, err := armnetwork.NewPublicIPAddressesClient(subscriptionID, credential, nil)
+	publicIPClient, err := armnetwork.NewPublicIPAddressesClient(subscriptionID, credential, clientOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create public IP client: %w", err)
 	}
 
-	reservationsClient, err := armreservations.NewReservationOrderClient(credential, nil)
+	reservationsClient, err := armreservations.NewReservationOrderClient(credential, clientOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create reservations client: %w", err)
 	}
 
+	activityLogClient, err := armmonitor.NewActivityLogsClient(subscriptionID, credential, clientOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create activity log client: %w", err)
+	}
+
 	return &service{
 		subscriptionID:     subscriptionID,
 		disksClient:        disksClient,
 		vmClient:           vmClient,
 		publicIPClient:     publicIPClient,
 		reservationsClient: reservationsClient,
+		activityLogClient:  activityLogClient,
+		owners:             ownership.NewResolver(ownerTagKeys),
 	}, nil
 }
 
@@ -62,10 +88,14 @@ func (s *service) GetUnusedVolumes(ctx context.Context) ([]model.UnusedVolume, e
 			name = *disk.Name
 		}
 
+		tags := tagMap(disk.Tags)
 		result = append(result, model.UnusedVolume{
 			ID:     name,
 			SizeGB: sizeGB,
 			Status: "available",
+			Region: stringValue(disk.Location),
+			Tags:   tags,
+			Owner:  s.owners.Resolve(tags),
 		})
 	}
 	return result, nil
@@ -96,30 +126,90 @@ func (s *service) GetUnattachedDisks(ctx context.Context) ([]*armcompute.Disk, e
 }
 
 // GetStoppedInstances implements service.ResourceService
-// Returns VMs that are deallocated
-// Note: Azure doesn't store deallocation timestamp directly, so we report all deallocated VMs
-// In a production implementation, you might query Activity Logs for the actual deallocation time
+// Returns VMs that have been deallocated for more than 30 days. The power state
+// carries no timestamp, so the stop time comes from the last deallocate or
+// power off operation in the Activity Log. A VM with no such operation in the
+// log's 90 days was stopped before them. Only when the log cannot be read is
+// the instance view status time used. Any update to the VM refreshes it, so
+// the VM stopped at or before it and is kept however recent it is. VMs with
+// neither are reported with StoppedDays -1 rather than dropped.
 func (s *service) GetStoppedInstances(ctx context.Context) ([]model.StoppedInstance, []model.UnusedVolume, error) {
-	vms, err := s.GetDeallocatedVMs(ctx)
+	vms, err := s.listDeallocatedVMs(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	now := time.Now()
+	thresholdTime := now.Add(-30 * 24 * time.Hour)
+
 	var stoppedInstances []model.StoppedInstance
 	var attachedVolumes []model.UnusedVolume
 
-	for _, vm := range vms {
+	var stopTimes map[string]time.Time
+	var logErr error
+	if len(vms) > 0 {
+		stopTimes, logErr = s.lastDeallocations(ctx)
+		if logErr != nil {
+			// Usually a missing Microsoft.Insights/eventtypes/values/read permission
+			warnings.Add(ctx, apierror.NewWarning("azure", "activity log", logErr))
+		}
+	}
+
+	for _, deallocated := range vms {
+		vm := deallocated.vm
 		name := ""
 		if vm.Name != nil {
 			name = *vm.Name
 		}
 
-		// For deallocated VMs, we report days as -1 since Azure doesn't provide timestamp
-		// without querying Activity Logs
+		var stoppedAt *time.Time
+		estimated := false
+		switch {
+		case logErr == nil:
+			if deallocatedAt, ok := stopTimes[strings.ToLower(*vm.ID)]; ok {
+				stoppedAt = &deallocatedAt
+			} else {
+				// Stopped before the log's window, so at least that long ago
+				retentionStart := now.Add(-activityLogRetention)
+				stoppedAt = &retentionStart
+				estimated = true
+			}
+			if stoppedAt.After(thresholdTime) {
+				continue
+			}
+		case deallocated.statusTime != nil:
+			stoppedAt = deallocated.statusTime
+			estimated = true
+		}
+
+		stoppedDays := -1
+		if stoppedAt != nil {
+			stoppedDays = int(now.Sub(*stoppedAt).Hours() / 24)
+		}
+
+		region := stringValue(vm.Location)
+		tags := tagMap(vm.Tags)
+		owner := s.owners.Resolve(tags)
+
+		var instanceType string
+		var launchTime *time.Time
+		if vm.Properties != nil {
+			if vm.Properties.HardwareProfile != nil && vm.Properties.HardwareProfile.VMSize != nil {
+				instanceType = string(*vm.Properties.HardwareProfile.VMSize)
+			}
+			launchTime = vm.Properties.TimeCreated
+		}
+
 		stoppedInstances = append(stoppedInstances, model.StoppedInstance{
-			ID:          name,
-			Name:        name,
-			StoppedDays: -1, // Unknown - would need Activity Log query
+			ID:                 name,
+			Name:               name,
+			StoppedDays:        stoppedDays,
+			StoppedAtEstimated: estimated,
+			Region:             region,
+			InstanceType:       instanceType,
+			LaunchTime:         launchTime,
+			Tags:               tags,
+			Owner:              owner,
 		})
 
 		// Collect attached disks
@@ -137,6 +227,8 @@ func (s *service) GetStoppedInstances(ctx context.Context) ([]model.StoppedInsta
 					ID:     diskName,
 					SizeGB: sizeGB,
 					Status: "attached_stopped",
+					Region: region,
+					Owner:  owner,
 				})
 			}
 
@@ -152,6 +244,8 @@ func (s *service) GetStoppedInstances(ctx context.Context) ([]model.StoppedInsta
 						ID:     diskName,
 						SizeGB: sizeGB,
 						Status: "attached_stopped",
+						Region: region,
+						Owner:  owner,
 					})
 				}
 			}
@@ -163,7 +257,20 @@ func (s *service) GetStoppedInstances(ctx context.Context) ([]model.StoppedInsta
 
 // GetDeallocatedVMs returns all VMs in deallocated state
 func (s *service) GetDeallocatedVMs(ctx context.Context) ([]*armcompute.VirtualMachine, error) {
-	var deallocatedVMs []*armcompute.VirtualMachine
+	vms, err := s.listDeallocatedVMs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deallocatedVMs := make([]*armcompute.VirtualMachine, 0, len(vms))
+	for _, deallocated := range vms {
+		deallocatedVMs = append(deallocatedVMs, deallocated.vm)
+	}
+	return deallocatedVMs, nil
+}
+
+func (s *service) listDeallocatedVMs(ctx context.Context) ([]deallocatedVM, error) {
+	var deallocatedVMs []deallocatedVM
 
 	// List all VMs with instance view to get power state
 	pager := s.vmClient.NewListAllPager(&armcompute.VirtualMachinesClientListAllOptions{
@@ -191,23 +298,26 @@ func (s *service) GetDeallocatedVMs(ctx context.Context) ([]*armcompute.VirtualM
 			// Get instance view to check power state
 			instanceView, err := s.vmClient.InstanceView(ctx, resourceGroup, vmName, nil)
 			if err != nil {
-				// Skip VMs we can't get instance view for
+				// Report VMs we can't get instance view for instead of silently dropping them
+				warnings.Add(ctx, apierror.NewWarning("azure", "vm "+vmName, err))
 				continue
 			}
 
-			// Check if VM is deallocated
+			// Check if VM is deallocated, keeping the latest status time as a
+			// fallback stop time (the provisioning status of the deallocation)
 			isDeallocated := false
-			if instanceView.Statuses != nil {
-				for _, status := range instanceView.Statuses {
-					if status.Code != nil && strings.HasPrefix(*status.Code, "PowerState/deallocated") {
-						isDeallocated = true
-						break
-					}
+			var statusTime *time.Time
+			for _, status := range instanceView.Statuses {
+				if status.Code != nil && strings.HasPrefix(*status.Code, "PowerState/deallocated") {
+					isDeallocated = true
+				}
+				if status.Time != nil && (statusTime == nil || status.Time.After(*statusTime)) {
+					statusTime = status.Time
 				}
 			}
 
 			if isDeallocated {
-				deallocatedVMs = append(deallocatedVMs, vm)
+				deallocatedVMs = append(deallocatedVMs, deallocatedVM{vm: vm, statusTime: statusTime})
 			}
 		}
 	}
@@ -235,9 +345,13 @@ func (s *service) GetUnusedIPs(ctx context.Context) ([]model.UnusedIP, error) {
 			name = *ip.Name
 		}
 
+		tags := tagMap(ip.Tags)
 		result = append(result, model.UnusedIP{
 			Address:      address,
 			AllocationID: name,
+			Region:       stringValue(ip.Location),
+			Tags:         tags,
+			Owner:        s.owners.Resolve(tags),
 		})
 	}
 	return result, nil
@@ -358,6 +472,59 @@ func extractResourceName(resourceID string) string {
 	return resourceID
 }
 
+// lastDeallocations returns when each VM was last deallocated or powered off
+// according to the Activity Log, by lower-case resource ID. One query covers
+// the subscription: the log can only be filtered by resource provider, so the
+// stop operations are picked from its Microsoft.Compute events.
+func (s *service) lastDeallocations(ctx context.Context) (map[string]time.Time, error) {
+	now := time.Now().UTC()
+	filter := fmt.Sprintf("eventTimestamp ge '%s' and eventTimestamp le '%s' and resourceProvider eq 'Microsoft.Compute'",
+		now.Add(-activityLogRetention).Format(time.RFC3339), now.Format(time.RFC3339))
+
+	pager := s.activityLogClient.NewListPager(filter, &armmonitor.ActivityLogsClientListOptions{
+		Select: to.Ptr("eventTimestamp,operationName,status,resourceId"),
+	})
+
+	latest := make(map[string]time.Time)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query activity log: %w", err)
+		}
+
+		for _, event := range page.Value {
+			if event.OperationName == nil || event.OperationName.Value == nil || event.EventTimestamp == nil || event.ResourceID == nil {
+				continue
+			}
+			if !stopOperations[strings.ToLower(*event.OperationName.Value)] {
+				continue
+			}
+			if event.Status == nil || event.Status.Value == nil || !strings.EqualFold(*event.Status.Value, "Succeeded") {
+				continue
+			}
+			vmID := strings.ToLower(*event.ResourceID)
+			if previous, ok := latest[vmID]; !ok || event.EventTimestamp.After(previous) {
+				latest[vmID] = *event.EventTimestamp
+			}
+		}
+	}
+
+	return latest, nil
+}
+
+// tagMap converts Azure tags to a map, returning nil when there are none
+func tagMap(tags map[string]*string) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	result := make(map[string]string, len(tags))
+	for key, value := range tags {
+		result[key] = stringValue(value)
+	}
+	return result
+}
+
 // extractResourceGroup extracts the resource group from an Azure resource ID
 func extractResourceGroup(resourceID string) string {
 	parts := strings.Split(resourceID, "/")
@@ -368,3 +535,10 @@ func extractResourceGroup(resourceID string) string {
 	}
 	return ""
 }
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -2,12 +2,15 @@ package azurecompute
 
 import (
 	"context"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v5"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/monitor/armmonitor"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v5"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/reservations/armreservations"
 	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/ownership"
 )
 
 type service struct {
@@ -16,6 +19,15 @@ type service struct {
 	vmClient           *armcompute.VirtualMachinesClient
 	publicIPClient     *armnetwork.PublicIPAddressesClient
 	reservationsClient *armreservations.ReservationOrderClient
+	activityLogClient  *armmonitor.ActivityLogsClient
+	owners             ownership.Resolver
+}
+
+// deallocatedVM pairs a VM with the latest timestamp from its instance view
+// statuses, which is the fallback stop time when the Activity Log has none
+type deallocatedVM struct {
+	vm         *armcompute.VirtualMachine
+	statusTime *time.Time
 }
 
 type ComputeService interface {
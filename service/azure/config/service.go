@@ -6,7 +6,20 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 )
 
-func NewService(subscriptionID string) (*service, error) {
+// NewService creates a credential loader. The credential is created on first
+// use and kept, so that every client, and every run in --serve mode, shares
+// its token cache.
+func NewService() *service {
+	return &service{}
+}
+
+func (s *service) GetCredential() (*azidentity.DefaultAzureCredential, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.credential != nil {
+		return s.credential, nil
+	}
+
 	// Use DefaultAzureCredential which supports:
 	// - Environment variables (AZURE_CLIENT_ID, AZURE_TENANT_ID, AZURE_CLIENT_SECRET)
 	// - Managed Identity (on Azure VMs, App Service, etc.)
@@ -18,16 +31,6 @@ func NewService(subscriptionID string) (*service, error) {
 		return nil, fmt.Errorf("failed to create Azure credential: %w", err)
 	}
 
-	return &service{
-		subscriptionID: subscriptionID,
-		credential:     credential,
-	}, nil
-}
-
-func (s *service) GetCredential() *azidentity.DefaultAzureCredential {
-	return s.credential
-}
-
-func (s *service) GetSubscriptionID() string {
-	return s.subscriptionID
+	s.credential = credential
+	return credential, nil
 }
@@ -1,15 +1,16 @@
 package azureconfig
 
 import (
+	"sync"
+
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 )
 
 type service struct {
-	subscriptionID string
-	credential     *azidentity.DefaultAzureCredential
+	mu         sync.Mutex
+	credential *azidentity.DefaultAzureCredential
 }
 
 type ConfigService interface {
-	GetCredential() *azidentity.DefaultAzureCredential
-	GetSubscriptionID() string
+	GetCredential() (*azidentity.DefaultAzureCredential, error)
 }
@@ -0,0 +1,104 @@
+package azureconsumption
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/budget"
+	"github.com/elC0mpa/aws-doctor/service/resilience"
+)
+
+const (
+	moduleName = "azureconsumption"
+	apiVersion = "2023-05-01"
+)
+
+// NewService creates a client for the Consumption budgets API. It calls ARM
+// directly through the SDK pipeline, so requests get the same credential,
+// retry and rate limit policies as the generated clients.
+func NewService(subscriptionID string, credential *Credential, policy resilience.Policy) (*service, error) {
+	client, err := arm.NewClient(moduleName, "v1.0.0", credential, policy.AzureClientOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumption client: %w", err)
+	}
+
+	return &service{
+		subscriptionID: subscriptionID,
+		client:         client,
+	}, nil
+}
+
+// GetBudgets implements service.BudgetService
+// Returns the subscription's monthly cost budgets with the current and
+// forecast spend Azure has calculated for them
+func (s *service) GetBudgets(ctx context.Context) ([]model.BudgetStatus, error) {
+	var statuses []model.BudgetStatus
+
+	next := fmt.Sprintf("%s/subscriptions/%s/providers/Microsoft.Consumption/budgets?api-version=%s",
+		s.client.Endpoint(), url.PathEscape(s.subscriptionID), apiVersion)
+	for next != "" {
+		page, err := s.getPage(ctx, next)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, b := range page.Value {
+			props := b.Properties
+			if props.Category != "Cost" || props.TimeGrain != "Monthly" {
+				continue
+			}
+
+			status := model.BudgetStatus{
+				Name:     b.Name,
+				Provider: "azure",
+				Scope:    "subscription",
+				Imported: true,
+				Amount:   props.Amount,
+				Status:   model.BudgetUnknown,
+			}
+
+			if props.CurrentSpend != nil {
+				status.Actual = props.CurrentSpend.Amount
+				status.Unit = props.CurrentSpend.Unit
+				status.Forecast = status.Actual
+				if props.ForecastSpend != nil {
+					status.Forecast = props.ForecastSpend.Amount
+				}
+				status.Status = budget.Status(status.Actual, status.Forecast, status.Amount, model.DefaultBudgetWarnPercent)
+			}
+
+			statuses = append(statuses, status)
+		}
+
+		next = page.NextLink
+	}
+
+	return statuses, nil
+}
+
+func (s *service) getPage(ctx context.Context, endpoint string) (*budgetList, error) {
+	req, err := runtime.NewRequest(ctx, http.MethodGet, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	req.Raw().Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Pipeline().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list budgets: %w", err)
+	}
+	if !runtime.HasStatusCode(resp, http.StatusOK) {
+		return nil, runtime.NewResponseError(resp)
+	}
+
+	var page budgetList
+	if err := runtime.UnmarshalAsJSON(resp, &page); err != nil {
+		return nil, fmt.Errorf("failed to decode budgets: %w", err)
+	}
+	return &page, nil
+}
@@ -0,0 +1,43 @@
+package azureconsumption
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+type service struct {
+	subscriptionID string
+	client         *arm.Client
+}
+
+type ConsumptionService interface {
+	GetBudgets(ctx context.Context) ([]model.BudgetStatus, error)
+}
+
+// Credential is passed to allow reuse across services
+type Credential = azidentity.DefaultAzureCredential
+
+// budgetList is a page of the Microsoft.Consumption budgets API
+type budgetList struct {
+	Value    []budgetResource `json:"value"`
+	NextLink string           `json:"nextLink"`
+}
+
+type budgetResource struct {
+	Name       string `json:"name"`
+	Properties struct {
+		Category      string  `json:"category"`
+		Amount        float64 `json:"amount"`
+		TimeGrain     string  `json:"timeGrain"`
+		CurrentSpend  *spend  `json:"currentSpend"`
+		ForecastSpend *spend  `json:"forecastSpend"`
+	} `json:"properties"`
+}
+
+type spend struct {
+	Amount float64 `json:"amount"`
+	Unit   string  `json:"unit"`
+}
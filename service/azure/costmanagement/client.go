@@ -0,0 +1,63 @@
+package azurecostmanagement
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/costmanagement/armcostmanagement"
+)
+
+// sdkModule is the Cost Management SDK module; its name and version tag the
+// requests of the NextLink pipeline like those of the generated clients
+const (
+	sdkModule     = "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/costmanagement/armcostmanagement"
+	sdkModuleName = "armcostmanagement"
+)
+
+// sdkVersion returns the version of sdkModule this binary was built with
+func sdkVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == sdkModule {
+				return dep.Version
+			}
+		}
+	}
+	return "unknown"
+}
+
+// queryClient is the generated QueryClient with the pipeline that follows
+// NextLink, which the QueryClient does not expose
+type queryClient struct {
+	*armcostmanagement.QueryClient
+	pipeline runtime.Pipeline
+}
+
+// NextPage fetches a later page of a query by posting the same definition to its NextLink
+func (c queryClient) NextPage(ctx context.Context, nextLink string, queryDefinition armcostmanagement.QueryDefinition) (armcostmanagement.QueryResult, error) {
+	var page armcostmanagement.QueryResult
+
+	req, err := runtime.NewRequest(ctx, http.MethodPost, nextLink)
+	if err != nil {
+		return page, err
+	}
+	req.Raw().Header["Accept"] = []string{"application/json"}
+	if err := runtime.MarshalAsJSON(req, queryDefinition); err != nil {
+		return page, err
+	}
+
+	resp, err := c.pipeline.Do(req)
+	if err != nil {
+		return page, err
+	}
+	if !runtime.HasStatusCode(resp, http.StatusOK, http.StatusNoContent) {
+		return page, runtime.NewResponseError(resp)
+	}
+
+	if err := runtime.UnmarshalAsJSON(resp, &page); err != nil {
+		return page, err
+	}
+	return page, nil
+}
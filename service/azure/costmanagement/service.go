@@ -3,22 +3,33 @@ package azurecostmanagement
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/costmanagement/armcostmanagement"
 	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/resilience"
 )
 
-func NewService(subscriptionID string, credential *Credential) (*service, error) {
-	client, err := armcostmanagement.NewQueryClient(credential, nil)
+func NewService(subscriptionID string, credential *Credential, policy resilience.Policy) (*service, error) {
+	clientOptions := policy.AzureClientOptions()
+
+	client, err := armcostmanagement.NewQueryClient(credential, clientOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cost management client: %w", err)
 	}
 
+	armClient, err := arm.NewClient(sdkModuleName, sdkVersion(), credential, clientOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cost management pipeline: %w", err)
+	}
+
 	return &service{
 		subscriptionID: subscriptionID,
-		client:         client,
+		client:         queryClient{QueryClient: client, pipeline: armClient.Pipeline()},
 	}, nil
 }
 
@@ -64,7 +75,7 @@ func (s *service) getMonthCostsByService(ctx context.Context, endDate time.Time)
 		},
 	}
 
-	resp, err := s.client.Usage(ctx, scope, queryDefinition, nil)
+	resp, err := s.query(ctx, scope, queryDefinition)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query costs: %w", err)
 	}
@@ -172,24 +183,14 @@ func (s *service) getMonthTotalCosts(ctx context.Context, endDate time.Time) (*s
 		},
 	}
 
-	resp, err := s.client.Usage(ctx, scope, queryDefinition, nil)
+	resp, err := s.query(ctx, scope, queryDefinition)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query total costs: %w", err)
 	}
 
-	var totalCost float64
+	totalCost, currency := s.sumCostRows(resp)
 
-	if resp.Properties != nil && resp.Properties.Rows != nil {
-		for _, row := range resp.Properties.Rows {
-			if len(row) >= 1 {
-				if cost, ok := row[0].(float64); ok {
-					totalCost += cost
-				}
-			}
-		}
-	}
-
-	result := fmt.Sprintf("%.2f USD", totalCost)
+	result := fmt.Sprintf("%.2f %s", totalCost, currency)
 	return &result, nil
 }
 
@@ -223,22 +224,13 @@ func (s *service) GetLastSixMonthsCosts(ctx context.Context) ([]model.CostInfo,
 			},
 		}
 
-		resp, err := s.client.Usage(ctx, scope, queryDefinition, nil)
+		resp, err := s.query(ctx, scope, queryDefinition)
 		if err != nil {
 			// If we can't get data for a month, continue with zero
 			continue
 		}
 
-		var totalCost float64
-		if resp.Properties != nil && resp.Properties.Rows != nil {
-			for _, row := range resp.Properties.Rows {
-				if len(row) >= 1 {
-					if cost, ok := row[0].(float64); ok {
-						totalCost += cost
-					}
-				}
-			}
-		}
+		totalCost, currency := s.sumCostRows(resp)
 
 		startDateStr := startDate.Format("2006-01-02")
 		endDateStr := endDate.Format("2006-01-02")
@@ -249,7 +241,7 @@ func (s *service) GetLastSixMonthsCosts(ctx context.Context) ([]model.CostInfo,
 			Unit   string
 		}{
 			Amount: totalCost,
-			Unit:   "USD",
+			Unit:   currency,
 		}
 
 		monthlyCosts = append(monthlyCosts, model.CostInfo{
@@ -264,6 +256,268 @@ func (s *service) GetLastSixMonthsCosts(ctx context.Context) ([]model.CostInfo,
 	return monthlyCosts, nil
 }
 
+// GetCostsByTag implements service.CostService
+func (s *service) GetCostsByTag(ctx context.Context, tagKey string, start, end time.Time) (*model.CostInfo, error) {
+	grouping := &armcostmanagement.QueryGrouping{
+		Type: to.Ptr(armcostmanagement.QueryColumnTypeTag),
+		Name: to.Ptr(tagKey),
+	}
+
+	costInfo, err := s.getGroupedCosts(ctx, grouping, nil, "TagValue", start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query costs by tag %s: %w", tagKey, err)
+	}
+	return costInfo, nil
+}
+
+// GetCostsByDimension implements service.CostService
+// Spend can be grouped by resource group, service or meter, Azure's SKU;
+// spend outside any resource group is reported under model.Untagged.
+func (s *service) GetCostsByDimension(ctx context.Context, dimension string, start, end time.Time) (*model.CostInfo, error) {
+	var column string
+	switch dimension {
+	case model.DimensionResourceGroup:
+		column = "ResourceGroupName"
+	case model.DimensionService:
+		column = "ServiceName"
+	case model.DimensionSKU:
+		column = "Meter"
+	default:
+		return nil, fmt.Errorf("azure cannot group costs by %s", dimension)
+	}
+
+	grouping := &armcostmanagement.QueryGrouping{
+		Type: to.Ptr(armcostmanagement.QueryColumnTypeDimension),
+		Name: to.Ptr(column),
+	}
+
+	costInfo, err := s.getGroupedCosts(ctx, grouping, nil, column, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query costs by %s: %w", strings.ReplaceAll(dimension, "_", " "), err)
+	}
+	return costInfo, nil
+}
+
+// GetServiceCosts implements service.CostService
+// A daily breakdown is read from the UsageDate column of the daily rows
+// themselves; a resource breakdown groups by the full resource ID.
+func (s *service) GetServiceCosts(ctx context.Context, serviceName, breakdown string, start, end time.Time) (*model.CostInfo, error) {
+	filter := &armcostmanagement.QueryFilter{
+		Dimensions: &armcostmanagement.QueryComparisonExpression{
+			Name:     to.Ptr("ServiceName"),
+			Operator: to.Ptr(armcostmanagement.QueryOperatorTypeIn),
+			Values:   []*string{to.Ptr(serviceName)},
+		},
+	}
+
+	var grouping *armcostmanagement.QueryGrouping
+	var column string
+	switch breakdown {
+	case model.BreakdownDaily:
+		column = "UsageDate"
+	case model.BreakdownResource:
+		column = "ResourceId"
+		grouping = &armcostmanagement.QueryGrouping{
+			Type: to.Ptr(armcostmanagement.QueryColumnTypeDimension),
+			Name: to.Ptr(column),
+		}
+	default:
+		return nil, fmt.Errorf("azure cannot break down service costs by %s", breakdown)
+	}
+
+	costInfo, err := s.getGroupedCosts(ctx, grouping, filter, column, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s costs by %s: %w", serviceName, breakdown, err)
+	}
+	return costInfo, nil
+}
+
+// getGroupedCosts sums spend in [start, end) by the value in valueColumn,
+// optionally grouped and filtered. Azure's period end is inclusive, so the
+// query stops one second before end.
+func (s *service) getGroupedCosts(ctx context.Context, grouping *armcostmanagement.QueryGrouping, filter *armcostmanagement.QueryFilter, valueColumn string, start, end time.Time) (*model.CostInfo, error) {
+	startDateStr := start.Format("2006-01-02")
+	endDateStr := end.Format("2006-01-02")
+
+	costGroups := make(model.CostGroup)
+	costInfo := &model.CostInfo{
+		DateInterval: model.DateInterval{
+			Start: &startDateStr,
+			End:   &endDateStr,
+		},
+		CostGroup: costGroups,
+	}
+	if !end.After(start) {
+		return costInfo, nil
+	}
+
+	scope := fmt.Sprintf("/subscriptions/%s", s.subscriptionID)
+
+	queryDefinition := armcostmanagement.QueryDefinition{
+		Type:      to.Ptr(armcostmanagement.ExportTypeActualCost),
+		Timeframe: to.Ptr(armcostmanagement.TimeframeTypeCustom),
+		TimePeriod: &armcostmanagement.QueryTimePeriod{
+			From: to.Ptr(start),
+			To:   to.Ptr(end.Add(-time.Second)),
+		},
+		Dataset: &armcostmanagement.QueryDataset{
+			Granularity: to.Ptr(armcostmanagement.GranularityTypeDaily),
+			Aggregation: map[string]*armcostmanagement.QueryAggregation{
+				"totalCost": {
+					Name:     to.Ptr("Cost"),
+					Function: to.Ptr(armcostmanagement.FunctionTypeSum),
+				},
+			},
+			Filter: filter,
+		},
+	}
+	if grouping != nil {
+		queryDefinition.Dataset.Grouping = []*armcostmanagement.QueryGrouping{grouping}
+	}
+
+	resp, err := s.query(ctx, scope, queryDefinition)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Properties == nil {
+		return costInfo, nil
+	}
+
+	costIdx := -1
+	valueIdx := -1
+	currencyIdx := -1
+	for i, col := range resp.Properties.Columns {
+		if col.Name == nil {
+			continue
+		}
+		switch *col.Name {
+		case "Cost", "PreTaxCost":
+			costIdx = i
+		case valueColumn:
+			valueIdx = i
+		case "Currency":
+			currencyIdx = i
+		}
+	}
+	if costIdx < 0 {
+		return costInfo, nil
+	}
+
+	for _, row := range resp.Properties.Rows {
+		if len(row) <= costIdx {
+			continue
+		}
+		cost, ok := row[costIdx].(float64)
+		if !ok || cost == 0 {
+			continue
+		}
+
+		value := model.Untagged
+		if valueIdx >= 0 && len(row) > valueIdx {
+			switch v := row[valueIdx].(type) {
+			case string:
+				if v != "" {
+					value = v
+				}
+			case float64:
+				// UsageDate is a number such as 20261001
+				if date, err := time.Parse("20060102", strconv.FormatFloat(v, 'f', 0, 64)); err == nil {
+					value = date.Format("2006-01-02")
+				}
+			}
+		}
+
+		currency := "USD"
+		if currencyIdx >= 0 && len(row) > currencyIdx {
+			if curr, ok := row[currencyIdx].(string); ok {
+				currency = curr
+			}
+		}
+
+		costGroups[value] = struct {
+			Amount float64
+			Unit   string
+		}{
+			Amount: costGroups[value].Amount + cost,
+			Unit:   currency,
+		}
+	}
+
+	return costInfo, nil
+}
+
+// query runs a cost query and follows NextLink, appending the rows of every
+// page. Later pages are fetched by posting the same definition to NextLink.
+func (s *service) query(ctx context.Context, scope string, queryDefinition armcostmanagement.QueryDefinition) (armcostmanagement.QueryResult, error) {
+	resp, err := s.client.Usage(ctx, scope, queryDefinition, nil)
+	if err != nil {
+		return armcostmanagement.QueryResult{}, err
+	}
+
+	result := resp.QueryResult
+	if result.Properties == nil {
+		return result, nil
+	}
+
+	nextLink := result.Properties.NextLink
+	for nextLink != nil && *nextLink != "" {
+		page, err := s.client.NextPage(ctx, *nextLink, queryDefinition)
+		if err != nil {
+			return armcostmanagement.QueryResult{}, fmt.Errorf("failed to fetch next page of cost query: %w", err)
+		}
+
+		nextLink = nil
+		if page.Properties != nil {
+			result.Properties.Rows = append(result.Properties.Rows, page.Properties.Rows...)
+			nextLink = page.Properties.NextLink
+		}
+	}
+
+	return result, nil
+}
+
+// sumCostRows adds up the cost column of an ungrouped query and returns the billing
+// currency reported alongside it, defaulting to USD when the column is absent
+func (s *service) sumCostRows(result armcostmanagement.QueryResult) (float64, string) {
+	var totalCost float64
+	currency := "USD"
+
+	if result.Properties == nil || result.Properties.Rows == nil {
+		return totalCost, currency
+	}
+
+	costIdx := 0
+	currencyIdx := -1
+	for i, col := range result.Properties.Columns {
+		if col.Name == nil {
+			continue
+		}
+		switch *col.Name {
+		case "Cost", "PreTaxCost":
+			costIdx = i
+		case "Currency":
+			currencyIdx = i
+		}
+	}
+
+	for _, row := range result.Properties.Rows {
+		if len(row) <= costIdx {
+			continue
+		}
+		if cost, ok := row[costIdx].(float64); ok {
+			totalCost += cost
+		}
+		if currencyIdx >= 0 && len(row) > currencyIdx {
+			if curr, ok := row[currencyIdx].(string); ok && curr != "" {
+				currency = curr
+			}
+		}
+	}
+
+	return totalCost, currency
+}
+
 func (s *service) getFirstDayOfMonth(month time.Time) time.Time {
 	return time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
 }
@@ -0,0 +1,134 @@
+package azurecostmanagement
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/costmanagement/armcostmanagement"
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+// fakeQuery serves query rows in pages linked by NextLink. A page listed in
+// failPage returns errPage instead.
+type fakeQuery struct {
+	pages     [][][]any
+	failPage  int
+	nextLinks []string
+}
+
+var errPage = errors.New("too many requests")
+
+func (f *fakeQuery) result(i int) armcostmanagement.QueryResult {
+	var nextLink *string
+	if i+1 < len(f.pages) {
+		nextLink = to.Ptr("https://management.azure.com/next?page=" + strconv.Itoa(i+1))
+	}
+	return armcostmanagement.QueryResult{
+		Properties: &armcostmanagement.QueryProperties{
+			Columns: []*armcostmanagement.QueryColumn{
+				{Name: to.Ptr("Cost")},
+				{Name: to.Ptr("ServiceName")},
+				{Name: to.Ptr("Currency")},
+			},
+			Rows:     f.pages[i],
+			NextLink: nextLink,
+		},
+	}
+}
+
+func (f *fakeQuery) Usage(ctx context.Context, scope string, parameters armcostmanagement.QueryDefinition, options *armcostmanagement.QueryClientUsageOptions) (armcostmanagement.QueryClientUsageResponse, error) {
+	return armcostmanagement.QueryClientUsageResponse{QueryResult: f.result(0)}, nil
+}
+
+func (f *fakeQuery) NextPage(ctx context.Context, nextLink string, parameters armcostmanagement.QueryDefinition) (armcostmanagement.QueryResult, error) {
+	f.nextLinks = append(f.nextLinks, nextLink)
+	i := int(nextLink[len(nextLink)-1] - '0')
+	if i == f.failPage {
+		return armcostmanagement.QueryResult{}, errPage
+	}
+	return f.result(i), nil
+}
+
+func amounts(costInfo *model.CostInfo) map[string]float64 {
+	result := make(map[string]float64, len(costInfo.CostGroup))
+	for name, cost := range costInfo.CostGroup {
+		result[name] = cost.Amount
+	}
+	return result
+}
+
+func TestGetCostsByDimensionFollowsNextLink(t *testing.T) {
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		pages    [][][]any
+		failPage int
+		want     map[string]float64
+		wantErr  bool
+	}{
+		{
+			name: "single page",
+			pages: [][][]any{
+				{{1.5, "Storage", "EUR"}, {2.0, "Virtual Machines", "EUR"}},
+			},
+			want: map[string]float64{"Storage": 1.5, "Virtual Machines": 2},
+		},
+		{
+			name: "rows of every page are aggregated",
+			pages: [][][]any{
+				{{1.5, "Storage", "EUR"}, {2.0, "Virtual Machines", "EUR"}},
+				{{3.0, "Virtual Machines", "EUR"}},
+				{{0.5, "Bandwidth", "EUR"}, {1.0, "Storage", "EUR"}},
+			},
+			want: map[string]float64{"Storage": 2.5, "Virtual Machines": 5, "Bandwidth": 0.5},
+		},
+		{
+			name: "error on a later page",
+			pages: [][][]any{
+				{{1.5, "Storage", "EUR"}},
+				{{2.0, "Storage", "EUR"}},
+				{{3.0, "Storage", "EUR"}},
+			},
+			failPage: 2,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeQuery{pages: tt.pages, failPage: tt.failPage}
+			s := &service{subscriptionID: "subscription", client: client}
+
+			got, err := s.GetCostsByDimension(context.Background(), model.DimensionService, start, end)
+			if tt.wantErr {
+				if !errors.Is(err, errPage) {
+					t.Fatalf("GetCostsByDimension() error = %v, want %v", err, errPage)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetCostsByDimension() error = %v", err)
+			}
+
+			if !maps.Equal(amounts(got), tt.want) {
+				t.Errorf("GetCostsByDimension() = %v, want %v", amounts(got), tt.want)
+			}
+			if len(client.nextLinks) != len(tt.pages)-1 {
+				t.Errorf("NextPage called %d times, want %d", len(client.nextLinks), len(tt.pages)-1)
+			}
+		})
+	}
+}
+
+func TestSDKVersionComesFromTheBuild(t *testing.T) {
+	if version := sdkVersion(); version == "unknown" || version == "" {
+		t.Errorf("sdkVersion() = %q, want the version of %s in go.mod", version, sdkModule)
+	}
+}
@@ -2,6 +2,7 @@ package azurecostmanagement
 
 import (
 	"context"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/costmanagement/armcostmanagement"
@@ -10,7 +11,14 @@ import (
 
 type service struct {
 	subscriptionID string
-	client         *armcostmanagement.QueryClient
+	client         queryAPI
+}
+
+// queryAPI runs Cost Management queries: the first page of a query, and the
+// page its NextLink points to
+type queryAPI interface {
+	Usage(ctx context.Context, scope string, parameters armcostmanagement.QueryDefinition, options *armcostmanagement.QueryClientUsageOptions) (armcostmanagement.QueryClientUsageResponse, error)
+	NextPage(ctx context.Context, nextLink string, parameters armcostmanagement.QueryDefinition) (armcostmanagement.QueryResult, error)
 }
 
 type CostManagementService interface {
@@ -19,6 +27,9 @@ type CostManagementService interface {
 	GetCurrentMonthTotalCosts(ctx context.Context) (*string, error)
 	GetLastMonthTotalCosts(ctx context.Context) (*string, error)
 	GetLastSixMonthsCosts(ctx context.Context) ([]model.CostInfo, error)
+	GetCostsByTag(ctx context.Context, tagKey string, start, end time.Time) (*model.CostInfo, error)
+	GetCostsByDimension(ctx context.Context, dimension string, start, end time.Time) (*model.CostInfo, error)
+	GetServiceCosts(ctx context.Context, serviceName, breakdown string, start, end time.Time) (*model.CostInfo, error)
 }
 
 // Credential is passed to allow reuse across services
@@ -0,0 +1,83 @@
+package azureresourcegraph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/resilience"
+)
+
+// resourcesQuery lists every resource in the subscription with its tags
+const resourcesQuery = "Resources | project id, name, type, location, tags"
+
+func NewService(subscriptionID string, credential *Credential, policy resilience.Policy) (*service, error) {
+	client, err := armresourcegraph.NewClient(credential, policy.AzureClientOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource graph client: %w", err)
+	}
+
+	return &service{
+		subscriptionID: subscriptionID,
+		client:         client,
+	}, nil
+}
+
+// GetTaggedResources implements service.TagService
+// Queries Azure Resource Graph and follows SkipToken until every row is read
+func (s *service) GetTaggedResources(ctx context.Context) ([]model.TaggedResource, error) {
+	var resources []model.TaggedResource
+
+	request := armresourcegraph.QueryRequest{
+		Query:         to.Ptr(resourcesQuery),
+		Subscriptions: []*string{to.Ptr(s.subscriptionID)},
+		Options: &armresourcegraph.QueryRequestOptions{
+			ResultFormat: to.Ptr(armresourcegraph.ResultFormatObjectArray),
+		},
+	}
+
+	for {
+		resp, err := s.client.Resources(ctx, request, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query resource graph: %w", err)
+		}
+
+		rows, _ := resp.Data.([]any)
+		for _, row := range rows {
+			fields, ok := row.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			resource := model.TaggedResource{
+				ID:     stringField(fields, "id"),
+				Name:   stringField(fields, "name"),
+				Type:   stringField(fields, "type"),
+				Region: stringField(fields, "location"),
+				Tags:   make(map[string]string),
+			}
+			if tags, ok := fields["tags"].(map[string]any); ok {
+				for key, value := range tags {
+					resource.Tags[key], _ = value.(string)
+				}
+			}
+
+			resources = append(resources, resource)
+		}
+
+		if resp.SkipToken == nil || *resp.SkipToken == "" {
+			return resources, nil
+		}
+
+		options := *request.Options
+		options.SkipToken = resp.SkipToken
+		request.Options = &options
+	}
+}
+
+func stringField(fields map[string]any, key string) string {
+	value, _ := fields[key].(string)
+	return value
+}
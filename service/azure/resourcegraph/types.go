@@ -0,0 +1,21 @@
+package azureresourcegraph
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+type service struct {
+	subscriptionID string
+	client         *armresourcegraph.Client
+}
+
+type ResourceGraphService interface {
+	GetTaggedResources(ctx context.Context) ([]model.TaggedResource, error)
+}
+
+// Credential is passed to allow reuse across services
+type Credential = azidentity.DefaultAzureCredential
@@ -0,0 +1,183 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service"
+	"github.com/elC0mpa/aws-doctor/service/apierror"
+	"github.com/elC0mpa/aws-doctor/service/warnings"
+	"github.com/elC0mpa/aws-doctor/utils"
+)
+
+// Validate checks the budgets declared in the config file
+func Validate(cfgs []model.BudgetConfig) error {
+	for i, cfg := range cfgs {
+		name := cfg.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+
+		switch {
+		case cfg.Provider != "aws" && cfg.Provider != "gcp" && cfg.Provider != "azure":
+			return fmt.Errorf("budget %s: unknown provider %q", name, cfg.Provider)
+		case cfg.Amount <= 0:
+			return fmt.Errorf("budget %s: amount must be positive", name)
+		case cfg.WarnPercent < 0 || cfg.WarnPercent > 100:
+			return fmt.Errorf("budget %s: warn_percent must be between 0 and 100", name)
+		case cfg.TagValue != "" && cfg.TagKey == "":
+			return fmt.Errorf("budget %s: tag_value requires tag_key", name)
+		case cfg.Service != "" && cfg.TagKey != "":
+			return fmt.Errorf("budget %s: service and tag_key cannot be combined", name)
+		}
+	}
+	return nil
+}
+
+// Evaluate checks the budgets in cfgs of the result's provider and account
+// against its month-to-date spend and, when budgetService is set, appends the
+// provider's own budgets. Tag budgets query costService; failures are reported
+// as warnings and leave the budget unknown.
+func Evaluate(ctx context.Context, cfgs []model.BudgetConfig, result model.ProviderCostResult, costService service.CostService, budgetService service.BudgetService) []model.BudgetStatus {
+	now := time.Now()
+	total, unit := utils.ParseTotal(result.CurrentTotalCost)
+
+	var statuses []model.BudgetStatus
+	for _, cfg := range cfgs {
+		if cfg.Provider != result.Provider || (cfg.Account != "" && cfg.Account != result.AccountID) {
+			continue
+		}
+
+		status := model.BudgetStatus{
+			Name:     cfg.Name,
+			Provider: result.Provider,
+			Scope:    Scope(cfg),
+			Amount:   cfg.Amount,
+			Unit:     unit,
+		}
+		if status.Name == "" {
+			status.Name = status.Scope
+		}
+
+		actual, err := spend(ctx, cfg, result, total, costService, now)
+		if err != nil {
+			warnings.Add(ctx, apierror.NewWarning(result.Provider, "budget "+status.Name, err))
+			status.Status = model.BudgetUnknown
+			statuses = append(statuses, status)
+			continue
+		}
+
+		warnPercent := cfg.WarnPercent
+		if warnPercent == 0 {
+			warnPercent = model.DefaultBudgetWarnPercent
+		}
+
+		status.Actual = actual
+		status.Forecast = Forecast(actual, now)
+		status.Status = Status(status.Actual, status.Forecast, cfg.Amount, warnPercent)
+		statuses = append(statuses, status)
+	}
+
+	if budgetService != nil {
+		imported, err := budgetService.GetBudgets(ctx)
+		if err != nil {
+			warnings.Add(ctx, apierror.NewWarning(result.Provider, "provider budgets", err))
+		}
+		statuses = append(statuses, imported...)
+	}
+
+	return statuses
+}
+
+// Scope describes what a budget covers
+func Scope(cfg model.BudgetConfig) string {
+	var scope string
+	switch {
+	case cfg.Service != "":
+		scope = "service " + cfg.Service
+	case cfg.TagKey != "" && cfg.TagValue != "":
+		scope = fmt.Sprintf("tag %s=%s", cfg.TagKey, cfg.TagValue)
+	case cfg.TagKey != "":
+		scope = fmt.Sprintf("tag %s (any value)", cfg.TagKey)
+	default:
+		scope = "total"
+	}
+
+	if cfg.Account != "" {
+		scope = cfg.Account + " " + scope
+	}
+	return scope
+}
+
+// Forecast projects month-to-date spend linearly to the end of the month.
+// Spend covers the days before today, so on the 1st there is nothing to
+// project from.
+func Forecast(actual float64, now time.Time) float64 {
+	elapsed := now.Day() - 1
+	if elapsed == 0 {
+		return actual
+	}
+
+	daysInMonth := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location()).Day()
+	return actual / float64(elapsed) * float64(daysInMonth)
+}
+
+// Status is breach once actual spend reaches the amount, warn when the
+// forecast will reach it or actual spend passed warnPercent of it, and ok
+// otherwise
+func Status(actual, forecast, amount, warnPercent float64) string {
+	switch {
+	case actual >= amount:
+		return model.BudgetBreach
+	case forecast >= amount || actual >= amount*warnPercent/100:
+		return model.BudgetWarn
+	default:
+		return model.BudgetOK
+	}
+}
+
+// spend returns the month-to-date spend covered by a budget
+func spend(ctx context.Context, cfg model.BudgetConfig, result model.ProviderCostResult, total float64, costService service.CostService, now time.Time) (float64, error) {
+	switch {
+	case cfg.Service != "":
+		if result.CurrentMonthData == nil {
+			return 0, nil
+		}
+		var amount float64
+		for name, cost := range result.CurrentMonthData.CostGroup {
+			if strings.EqualFold(name, cfg.Service) {
+				amount += cost.Amount
+			}
+		}
+		return amount, nil
+
+	case cfg.TagKey != "":
+		end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		if !end.After(start) {
+			return 0, nil
+		}
+
+		costs, err := costService.GetCostsByTag(ctx, cfg.TagKey, start, end)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get costs by tag %s: %w", cfg.TagKey, err)
+		}
+
+		var amount float64
+		for value, cost := range costs.CostGroup {
+			if value == model.Untagged {
+				continue
+			}
+			if cfg.TagValue == "" || strings.EqualFold(value, cfg.TagValue) {
+				amount += cost.Amount
+			}
+		}
+		return amount, nil
+
+	default:
+		return total, nil
+	}
+}
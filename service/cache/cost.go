@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service"
+)
+
+// Cost groupings used in cache keys
+const (
+	groupingService   = "service"
+	groupingTotal     = "total"
+	groupingMonthly   = "monthly"
+	groupingTag       = "tag:"
+	groupingDimension = "dimension:"
+	groupingBreakdown = "breakdown:"
+)
+
+// NewCostService wraps costService so responses are served from cache while fresh.
+// The identity service is used once to resolve the provider and account for cache keys.
+func NewCostService(costService service.CostService, identityService service.IdentityService, cache CacheService) *cachedCostService {
+	return &cachedCostService{
+		costService:     costService,
+		identityService: identityService,
+		cache:           cache,
+	}
+}
+
+// WrapCostService returns costService unchanged when caching is disabled (cache is nil)
+func WrapCostService(costService service.CostService, identityService service.IdentityService, cache CacheService) service.CostService {
+	if cache == nil {
+		return costService
+	}
+	return NewCostService(costService, identityService, cache)
+}
+
+// CachedAt reports when the oldest cached response served by costService was fetched,
+// or nil if every response came directly from the provider
+func CachedAt(costService service.CostService) *time.Time {
+	cached, ok := costService.(*cachedCostService)
+	if !ok {
+		return nil
+	}
+
+	cached.mu.Lock()
+	defer cached.mu.Unlock()
+	return cached.cachedAt
+}
+
+func (s *cachedCostService) GetCurrentMonthCostsByService(ctx context.Context) (*model.CostInfo, error) {
+	now := time.Now()
+	return fetch(ctx, s, groupingService, firstDayOfMonth(now), now, s.costService.GetCurrentMonthCostsByService)
+}
+
+func (s *cachedCostService) GetLastMonthCostsByService(ctx context.Context) (*model.CostInfo, error) {
+	end := time.Now().AddDate(0, -1, 0)
+	return fetch(ctx, s, groupingService, firstDayOfMonth(end), end, s.costService.GetLastMonthCostsByService)
+}
+
+func (s *cachedCostService) GetCurrentMonthTotalCosts(ctx context.Context) (*string, error) {
+	now := time.Now()
+	return fetch(ctx, s, groupingTotal, firstDayOfMonth(now), now, s.costService.GetCurrentMonthTotalCosts)
+}
+
+func (s *cachedCostService) GetLastMonthTotalCosts(ctx context.Context) (*string, error) {
+	end := time.Now().AddDate(0, -1, 0)
+	return fetch(ctx, s, groupingTotal, firstDayOfMonth(end), end, s.costService.GetLastMonthTotalCosts)
+}
+
+func (s *cachedCostService) GetLastSixMonthsCosts(ctx context.Context) ([]model.CostInfo, error) {
+	now := time.Now()
+	return fetch(ctx, s, groupingMonthly, firstDayOfMonth(now.AddDate(0, -6, 0)), firstDayOfMonth(now), s.costService.GetLastSixMonthsCosts)
+}
+
+// GetCostsByTag caches each tag key under its own grouping
+func (s *cachedCostService) GetCostsByTag(ctx context.Context, tagKey string, start, end time.Time) (*model.CostInfo, error) {
+	return fetch(ctx, s, groupingTag+tagKey, start, end, func(ctx context.Context) (*model.CostInfo, error) {
+		return s.costService.GetCostsByTag(ctx, tagKey, start, end)
+	})
+}
+
+// GetCostsByDimension caches each dimension under its own grouping
+func (s *cachedCostService) GetCostsByDimension(ctx context.Context, dimension string, start, end time.Time) (*model.CostInfo, error) {
+	return fetch(ctx, s, groupingDimension+dimension, start, end, func(ctx context.Context) (*model.CostInfo, error) {
+		return s.costService.GetCostsByDimension(ctx, dimension, start, end)
+	})
+}
+
+// GetServiceCosts caches each service and breakdown under its own grouping
+func (s *cachedCostService) GetServiceCosts(ctx context.Context, serviceName, breakdown string, start, end time.Time) (*model.CostInfo, error) {
+	return fetch(ctx, s, groupingBreakdown+breakdown+":"+serviceName, start, end, func(ctx context.Context) (*model.CostInfo, error) {
+		return s.costService.GetServiceCosts(ctx, serviceName, breakdown, start, end)
+	})
+}
+
+// fetch serves a cached value for the grouping and range, calling load on a miss.
+// Cache failures never fail the request; they only cause the provider to be queried.
+func fetch[T any](ctx context.Context, s *cachedCostService, grouping string, start, end time.Time, load func(context.Context) (T, error)) (T, error) {
+	prefix, err := s.keyPrefix(ctx)
+	if err != nil {
+		return load(ctx)
+	}
+	key := fmt.Sprintf("%s/%s/%s_%s", prefix, grouping, start.Format("2006-01-02"), end.Format("2006-01-02"))
+
+	var cached T
+	if storedAt, err := s.cache.Get(key, &cached); err == nil && storedAt != nil {
+		s.recordHit(*storedAt)
+		return cached, nil
+	}
+
+	value, err := load(ctx)
+	if err != nil {
+		return value, err
+	}
+
+	_ = s.cache.Set(key, value)
+	return value, nil
+}
+
+func (s *cachedCostService) keyPrefix(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.prefix != "" {
+		return s.prefix, nil
+	}
+
+	accountInfo, err := s.identityService.GetAccountInfo(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	s.prefix = fmt.Sprintf("%s/%s", accountInfo.Provider, accountInfo.AccountID)
+	return s.prefix, nil
+}
+
+func (s *cachedCostService) recordHit(storedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cachedAt == nil || storedAt.Before(*s.cachedAt) {
+		s.cachedAt = &storedAt
+	}
+}
+
+func firstDayOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
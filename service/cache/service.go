@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultTTL is how long cached cost data is reused when no TTL is configured
+const DefaultTTL = time.Hour
+
+// NewService creates an on-disk cache rooted at dir. An empty dir falls back to
+// the default location under the user cache directory.
+func NewService(dir string, ttl time.Duration) (*cacheService, error) {
+	if dir == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate user cache directory: %w", err)
+		}
+		dir = filepath.Join(cacheDir, "cloud-doctor", "cache")
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	return &cacheService{dir: dir, ttl: ttl}, nil
+}
+
+// Get decodes the value stored under key into value and returns when it was stored.
+// A nil time means the key is missing or has expired.
+func (s *cacheService) Get(key string, value any) (*time.Time, error) {
+	data, err := os.ReadFile(s.filePath(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("failed to parse cache entry: %w", err)
+	}
+
+	// Guard against hash collisions and expired entries
+	if e.Key != key || time.Since(e.StoredAt) > s.ttl {
+		return nil, nil
+	}
+
+	if err := json.Unmarshal(e.Value, value); err != nil {
+		return nil, fmt.Errorf("failed to decode cached value: %w", err)
+	}
+
+	return &e.StoredAt, nil
+}
+
+// Set stores value under key, replacing any previous entry
+func (s *cacheService) Set(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache value: %w", err)
+	}
+
+	data, err := json.Marshal(entry{Key: key, StoredAt: time.Now(), Value: raw})
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Write to a temporary file first so concurrent readers never see partial entries
+	path := s.filePath(key)
+	tmp, err := os.CreateTemp(s.dir, ".entry-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+
+	return nil
+}
+
+func (s *cacheService) GetDir() string {
+	return s.dir
+}
+
+func (s *cacheService) GetTTL() time.Duration {
+	return s.ttl
+}
+
+func (s *cacheService) filePath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/service"
+)
+
+type cacheService struct {
+	dir string
+	ttl time.Duration
+}
+
+// CacheService stores JSON-encodable values on disk for a limited time
+type CacheService interface {
+	Get(key string, value any) (*time.Time, error)
+	Set(key string, value any) error
+	GetDir() string
+	GetTTL() time.Duration
+}
+
+// entry is the on-disk representation of a cached value
+type entry struct {
+	Key      string          `json:"key"`
+	StoredAt time.Time       `json:"stored_at"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// cachedCostService decorates a CostService with a CacheService, keyed by
+// provider, account, grouping and date range
+type cachedCostService struct {
+	costService     service.CostService
+	identityService service.IdentityService
+	cache           CacheService
+
+	mu       sync.Mutex
+	prefix   string
+	cachedAt *time.Time
+}
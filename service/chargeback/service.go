@@ -0,0 +1,257 @@
+package chargeback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service"
+	"github.com/elC0mpa/aws-doctor/service/currency"
+)
+
+// defaultSource groups spend by the team tag when the mapping declares no
+// source for a provider
+var defaultSource = model.AllocationSource{Dimension: model.DimensionTag, Key: "team"}
+
+// LoadMapping reads a chargeback mapping file
+func LoadMapping(path string) (*model.ChargebackMapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chargeback mapping %s: %w", path, err)
+	}
+
+	mapping := &model.ChargebackMapping{}
+	if err := json.Unmarshal(data, mapping); err != nil {
+		return nil, fmt.Errorf("failed to parse chargeback mapping %s: %w", path, err)
+	}
+	return mapping, nil
+}
+
+// ParsePeriod returns the [start, end) range for a YYYY-MM month. An empty
+// month selects last month; the current month runs up to today.
+func ParsePeriod(month string, now time.Time) (time.Time, time.Time, error) {
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	if month == "" {
+		return thisMonth.AddDate(0, -1, 0), thisMonth, nil
+	}
+
+	start, err := time.ParseInLocation("2006-01", month, now.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid month %q: use YYYY-MM", month)
+	}
+	if start.After(thisMonth) {
+		return time.Time{}, time.Time{}, fmt.Errorf("month %s is in the future", month)
+	}
+
+	end := start.AddDate(0, 1, 0)
+	if start.Equal(thisMonth) {
+		end = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	}
+	return start, end, nil
+}
+
+// NewService validates the mapping and creates a chargeback allocator.
+// Amounts are converted to the reporting currency of currencyService.
+func NewService(mapping model.ChargebackMapping, currencyService currency.CurrencyService) (*chargebackService, error) {
+	if len(mapping.Teams) == 0 {
+		return nil, fmt.Errorf("chargeback mapping declares no teams")
+	}
+
+	if mapping.SharedSplit == "" {
+		mapping.SharedSplit = model.SplitProportional
+	}
+	if mapping.UnallocatedSplit == "" {
+		mapping.UnallocatedSplit = model.SplitNone
+	}
+	if mapping.SharedSplit != model.SplitEven && mapping.SharedSplit != model.SplitProportional {
+		return nil, fmt.Errorf("invalid shared_split %q: use %s or %s", mapping.SharedSplit, model.SplitEven, model.SplitProportional)
+	}
+	switch mapping.UnallocatedSplit {
+	case model.SplitEven, model.SplitProportional, model.SplitNone:
+	default:
+		return nil, fmt.Errorf("invalid unallocated_split %q: use %s, %s or %s", mapping.UnallocatedSplit, model.SplitEven, model.SplitProportional, model.SplitNone)
+	}
+
+	for _, source := range mapping.Sources {
+		switch source.Dimension {
+		case model.DimensionTag:
+			if source.Key == "" {
+				return nil, fmt.Errorf("chargeback source for %q groups by tag but sets no key", source.Provider)
+			}
+		case model.DimensionResourceGroup, model.DimensionProject:
+		default:
+			return nil, fmt.Errorf("unknown chargeback dimension %q", source.Dimension)
+		}
+	}
+
+	teamByValue := make(map[string]string)
+	for team, values := range mapping.Teams {
+		for _, value := range values {
+			key := strings.ToLower(value)
+			if other, ok := teamByValue[key]; ok && other != team {
+				return nil, fmt.Errorf("chargeback value %q is mapped to both %s and %s", value, other, team)
+			}
+			teamByValue[key] = team
+		}
+	}
+
+	shared := make(map[string]bool, len(mapping.Shared))
+	for _, value := range mapping.Shared {
+		if team, ok := teamByValue[strings.ToLower(value)]; ok {
+			return nil, fmt.Errorf("chargeback value %q is both shared and mapped to %s", value, team)
+		}
+		shared[strings.ToLower(value)] = true
+	}
+
+	return &chargebackService{
+		mapping:         mapping,
+		currencyService: currencyService,
+		teamByValue:     teamByValue,
+		shared:          shared,
+	}, nil
+}
+
+// GetSource returns the allocation source for provider: its own entry, then an
+// entry without a provider, then the team tag
+func (s *chargebackService) GetSource(provider string) model.AllocationSource {
+	fallback := defaultSource
+	for _, source := range s.mapping.Sources {
+		if source.Provider == provider {
+			return source
+		}
+		if source.Provider == "" {
+			fallback = source
+		}
+	}
+
+	fallback.Provider = provider
+	return fallback
+}
+
+// GroupedCosts reads spend in [start, end) grouped by a tag key or dimension
+func GroupedCosts(ctx context.Context, source model.AllocationSource, costService service.CostService, start, end time.Time) (*model.CostInfo, error) {
+	if source.Dimension == model.DimensionTag {
+		return costService.GetCostsByTag(ctx, source.Key, start, end)
+	}
+	return costService.GetCostsByDimension(ctx, source.Dimension, start, end)
+}
+
+// Allocate converts every provider's spend to the reporting currency and
+// assigns it to teams. Shared spend is always split across teams; spend that
+// matches no team is split or kept as model.UnallocatedTeam depending on the
+// mapping. Providers that failed or cannot be converted are left out and have
+// their ConversionError set.
+func (s *chargebackService) Allocate(results []model.ProviderChargeback) []model.TeamCharge {
+	charges := make(map[string]*model.TeamCharge, len(s.mapping.Teams))
+	for team := range s.mapping.Teams {
+		charges[team] = &model.TeamCharge{Team: team, Direct: make(map[string]float64)}
+	}
+
+	var sharedPool, unallocatedPool float64
+
+	for i := range results {
+		result := &results[i]
+		if result.Error != nil || result.Costs == nil {
+			continue
+		}
+
+		type allocation struct {
+			value  string
+			amount float64
+		}
+		converted := make([]allocation, 0, len(result.Costs.CostGroup))
+		for value, cost := range result.Costs.CostGroup {
+			amount, rate, err := s.currencyService.Convert(cost.Amount, cost.Unit)
+			if err != nil {
+				result.ConversionError = err
+				break
+			}
+			result.Currency = cost.Unit
+			result.Rate = rate
+			converted = append(converted, allocation{value: value, amount: amount})
+		}
+		if result.ConversionError != nil {
+			continue
+		}
+
+		for _, entry := range converted {
+			key := strings.ToLower(entry.value)
+			switch {
+			case s.shared[key]:
+				sharedPool += entry.amount
+			case s.teamByValue[key] != "":
+				charges[s.teamByValue[key]].Direct[result.Provider] += entry.amount
+			default:
+				unallocatedPool += entry.amount
+			}
+		}
+	}
+
+	teams := make([]model.TeamCharge, 0, len(charges)+1)
+	weights := s.weights(charges, s.mapping.SharedSplit)
+	unallocatedWeights := s.weights(charges, s.mapping.UnallocatedSplit)
+
+	for team, charge := range charges {
+		charge.Shared = sharedPool * weights[team]
+		if s.mapping.UnallocatedSplit != model.SplitNone {
+			charge.Unallocated = unallocatedPool * unallocatedWeights[team]
+		}
+		charge.Total = directTotal(charge) + charge.Shared + charge.Unallocated
+		teams = append(teams, *charge)
+	}
+
+	sort.Slice(teams, func(i, j int) bool {
+		if teams[i].Total != teams[j].Total {
+			return teams[i].Total > teams[j].Total
+		}
+		return teams[i].Team < teams[j].Team
+	})
+
+	if s.mapping.UnallocatedSplit == model.SplitNone && unallocatedPool > 0 {
+		teams = append(teams, model.TeamCharge{
+			Team:        model.UnallocatedTeam,
+			Direct:      map[string]float64{},
+			Unallocated: unallocatedPool,
+			Total:       unallocatedPool,
+		})
+	}
+
+	return teams
+}
+
+// weights returns each team's share of a split. Proportional splits follow
+// direct spend and fall back to even when no team has any.
+func (s *chargebackService) weights(charges map[string]*model.TeamCharge, split string) map[string]float64 {
+	weights := make(map[string]float64, len(charges))
+
+	if split == model.SplitProportional {
+		var total float64
+		for _, charge := range charges {
+			total += directTotal(charge)
+		}
+		if total > 0 {
+			for team, charge := range charges {
+				weights[team] = directTotal(charge) / total
+			}
+			return weights
+		}
+	}
+
+	for team := range charges {
+		weights[team] = 1 / float64(len(charges))
+	}
+	return weights
+}
+
+func directTotal(charge *model.TeamCharge) float64 {
+	var total float64
+	for _, amount := range charge.Direct {
+		total += amount
+	}
+	return total
+}
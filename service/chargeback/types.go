@@ -0,0 +1,19 @@
+package chargeback
+
+import (
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/currency"
+)
+
+type chargebackService struct {
+	mapping         model.ChargebackMapping
+	currencyService currency.CurrencyService
+	// teamByValue and shared are keyed by the lower-cased mapped value
+	teamByValue map[string]string
+	shared      map[string]bool
+}
+
+type ChargebackService interface {
+	GetSource(provider string) model.AllocationSource
+	Allocate(results []model.ProviderChargeback) []model.TeamCharge
+}
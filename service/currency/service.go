@@ -0,0 +1,163 @@
+package currency
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/utils"
+)
+
+// DefaultReportingCurrency is used when no reporting currency is configured
+const DefaultReportingCurrency = "USD"
+
+// NewService builds a converter from the currency section of the config file.
+// Rates from RatesFile are loaded first and inline Rates override them.
+func NewService(cfg model.CurrencyConfig) (*service, error) {
+	s := &service{
+		reportingCurrency: normalizeCode(cfg.ReportingCurrency),
+		base:              normalizeCode(cfg.Base),
+		rates:             make(map[string]float64),
+	}
+
+	if cfg.RatesFile != "" {
+		data, err := os.ReadFile(cfg.RatesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rates file %s: %w", cfg.RatesFile, err)
+		}
+
+		var file ratesFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse rates file %s: %w", cfg.RatesFile, err)
+		}
+
+		if s.base == "" {
+			s.base = normalizeCode(file.Base)
+		} else if file.Base != "" && normalizeCode(file.Base) != s.base {
+			return nil, fmt.Errorf("rates file base %s does not match configured base %s", file.Base, s.base)
+		}
+
+		for code, rate := range file.Rates {
+			s.rates[normalizeCode(code)] = rate
+		}
+	}
+
+	for code, rate := range cfg.Rates {
+		s.rates[normalizeCode(code)] = rate
+	}
+
+	if s.reportingCurrency == "" {
+		s.reportingCurrency = DefaultReportingCurrency
+	}
+	if s.base == "" {
+		s.base = s.reportingCurrency
+	}
+
+	// The base currency is always worth exactly one unit of itself
+	s.rates[s.base] = 1
+
+	for code, rate := range s.rates {
+		if rate <= 0 {
+			return nil, fmt.Errorf("invalid exchange rate for %s: %v", code, rate)
+		}
+	}
+
+	return s, nil
+}
+
+func (s *service) GetReportingCurrency() string {
+	return s.reportingCurrency
+}
+
+// Convert converts an amount in the given currency to the reporting currency,
+// returning the converted amount and the rate that was applied
+func (s *service) Convert(amount float64, from string) (float64, float64, error) {
+	from = normalizeCode(from)
+	if from == "" || from == s.reportingCurrency {
+		return amount, 1, nil
+	}
+
+	fromRate, ok := s.rates[from]
+	if !ok {
+		return 0, 0, fmt.Errorf("no exchange rate for %s to %s", from, s.reportingCurrency)
+	}
+
+	toRate, ok := s.rates[s.reportingCurrency]
+	if !ok {
+		return 0, 0, fmt.Errorf("no exchange rate for %s to %s", from, s.reportingCurrency)
+	}
+
+	rate := toRate / fromRate
+	return amount * rate, rate, nil
+}
+
+// ConvertCostResults detects the billing currency of each provider result and fills in
+// its totals converted to the reporting currency. Results that cannot be converted keep
+// a nil Converted field so callers never mix currencies silently.
+func (s *service) ConvertCostResults(results []model.ProviderCostResult) {
+	for i := range results {
+		result := &results[i]
+		if result.Error != nil {
+			continue
+		}
+
+		if result.Currency == "" {
+			result.Currency = DetectCurrency(*result)
+		}
+
+		currentAmount, _ := utils.ParseTotal(result.CurrentTotalCost)
+		lastAmount, _ := utils.ParseTotal(result.LastTotalCost)
+
+		current, rate, err := s.Convert(currentAmount, result.Currency)
+		if err != nil {
+			result.ConversionError = err
+			continue
+		}
+		last, _, _ := s.Convert(lastAmount, result.Currency)
+
+		result.Converted = &model.ConvertedCost{
+			Currency:         s.reportingCurrency,
+			Rate:             rate,
+			CurrentTotalCost: current,
+			LastTotalCost:    last,
+		}
+	}
+}
+
+// DetectCurrency returns the billing currency reported by a provider, looking at the
+// formatted totals first and falling back to the per-service cost units
+func DetectCurrency(result model.ProviderCostResult) string {
+	for _, total := range []string{result.CurrentTotalCost, result.LastTotalCost} {
+		parts := strings.Fields(total)
+		if len(parts) > 1 {
+			return normalizeCode(parts[1])
+		}
+	}
+
+	for _, info := range []*model.CostInfo{result.CurrentMonthData, result.LastMonthData} {
+		if info == nil {
+			continue
+		}
+		for _, group := range info.CostGroup {
+			if group.Unit != "" {
+				return normalizeCode(group.Unit)
+			}
+		}
+	}
+
+	for _, month := range result.TrendData {
+		for _, group := range month.CostGroup {
+			if group.Unit != "" {
+				return normalizeCode(group.Unit)
+			}
+		}
+	}
+
+	return ""
+}
+
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,21 @@
+package currency
+
+import "github.com/elC0mpa/aws-doctor/model"
+
+type service struct {
+	reportingCurrency string
+	base              string
+	rates             map[string]float64
+}
+
+type CurrencyService interface {
+	GetReportingCurrency() string
+	Convert(amount float64, from string) (float64, float64, error)
+	ConvertCostResults(results []model.ProviderCostResult)
+}
+
+// ratesFile mirrors the layout of common exchange rate feeds
+type ratesFile struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
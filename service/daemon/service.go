@@ -0,0 +1,282 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/history"
+	"github.com/elC0mpa/aws-doctor/service/notify"
+	"github.com/elC0mpa/aws-doctor/service/schedule"
+)
+
+// NewService validates the scheduled checks. The alerts of each check's last
+// run are kept in statePath so that a restart does not repeat notifications.
+func NewService(cfg model.DaemonConfig, topMovers int, statePath string, run Runner, deliver Deliverer) (*service, error) {
+	if len(cfg.Checks) == 0 {
+		return nil, fmt.Errorf("no scheduled checks: add daemon.checks to the config file")
+	}
+	if topMovers <= 0 {
+		topMovers = model.DefaultTopMovers
+	}
+
+	s := &service{
+		runOnStart: cfg.RunOnStart,
+		topMovers:  topMovers,
+		statePath:  statePath,
+		run:        run,
+		deliver:    deliver,
+	}
+
+	names := make(map[string]bool)
+	for i, check := range cfg.Checks {
+		if check.Name == "" {
+			check.Name = fmt.Sprintf("%s-%d", check.Mode, i+1)
+		}
+		if names[check.Name] {
+			return nil, fmt.Errorf("check %s: duplicate name", check.Name)
+		}
+		names[check.Name] = true
+
+		switch check.Mode {
+		case model.CheckCost, model.CheckWaste, model.CheckAnomalies:
+		case model.CheckTrend:
+			if check.Notify || check.EmailEnv != "" {
+				return nil, fmt.Errorf("check %s: trend checks cannot notify or send email", check.Name)
+			}
+		default:
+			return nil, fmt.Errorf("check %s: unknown mode %q (supported: cost, trend, waste, anomalies)", check.Name, check.Mode)
+		}
+
+		switch check.Provider {
+		case "", "aws", "gcp", "azure", "all":
+		default:
+			return nil, fmt.Errorf("check %s: unknown provider %q", check.Name, check.Provider)
+		}
+
+		if check.Schedule == "" {
+			return nil, fmt.Errorf("check %s: schedule is required", check.Name)
+		}
+		sched, err := schedule.NewService(check.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("check %s: %w", check.Name, err)
+		}
+
+		s.jobs = append(s.jobs, &job{check: check, schedule: sched})
+	}
+
+	return s, nil
+}
+
+// Run starts every check on its schedule and blocks until ctx is cancelled
+// and the running checks have finished
+func (s *service) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, j, &wg)
+		}()
+	}
+
+	wg.Wait()
+	return nil
+}
+
+// loop runs j on its schedule until ctx is cancelled. Background runs are
+// added to wg so that Run waits for them.
+func (s *service) loop(ctx context.Context, j *job, wg *sync.WaitGroup) {
+	if s.runOnStart {
+		s.runJob(ctx, j)
+	}
+
+	for {
+		next := j.schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("check %s: schedule %q never fires again", j.check.Name, j.schedule.String())
+			return
+		}
+		log.Printf("check %s: next run at %s", j.check.Name, next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// Run in the background so that a slow check does not delay the
+		// schedule; runJob skips the run if the previous one is still going
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runJob(ctx, j)
+		}()
+	}
+}
+
+func (s *service) runJob(ctx context.Context, j *job) {
+	if !j.running.TryLock() {
+		log.Printf("check %s: previous run still in progress, skipping", j.check.Name)
+		return
+	}
+	defer j.running.Unlock()
+
+	started := time.Now()
+	result, err := s.run(ctx, j.check)
+	if err != nil {
+		log.Printf("check %s: failed: %v", j.check.Name, err)
+		result = nil
+	}
+
+	alerts := Alerts(result, err, s.topMovers)
+	previous, known, stateErr := s.loadAlerts(j.check.Name)
+	if stateErr != nil {
+		log.Printf("check %s: %v", j.check.Name, stateErr)
+	}
+
+	changed := !known || !slices.Equal(previous, alerts)
+	log.Printf("check %s: finished in %s, %d alert(s), changed=%t", j.check.Name, time.Since(started).Round(time.Second), len(alerts), changed)
+	if !changed {
+		return
+	}
+
+	if (j.check.Notify || j.check.EmailEnv != "") && result != nil {
+		// A first run with nothing to report is recorded without a message
+		if known || len(alerts) > 0 {
+			if err := s.deliver(ctx, j.check, result); err != nil {
+				// Keep the previous alerts so the next run tries again
+				log.Printf("check %s: delivery failed: %v", j.check.Name, err)
+				return
+			}
+			log.Printf("check %s: report delivered", j.check.Name)
+		}
+	}
+
+	if err := s.saveAlerts(j.check.Name, alerts); err != nil {
+		log.Printf("check %s: %v", j.check.Name, err)
+	}
+}
+
+// Alerts lists what a check reports on, one sorted line per alert: failed
+// providers, budget statuses, anomalies, the services with the largest cost
+// change and waste findings. A report is only delivered when this list
+// differs from the check's previous run.
+func Alerts(result *model.RunResult, runErr error, topMovers int) []string {
+	alerts := make([]string, 0)
+	if runErr != nil {
+		return append(alerts, "run failed")
+	}
+	if result == nil {
+		return alerts
+	}
+
+	for _, cost := range result.Costs {
+		if cost.Error != nil {
+			alerts = append(alerts, fmt.Sprintf("%s failed", cost.Provider))
+			continue
+		}
+		for _, b := range cost.Budgets {
+			alerts = append(alerts, fmt.Sprintf("%s budget %s: %s", cost.Provider, b.Name, b.Status))
+		}
+		for _, a := range cost.Anomalies {
+			alerts = append(alerts, fmt.Sprintf("%s anomaly %s", cost.Provider, a.Service))
+		}
+	}
+
+	if result.Mode == model.RunModeCost {
+		for _, mover := range notify.BuildDigest(result, topMovers).TopMovers {
+			direction := "up"
+			if mover.Change < 0 {
+				direction = "down"
+			}
+			alerts = append(alerts, fmt.Sprintf("%s mover %s %s", mover.Provider, mover.Service, direction))
+		}
+	}
+
+	for _, waste := range result.Waste {
+		if waste.Error != nil {
+			alerts = append(alerts, fmt.Sprintf("%s failed", waste.Provider))
+			continue
+		}
+		for _, finding := range history.WasteFindings(waste) {
+			alerts = append(alerts, fmt.Sprintf("%s waste %s %s", waste.Provider, finding.Category, finding.ID))
+		}
+	}
+
+	sort.Strings(alerts)
+	return alerts
+}
+
+// loadAlerts returns the alerts saved for a check and whether the check has run before
+func (s *service) loadAlerts(name string) ([]string, bool, error) {
+	state, err := s.readState()
+	if err != nil {
+		return nil, false, err
+	}
+	alerts, ok := state[name]
+	return alerts, ok, nil
+}
+
+func (s *service) saveAlerts(name string, alerts []string) error {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	state, err := s.readStateLocked()
+	if err != nil {
+		state = make(map[string][]string)
+	}
+	state[name] = alerts
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode daemon state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.statePath), 0o755); err != nil {
+		return fmt.Errorf("failed to create daemon state directory: %w", err)
+	}
+
+	tmp := s.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write daemon state: %w", err)
+	}
+	if err := os.Rename(tmp, s.statePath); err != nil {
+		return fmt.Errorf("failed to write daemon state: %w", err)
+	}
+	return nil
+}
+
+func (s *service) readState() (map[string][]string, error) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return s.readStateLocked()
+}
+
+func (s *service) readStateLocked() (map[string][]string, error) {
+	state := make(map[string][]string)
+
+	data, err := os.ReadFile(s.statePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read daemon state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse daemon state %s: %w", s.statePath, err)
+	}
+	return state, nil
+}
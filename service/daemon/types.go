@@ -0,0 +1,37 @@
+package daemon
+
+import (
+	"context"
+	"sync"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/schedule"
+)
+
+// Runner collects the report of a scheduled check
+type Runner func(ctx context.Context, check model.ScheduledCheck) (*model.RunResult, error)
+
+// Deliverer sends a check's report to its notification channels and recipients
+type Deliverer func(ctx context.Context, check model.ScheduledCheck, result *model.RunResult) error
+
+type service struct {
+	jobs       []*job
+	runOnStart bool
+	topMovers  int
+	statePath  string
+	stateMu    sync.Mutex
+	run        Runner
+	deliver    Deliverer
+}
+
+// job is a check with its parsed schedule. running keeps a slow run from
+// overlapping with the next one.
+type job struct {
+	check    model.ScheduledCheck
+	schedule schedule.ScheduleService
+	running  sync.Mutex
+}
+
+type DaemonService interface {
+	Run(ctx context.Context) error
+}
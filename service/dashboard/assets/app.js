@@ -0,0 +1,511 @@
+"use strict";
+
+// The page reads the same reports as the REST API: /v1/costs for the summary
+// and the service breakdowns, /v1/trend and /v1/waste. Each report is fetched
+// when its tab is first shown and kept until Refresh or a provider change.
+
+const PROVIDER_COLORS = { aws: "#ff9900", gcp: "#4285f4", azure: "#0089d6" };
+
+const CATEGORY_LABELS = {
+  unused_volumes: "Unused volumes",
+  attached_volumes: "Volumes on stopped instances",
+  unused_ips: "Unused IPs",
+  stopped_instances: "Stopped instances",
+  expiring_reservations: "Expiring reservations",
+};
+
+const REPORTS = { summary: "costs", services: "costs", trend: "trend", waste: "waste" };
+
+const RENDERERS = {
+  summary: renderSummary,
+  services: renderServices,
+  trend: renderTrend,
+  waste: renderWaste,
+};
+
+const state = {
+  tab: "summary",
+  provider: "",
+  // generation changes on refresh so responses of older requests are dropped
+  generation: 0,
+  reports: {},
+  pending: {},
+  loadedAt: {},
+  services: { provider: "", filter: "", sort: { key: "amount", desc: true } },
+  trend: { hidden: new Set() },
+  waste: { provider: "", category: "", filter: "", sort: { key: "provider", desc: false } },
+};
+
+const $ = (selector) => document.querySelector(selector);
+
+function escapeHTML(value) {
+  return String(value ?? "").replace(/[&<>"']/g, (c) => ({
+    "&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;", "'": "&#39;",
+  })[c]);
+}
+
+function money(amount, currency) {
+  const formatted = (amount || 0).toLocaleString(undefined, {
+    minimumFractionDigits: 2,
+    maximumFractionDigits: 2,
+  });
+  return currency ? `${formatted} ${currency}` : formatted;
+}
+
+function change(current, last, currency) {
+  const diff = current - last;
+  const cls = diff > 0 ? "up" : diff < 0 ? "down" : "";
+  const sign = diff > 0 ? "+" : "";
+  const pct = last > 0 ? ` (${sign}${((diff / last) * 100).toFixed(1)}%)` : "";
+  return `<span class="${cls}">${sign}${money(diff, currency)}${pct}</span>`;
+}
+
+function providerName(provider) {
+  return provider.toUpperCase();
+}
+
+function setStatus(text, failed) {
+  const status = $("#status");
+  status.textContent = text;
+  status.classList.toggle("error", Boolean(failed));
+}
+
+// describeError shows a failed provider; the message already names a missing permission
+function describeError(error) {
+  return escapeHTML(error.message);
+}
+
+// Loading
+
+function load(report) {
+  if (state.reports[report]) {
+    return Promise.resolve(state.reports[report]);
+  }
+  if (state.pending[report]) {
+    return state.pending[report];
+  }
+
+  const params = new URLSearchParams();
+  if (state.provider) {
+    params.set("provider", state.provider);
+  }
+
+  const generation = state.generation;
+  const request = fetch(`v1/${report}?${params}`)
+    .then(async (resp) => {
+      const body = await resp.json();
+      if (!resp.ok) {
+        throw new Error(body.error ? body.error.message : resp.statusText);
+      }
+      if (generation === state.generation) {
+        state.reports[report] = body;
+        state.loadedAt[report] = new Date();
+      }
+      return body;
+    })
+    .finally(() => {
+      if (generation === state.generation) {
+        delete state.pending[report];
+      }
+    });
+
+  state.pending[report] = request;
+  return request;
+}
+
+async function show(tab) {
+  if (!RENDERERS[tab]) {
+    tab = "summary";
+  }
+  state.tab = tab;
+
+  document.querySelectorAll("#tabs a").forEach((a) => a.classList.toggle("active", a.dataset.tab === tab));
+  document.querySelectorAll(".tab").forEach((section) => section.classList.toggle("active", section.id === tab));
+
+  const report = REPORTS[tab];
+  const generation = state.generation;
+  if (!state.reports[report]) {
+    setStatus(`Collecting ${report}…`);
+  }
+
+  try {
+    const data = await load(report);
+    if (state.tab !== tab || generation !== state.generation) {
+      return;
+    }
+    RENDERERS[tab](data);
+    setStatus(`Updated ${state.loadedAt[report].toLocaleTimeString()}`);
+  } catch (err) {
+    if (state.tab === tab && generation === state.generation) {
+      setStatus(err.message, true);
+    }
+  }
+}
+
+function reload() {
+  state.generation++;
+  state.reports = {};
+  state.pending = {};
+  show(state.tab);
+}
+
+// Sorting
+
+function sortRows(rows, sort) {
+  const dir = sort.desc ? -1 : 1;
+  return rows.slice().sort((a, b) => {
+    const x = a[sort.key];
+    const y = b[sort.key];
+    if (typeof x === "number" && typeof y === "number") {
+      return (x - y) * dir;
+    }
+    return String(x ?? "").localeCompare(String(y ?? "")) * dir;
+  });
+}
+
+function bindSorting(table, sort, render) {
+  table.querySelectorAll("th[data-key]").forEach((th) => {
+    th.addEventListener("click", () => {
+      if (sort.key === th.dataset.key) {
+        sort.desc = !sort.desc;
+      } else {
+        sort.key = th.dataset.key;
+        sort.desc = th.classList.contains("num");
+      }
+      render();
+    });
+  });
+}
+
+function markSorted(table, sort) {
+  table.querySelectorAll("th[data-key]").forEach((th) => {
+    th.classList.toggle("asc", th.dataset.key === sort.key && !sort.desc);
+    th.classList.toggle("desc", th.dataset.key === sort.key && sort.desc);
+  });
+}
+
+// Summary
+
+function renderSummary(data) {
+  let total = "";
+  if (data.total != null) {
+    let excluded = "";
+    if (data.excluded_from_total) {
+      excluded = `<div class="meta warning">Excludes ${data.excluded_from_total.map(providerName).join(", ")}, not converted to ${escapeHTML(data.reporting_currency)}</div>`;
+    }
+    total = `
+      <div class="card">
+        <h2>All providers</h2>
+        <div class="amount">${money(data.total, data.reporting_currency)}</div>
+        <div class="meta">Last period ${money(data.compare_total, data.reporting_currency)}</div>
+        <div>${change(data.total, data.compare_total, data.reporting_currency)}</div>
+        ${excluded}
+      </div>`;
+  }
+  $("#summary-total").innerHTML = total;
+
+  const cards = data.providers.map((p) => {
+    if (p.error) {
+      return `
+        <div class="card failed">
+          <h2>${providerName(p.provider)}</h2>
+          <p class="error">${describeError(p.error)}</p>
+        </div>`;
+    }
+
+    let converted = "";
+    if (p.converted_total != null && data.reporting_currency && p.currency !== data.reporting_currency) {
+      converted = `<div class="meta">${money(p.converted_total, data.reporting_currency)}</div>`;
+    }
+    if (p.conversion_error) {
+      converted = `<div class="meta warning">${escapeHTML(p.conversion_error)}</div>`;
+    }
+
+    const budgets = (p.budgets || []).map((b) =>
+      `<span class="badge ${escapeHTML(b.status)}" title="${escapeHTML(b.scope)}">${escapeHTML(b.name)}: ${money(b.actual, b.currency)} of ${money(b.amount, b.currency)}</span>`).join("");
+
+    const warnings = (p.warnings || []).map((w) => `<p class="warning">${escapeHTML(w.scope ? `${w.scope}: ${w.message}` : w.message)}</p>`).join("");
+
+    return `
+      <div class="card">
+        <h2>${providerName(p.provider)} · ${escapeHTML(p.account_id)}</h2>
+        <div class="amount">${money(p.total, p.currency)}</div>
+        ${converted}
+        <div class="meta">${escapeHTML(p.start)} to ${escapeHTML(p.end)}, last period ${money(p.compare_total, p.currency)}</div>
+        <div>${change(p.total, p.compare_total || 0, p.currency)}</div>
+        <div>${budgets}</div>
+        ${warnings}
+      </div>`;
+  });
+  $("#summary-providers").innerHTML = cards.join("") || `<p class="meta">No providers selected.</p>`;
+}
+
+// Services
+
+function renderServices(data) {
+  const providers = data.providers.filter((p) => !p.error);
+  if (!providers.some((p) => p.provider === state.services.provider)) {
+    state.services.provider = providers.length ? providers[0].provider : "";
+  }
+
+  $("#services-providers").innerHTML = data.providers.map((p) => {
+    const cls = p.provider === state.services.provider ? "pill active" : p.error ? "pill off" : "pill";
+    return `<button type="button" class="${cls}" data-provider="${escapeHTML(p.provider)}" ${p.error ? "disabled" : ""}>${providerName(p.provider)}</button>`;
+  }).join("");
+
+  const table = $("#services-table");
+  const provider = providers.find((p) => p.provider === state.services.provider);
+  if (!provider) {
+    table.tBodies[0].innerHTML = `<tr><td colspan="5" class="empty">No cost data</td></tr>`;
+    return;
+  }
+
+  const filter = state.services.filter.toLowerCase();
+  const rows = provider.groups
+    .filter((g) => g.name.toLowerCase().includes(filter))
+    .map((g) => ({
+      name: g.name,
+      amount: g.amount,
+      compare: g.compare_amount || 0,
+      change: g.amount - (g.compare_amount || 0),
+      share: provider.total > 0 ? g.amount / provider.total : 0,
+    }));
+
+  markSorted(table, state.services.sort);
+  table.tBodies[0].innerHTML = sortRows(rows, state.services.sort).map((r) => `
+    <tr>
+      <td>${escapeHTML(r.name)}</td>
+      <td class="num">${money(r.compare, provider.currency)}</td>
+      <td class="num">${money(r.amount, provider.currency)}</td>
+      <td class="num">${change(r.amount, r.compare, provider.currency)}</td>
+      <td><div class="bar" style="width: ${(r.share * 100).toFixed(1)}%" data-tip="${(r.share * 100).toFixed(1)}% of ${providerName(provider.provider)}"></div></td>
+    </tr>`).join("") || `<tr><td colspan="5" class="empty">No matching services</td></tr>`;
+}
+
+// Trend
+
+function renderTrend(data) {
+  const series = data.providers.filter((p) => !p.error && p.months.length);
+  const months = [...new Set(series.flatMap((p) => p.months.map((m) => m.start.slice(0, 7))))].sort();
+
+  $("#trend-legend").innerHTML = data.providers.map((p) => {
+    if (p.error) {
+      return `<span class="pill off" title="${describeError(p.error)}">${providerName(p.provider)}: failed</span>`;
+    }
+    const off = state.trend.hidden.has(p.provider) ? " off" : "";
+    return `<button type="button" class="pill${off}" data-provider="${escapeHTML(p.provider)}">
+      <span class="swatch" style="background: ${PROVIDER_COLORS[p.provider] || "#888"}"></span>${providerName(p.provider)} (${escapeHTML(p.currency)})</button>`;
+  }).join("");
+
+  drawTrendChart(series.filter((p) => !state.trend.hidden.has(p.provider)), months);
+
+  const table = $("#trend-table");
+  table.tHead.innerHTML = `<tr><th>Month</th>${series.map((p) => `<th class="num">${providerName(p.provider)} (${escapeHTML(p.currency)})</th>`).join("")}</tr>`;
+  table.tBodies[0].innerHTML = months.map((month) => `
+    <tr>
+      <td>${month}</td>
+      ${series.map((p) => {
+        const m = p.months.find((m) => m.start.startsWith(month));
+        return `<td class="num">${m ? money(m.total) : "-"}</td>`;
+      }).join("")}
+    </tr>`).join("") || `<tr><td class="empty">No trend data</td></tr>`;
+}
+
+function drawTrendChart(series, months) {
+  const chart = $("#trend-chart");
+  const width = Math.max(chart.clientWidth, 320);
+  const height = 320;
+  const pad = { top: 20, right: 24, bottom: 32, left: 72 };
+  const plotWidth = width - pad.left - pad.right;
+  const plotHeight = height - pad.top - pad.bottom;
+
+  const max = Math.max(1, ...series.flatMap((p) => p.months.map((m) => m.total)));
+  const x = (i) => pad.left + (months.length > 1 ? (i / (months.length - 1)) * plotWidth : plotWidth / 2);
+  const y = (v) => pad.top + plotHeight - (v / max) * plotHeight;
+
+  let svg = "";
+  for (let i = 0; i <= 4; i++) {
+    const value = (max / 4) * i;
+    svg += `<line class="axis" x1="${pad.left}" x2="${width - pad.right}" y1="${y(value)}" y2="${y(value)}"/>`;
+    svg += `<text x="${pad.left - 8}" y="${y(value) + 4}" text-anchor="end">${money(value)}</text>`;
+  }
+  months.forEach((month, i) => {
+    svg += `<text x="${x(i)}" y="${height - 10}" text-anchor="middle">${month}</text>`;
+  });
+
+  for (const p of series) {
+    const color = PROVIDER_COLORS[p.provider] || "#888";
+    const points = months
+      .map((month, i) => ({ i, m: p.months.find((m) => m.start.startsWith(month)) }))
+      .filter((pt) => pt.m);
+    const path = points.map((pt, n) => `${n ? "L" : "M"}${x(pt.i)},${y(pt.m.total)}`).join(" ");
+    svg += `<path d="${path}" fill="none" stroke="${color}" stroke-width="2"/>`;
+    for (const pt of points) {
+      const tip = `${providerName(p.provider)} ${months[pt.i]}\n${money(pt.m.total, p.currency)}`;
+      svg += `<circle cx="${x(pt.i)}" cy="${y(pt.m.total)}" r="4" fill="${color}" data-tip="${escapeHTML(tip)}"/>`;
+    }
+  }
+
+  chart.innerHTML = `<svg viewBox="0 0 ${width} ${height}">${svg}</svg>`;
+}
+
+// Waste
+
+function wasteRows(data) {
+  return data.providers.flatMap((p) => (p.findings || []).map((f) => ({
+    provider: p.provider,
+    category: f.category,
+    id: f.id,
+    name: f.name || "",
+    region: f.region || "",
+    detail: f.detail || "",
+    owner: f.owner || "",
+  })));
+}
+
+function renderWaste(data) {
+  const all = wasteRows(data);
+
+  const counts = {};
+  for (const row of all) {
+    counts[row.category] = (counts[row.category] || 0) + 1;
+  }
+  $("#waste-counts").innerHTML = Object.entries(CATEGORY_LABELS).map(([category, label]) => {
+    const cls = state.waste.category === category ? "pill active" : "pill";
+    return `<button type="button" class="${cls}" data-category="${category}">${label}: ${counts[category] || 0}</button>`;
+  }).join("");
+
+  const providerSelect = $("#waste-provider");
+  providerSelect.innerHTML = `<option value="">All providers</option>` + data.providers
+    .map((p) => `<option value="${escapeHTML(p.provider)}">${providerName(p.provider)}</option>`).join("");
+  providerSelect.value = state.waste.provider;
+  $("#waste-category").value = state.waste.category;
+
+  $("#waste-problems").innerHTML = data.providers.map((p) => {
+    const lines = [];
+    if (p.error) {
+      lines.push(`<p class="error">${providerName(p.provider)}: ${describeError(p.error)}</p>`);
+    }
+    for (const w of p.warnings || []) {
+      lines.push(`<p class="warning">${providerName(p.provider)}: ${escapeHTML(w.scope ? `${w.scope}: ${w.message}` : w.message)}</p>`);
+    }
+    return lines.join("");
+  }).join("");
+
+  const filter = state.waste.filter.toLowerCase();
+  const rows = all.filter((r) =>
+    (!state.waste.provider || r.provider === state.waste.provider) &&
+    (!state.waste.category || r.category === state.waste.category) &&
+    [r.id, r.name, r.region, r.owner, r.detail].some((v) => v.toLowerCase().includes(filter)));
+
+  const table = $("#waste-table");
+  markSorted(table, state.waste.sort);
+  table.tBodies[0].innerHTML = sortRows(rows, state.waste.sort).map((r) => `
+    <tr>
+      <td>${providerName(r.provider)}</td>
+      <td>${escapeHTML(CATEGORY_LABELS[r.category] || r.category)}</td>
+      <td>${escapeHTML(r.id)}</td>
+      <td>${escapeHTML(r.name)}</td>
+      <td>${escapeHTML(r.region)}</td>
+      <td>${escapeHTML(r.detail)}</td>
+      <td>${escapeHTML(r.owner)}</td>
+    </tr>`).join("") || `<tr><td colspan="7" class="empty">${all.length ? "No matching findings" : "No waste found"}</td></tr>`;
+}
+
+// Events
+
+function rerender(tab) {
+  const data = state.reports[REPORTS[tab]];
+  if (state.tab === tab && data) {
+    RENDERERS[tab](data);
+  }
+}
+
+function bindEvents() {
+  window.addEventListener("hashchange", () => show(location.hash.slice(1)));
+  window.addEventListener("resize", () => rerender("trend"));
+
+  $("#refresh").addEventListener("click", reload);
+  $("#provider").addEventListener("change", (e) => {
+    state.provider = e.target.value;
+    reload();
+  });
+
+  $("#services-providers").addEventListener("click", (e) => {
+    const pill = e.target.closest("[data-provider]");
+    if (pill) {
+      state.services.provider = pill.dataset.provider;
+      rerender("services");
+    }
+  });
+  $("#services-filter").addEventListener("input", (e) => {
+    state.services.filter = e.target.value;
+    rerender("services");
+  });
+  bindSorting($("#services-table"), state.services.sort, () => rerender("services"));
+
+  $("#trend-legend").addEventListener("click", (e) => {
+    const pill = e.target.closest("[data-provider]");
+    if (pill) {
+      const hidden = state.trend.hidden;
+      hidden.has(pill.dataset.provider) ? hidden.delete(pill.dataset.provider) : hidden.add(pill.dataset.provider);
+      rerender("trend");
+    }
+  });
+
+  $("#waste-counts").addEventListener("click", (e) => {
+    const pill = e.target.closest("[data-category]");
+    if (pill) {
+      state.waste.category = state.waste.category === pill.dataset.category ? "" : pill.dataset.category;
+      rerender("waste");
+    }
+  });
+  $("#waste-provider").addEventListener("change", (e) => {
+    state.waste.provider = e.target.value;
+    rerender("waste");
+  });
+  $("#waste-category").addEventListener("change", (e) => {
+    state.waste.category = e.target.value;
+    rerender("waste");
+  });
+  $("#waste-filter").addEventListener("input", (e) => {
+    state.waste.filter = e.target.value;
+    rerender("waste");
+  });
+  bindSorting($("#waste-table"), state.waste.sort, () => rerender("waste"));
+
+  const tooltip = $("#tooltip");
+  document.addEventListener("mouseover", (e) => {
+    const target = e.target.closest("[data-tip]");
+    tooltip.hidden = !target;
+    if (target) {
+      tooltip.textContent = target.dataset.tip;
+    }
+  });
+  document.addEventListener("mousemove", (e) => {
+    tooltip.style.left = `${e.clientX + 12}px`;
+    tooltip.style.top = `${e.clientY + 12}px`;
+  });
+}
+
+async function init() {
+  $("#waste-category").innerHTML += Object.entries(CATEGORY_LABELS)
+    .map(([category, label]) => `<option value="${category}">${label}</option>`).join("");
+
+  bindEvents();
+  show(location.hash.slice(1));
+
+  // Name the providers the server queries by default
+  try {
+    const resp = await fetch("v1/providers");
+    const body = await resp.json();
+    const defaults = body.providers.filter((p) => p.default).map((p) => providerName(p.name));
+    if (defaults.length) {
+      $("#provider option[value='']").textContent = `Default (${defaults.join(", ")})`;
+    }
+  } catch (err) {
+    // The label is cosmetic; reports still load with the server's default
+  }
+}
+
+init();
@@ -0,0 +1,63 @@
+package dashboard
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// assets holds the single-page dashboard
+//
+//go:embed assets
+var assets embed.FS
+
+// NewService creates the dashboard listening on addr. data answers the /v1
+// endpoints of the REST API that the page reads its reports from, see
+// api.Routes.
+func NewService(addr string, data http.Handler) (*service, error) {
+	sub, err := fs.Sub(assets, "assets")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dashboard assets: %w", err)
+	}
+
+	return &service{addr: addr, data: data, assets: sub}, nil
+}
+
+// Handler serves the page on / and its data under /v1. Requests naming
+// another host are refused: a page on a hostname rebound to this machine
+// would otherwise read the reports, which need no token here.
+func (s *service) Handler() http.Handler {
+	files := http.FileServerFS(s.assets)
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/", s.data)
+	mux.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The assets change with the binary, so browsers must revalidate them
+		w.Header().Set("Cache-Control", "no-cache")
+		files.ServeHTTP(w, r)
+	}))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.localHost(r.Host) {
+			http.Error(w, "the dashboard only answers requests for localhost", http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// localHost reports whether the Host header of a request names this machine:
+// localhost, a loopback IP or the address the dashboard listens on
+func (s *service) localHost(host string) bool {
+	if host == s.addr {
+		return true
+	}
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	return host == "localhost" || net.ParseIP(host).IsLoopback()
+}
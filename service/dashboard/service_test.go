@@ -0,0 +1,48 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandlerRefusesForeignHosts(t *testing.T) {
+	tests := []struct {
+		host string
+		want int
+	}{
+		{host: "localhost:8081", want: http.StatusOK},
+		{host: "localhost", want: http.StatusOK},
+		{host: "127.0.0.1:8081", want: http.StatusOK},
+		{host: "[::1]:8081", want: http.StatusOK},
+		{host: "127.0.0.2:9000", want: http.StatusOK},
+		{host: "attacker.example:8081", want: http.StatusForbidden},
+		{host: "localhost.attacker.example", want: http.StatusForbidden},
+		{host: "10.0.0.5:8081", want: http.StatusForbidden},
+		{host: "", want: http.StatusForbidden},
+	}
+
+	data := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	s, err := NewService("127.0.0.2:9000", data)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	handler := s.Handler()
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			for _, path := range []string{"/", "/v1/costs"} {
+				r := httptest.NewRequest(http.MethodGet, path, nil)
+				r.Host = tt.host
+
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				if w.Code != tt.want {
+					t.Errorf("GET %s with Host %q = %d, want %d", path, tt.host, w.Code, tt.want)
+				}
+			}
+		})
+	}
+}
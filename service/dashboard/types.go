@@ -0,0 +1,18 @@
+package dashboard
+
+import (
+	"io/fs"
+	"net/http"
+)
+
+type service struct {
+	// addr is the listen address, which the Host header may name
+	addr string
+	// data answers the /v1 endpoints the page reads
+	data   http.Handler
+	assets fs.FS
+}
+
+type DashboardService interface {
+	Handler() http.Handler
+}
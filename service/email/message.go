@@ -0,0 +1,204 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/history"
+)
+
+// base64LineLength is the maximum encoded line length allowed by RFC 2045
+const base64LineLength = 76
+
+// buildMessage assembles a multipart/mixed message whose first part holds the
+// plain text and HTML bodies as alternatives, followed by the attachments
+func buildMessage(from string, to []string, subject, plain, html string, attachments []attachment, date time.Time) ([]byte, error) {
+	var alternative bytes.Buffer
+	altWriter := multipart.NewWriter(&alternative)
+	for _, body := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", plain},
+		{"text/html; charset=utf-8", html},
+	} {
+		part, err := altWriter.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {body.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(part)
+		if _, err := qp.Write([]byte(body.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := altWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	mixedWriter := multipart.NewWriter(&message)
+
+	headers := []string{
+		"From: " + from,
+		"To: " + strings.Join(to, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + date.Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q", mixedWriter.Boundary()),
+	}
+	message.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	part, err := mixedWriter.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {fmt.Sprintf("multipart/alternative; boundary=%q", altWriter.Boundary())},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(alternative.Bytes()); err != nil {
+		return nil, err
+	}
+
+	for _, a := range attachments {
+		part, err := mixedWriter.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(a.contentType, map[string]string{"name": a.filename})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		encoded := base64.StdEncoding.EncodeToString(a.content)
+		for len(encoded) > base64LineLength {
+			if _, err := part.Write([]byte(encoded[:base64LineLength] + "\r\n")); err != nil {
+				return nil, err
+			}
+			encoded = encoded[base64LineLength:]
+		}
+		if _, err := part.Write([]byte(encoded + "\r\n")); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mixedWriter.Close(); err != nil {
+		return nil, err
+	}
+	return message.Bytes(), nil
+}
+
+// csvAttachments exports the per-service costs and the waste findings of a
+// run. Sections without data are left out.
+func csvAttachments(result *model.RunResult) ([]attachment, error) {
+	var attachments []attachment
+	if result == nil {
+		return attachments, nil
+	}
+
+	if result.Mode == model.RunModeCost && len(result.Costs) > 0 {
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"provider", "account_id", "service", "last_month", "current_month", "change", "unit"})
+		for _, cost := range result.Costs {
+			if cost.Error != nil {
+				continue
+			}
+			for _, row := range serviceRows(cost) {
+				w.Write(row)
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment{filename: "costs.csv", contentType: "text/csv", content: buf.Bytes()})
+	}
+
+	if len(result.Waste) > 0 {
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"provider", "account_id", "category", "id", "detail"})
+		for _, waste := range result.Waste {
+			if waste.Error != nil {
+				continue
+			}
+			for _, finding := range history.WasteFindings(waste) {
+				w.Write([]string{waste.Provider, waste.AccountID, finding.Category, finding.ID, finding.Detail})
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment{filename: "waste.csv", contentType: "text/csv", content: buf.Bytes()})
+	}
+
+	return attachments, nil
+}
+
+// serviceRows lists every service billed in either period of a cost result
+func serviceRows(result model.ProviderCostResult) [][]string {
+	type amounts struct {
+		last, current float64
+		unit          string
+	}
+	services := make(map[string]*amounts)
+	var names []string
+	get := func(name string) *amounts {
+		a, ok := services[name]
+		if !ok {
+			a = &amounts{}
+			services[name] = a
+			names = append(names, name)
+		}
+		return a
+	}
+
+	if result.CurrentMonthData != nil {
+		for name, cost := range result.CurrentMonthData.CostGroup {
+			a := get(name)
+			a.current = cost.Amount
+			a.unit = cost.Unit
+		}
+	}
+	if result.LastMonthData != nil {
+		for name, cost := range result.LastMonthData.CostGroup {
+			a := get(name)
+			a.last = cost.Amount
+			if a.unit == "" {
+				a.unit = cost.Unit
+			}
+		}
+	}
+
+	sort.Strings(names)
+	rows := make([][]string, 0, len(names))
+	for _, name := range names {
+		a := services[name]
+		rows = append(rows, []string{
+			result.Provider,
+			result.AccountID,
+			name,
+			fmt.Sprintf("%.2f", a.last),
+			fmt.Sprintf("%.2f", a.current),
+			fmt.Sprintf("%.2f", a.current-a.last),
+			a.unit,
+		})
+	}
+	return rows
+}
@@ -0,0 +1,257 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	htmltemplate "html/template"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/notify"
+)
+
+// sendTimeout bounds the whole SMTP conversation
+const sendTimeout = time.Minute
+
+// defaultPorts is the submission port for each security mode
+var defaultPorts = map[string]int{
+	model.SMTPStartTLS: 587,
+	model.SMTPTLS:      465,
+	model.SMTPNone:     25,
+}
+
+// NewService validates the SMTP settings. topMovers is how many services with
+// the largest cost change the report lists.
+func NewService(cfg model.EmailConfig, topMovers int) (*service, error) {
+	if cfg.Security == "" {
+		cfg.Security = model.SMTPStartTLS
+	}
+	if _, ok := defaultPorts[cfg.Security]; !ok {
+		return nil, fmt.Errorf("email: unknown security %q. Supported values: starttls, tls, none", cfg.Security)
+	}
+	if cfg.Port == 0 {
+		cfg.Port = defaultPorts[cfg.Security]
+	}
+
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("email: host is required")
+	}
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return nil, fmt.Errorf("email: invalid from address %q: %w", cfg.From, err)
+	}
+	for environment, recipients := range cfg.Recipients {
+		for _, recipient := range recipients {
+			if _, err := mail.ParseAddress(recipient); err != nil {
+				return nil, fmt.Errorf("email: invalid recipient %q for %s: %w", recipient, environment, err)
+			}
+		}
+	}
+
+	password := cfg.Password
+	if cfg.PasswordEnv != "" {
+		password = os.Getenv(cfg.PasswordEnv)
+		if password == "" {
+			return nil, fmt.Errorf("email: environment variable %s is empty", cfg.PasswordEnv)
+		}
+	}
+
+	if topMovers <= 0 {
+		topMovers = model.DefaultTopMovers
+	}
+
+	return &service{
+		cfg:       cfg,
+		password:  password,
+		topMovers: topMovers,
+	}, nil
+}
+
+// Send mails the run's cost and waste summary to the recipients of environment
+func (s *service) Send(ctx context.Context, result *model.RunResult, environment string) error {
+	if environment == "" {
+		environment = model.DefaultEmailEnvironment
+	}
+	recipients := s.cfg.Recipients[environment]
+	if len(recipients) == 0 {
+		return fmt.Errorf("email: no recipients configured for environment %q", environment)
+	}
+
+	digest := notify.BuildDigest(result, s.topMovers)
+
+	var plain, html bytes.Buffer
+	if err := plainTemplate.Execute(&plain, digest); err != nil {
+		return fmt.Errorf("email: failed to render text body: %w", err)
+	}
+	if err := htmlTemplate.Execute(&html, digest); err != nil {
+		return fmt.Errorf("email: failed to render HTML body: %w", err)
+	}
+
+	var attachments []attachment
+	if s.cfg.AttachCSV {
+		var err error
+		attachments, err = csvAttachments(result)
+		if err != nil {
+			return fmt.Errorf("email: failed to build CSV attachments: %w", err)
+		}
+	}
+
+	message, err := buildMessage(s.cfg.From, recipients, digest.Title, plain.String(), html.String(), attachments, time.Now())
+	if err != nil {
+		return fmt.Errorf("email: failed to build message: %w", err)
+	}
+
+	if err := s.deliver(ctx, recipients, message); err != nil {
+		return fmt.Errorf("email: failed to send to %s:%d: %w", s.cfg.Host, s.cfg.Port, err)
+	}
+	return nil
+}
+
+// deliver runs the SMTP conversation, upgrading the connection with STARTTLS
+// or dialing TLS directly depending on the security mode
+func (s *service) deliver(ctx context.Context, recipients []string, message []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: s.cfg.Host}
+
+	var conn net.Conn
+	var err error
+	if s.cfg.Security == model.SMTPTLS {
+		dialer := &tls.Dialer{Config: tlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if s.cfg.Security == model.SMTPStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.password, s.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	from, _ := mail.ParseAddress(s.cfg.From)
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		to, _ := mail.ParseAddress(recipient)
+		if err := client.Rcpt(to.Address); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+var templateFuncs = map[string]any{
+	"upper":  strings.ToUpper,
+	"money":  func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"signed": func(v float64) string { return fmt.Sprintf("%+.2f", v) },
+	"pct":    func(v float64) string { return fmt.Sprintf("%+.1f%%", v) },
+}
+
+var plainTemplate = texttemplate.Must(texttemplate.New("plain").Funcs(templateFuncs).Parse(`{{.Title}}
+{{if .Costs}}
+COST COMPARISON (month to date vs same period last month)
+{{range .Costs}}- {{upper .Provider}}{{with .AccountID}} {{.}}{{end}}: {{if .Error}}failed: {{.Error}}{{else}}{{money .Current}} {{.Unit}} vs {{money .Last}} {{.Unit}} ({{pct .ChangePercent}}){{end}}
+{{end}}{{if .ReportingCurrency}}- TOTAL: {{money .TotalCurrent}} {{.ReportingCurrency}} vs {{money .TotalLast}} {{.ReportingCurrency}}
+{{end}}{{end}}{{if .Anomalies}}
+ANOMALIES
+{{range .Anomalies}}- {{upper .Provider}} {{.Service}}: {{signed .Change}} {{.Unit}} ({{money .Last}} -> {{money .Current}})
+{{end}}{{end}}{{if .TopMovers}}
+TOP MOVERS
+{{range .TopMovers}}- {{upper .Provider}} {{.Service}}: {{signed .Change}} {{.Unit}} ({{money .Last}} -> {{money .Current}})
+{{end}}{{end}}{{if .Waste}}
+WASTE
+{{range .Waste}}- {{upper .Provider}}{{with .AccountID}} {{.}}{{end}}: {{if .Error}}failed: {{.Error}}{{else}}{{.Total}} finding(s): {{.UnusedVolumes}} volumes, {{.UnusedIPs}} IPs, {{.StoppedInstances}} stopped instances, {{.ExpiringReservations}} expiring reservations{{end}}
+{{end}}{{end}}
+Sent by cloud-doctor at {{.Timestamp.Format "2006-01-02 15:04 MST"}}
+`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(templateFuncs).Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, Helvetica, sans-serif; color: #222;">
+<h2>{{.Title}}</h2>
+{{if .Costs}}
+<h3>Cost comparison</h3>
+<p style="color: #666;">Month to date vs the same period last month</p>
+<table cellpadding="6" style="border-collapse: collapse;">
+<tr style="background: #f0f0f0;"><th align="left">Provider</th><th align="left">Account/Project ID</th><th align="right">Last Month</th><th align="right">Current Month</th><th align="right">Change</th></tr>
+{{range .Costs}}<tr>
+<td><b>{{upper .Provider}}</b></td><td>{{.AccountID}}</td>
+{{if .Error}}<td colspan="3" style="color: #c00;">Failed: {{.Error}}</td>{{else}}<td align="right">{{money .Last}} {{.Unit}}</td><td align="right">{{money .Current}} {{.Unit}}</td><td align="right" style="color: {{if gt .ChangePercent 0.0}}#c00{{else}}#080{{end}};">{{pct .ChangePercent}}</td>{{end}}
+</tr>
+{{end}}{{if .ReportingCurrency}}<tr style="border-top: 1px solid #999;"><td><b>TOTAL</b></td><td></td><td align="right">{{money .TotalLast}} {{.ReportingCurrency}}</td><td align="right"><b>{{money .TotalCurrent}} {{.ReportingCurrency}}</b></td><td></td></tr>
+{{end}}</table>
+{{end}}
+{{if .Anomalies}}
+<h3>Anomalies</h3>
+<table cellpadding="6" style="border-collapse: collapse;">
+<tr style="background: #f0f0f0;"><th align="left">Provider</th><th align="left">Service</th><th align="right">Last Month</th><th align="right">Current Month</th><th align="right">Change</th></tr>
+{{range .Anomalies}}<tr><td>{{upper .Provider}}</td><td>{{.Service}}</td><td align="right">{{money .Last}}</td><td align="right">{{money .Current}}</td><td align="right" style="color: #c00;">{{signed .Change}} {{.Unit}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .TopMovers}}
+<h3>Top movers</h3>
+<table cellpadding="6" style="border-collapse: collapse;">
+<tr style="background: #f0f0f0;"><th align="left">Provider</th><th align="left">Service</th><th align="right">Last Month</th><th align="right">Current Month</th><th align="right">Change</th></tr>
+{{range .TopMovers}}<tr><td>{{upper .Provider}}</td><td>{{.Service}}</td><td align="right">{{money .Last}}</td><td align="right">{{money .Current}}</td><td align="right" style="color: {{if gt .Change 0.0}}#c00{{else}}#080{{end}};">{{signed .Change}} {{.Unit}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Waste}}
+<h3>Waste</h3>
+<table cellpadding="6" style="border-collapse: collapse;">
+<tr style="background: #f0f0f0;"><th align="left">Provider</th><th align="left">Account/Project ID</th><th>Unused Volumes</th><th>Unused IPs</th><th>Stopped Instances</th><th>Expiring RIs</th></tr>
+{{range .Waste}}<tr><td><b>{{upper .Provider}}</b></td><td>{{.AccountID}}</td>
+{{if .Error}}<td colspan="4" style="color: #c00;">Failed: {{.Error}}</td>{{else}}<td align="center">{{.UnusedVolumes}}</td><td align="center">{{.UnusedIPs}}</td><td align="center">{{.StoppedInstances}}</td><td align="center">{{.ExpiringReservations}}</td>{{end}}
+</tr>
+{{end}}</table>
+{{end}}
+<p style="color: #999; font-size: 12px;">Sent by cloud-doctor at {{.Timestamp.Format "2006-01-02 15:04 MST"}}</p>
+</body>
+</html>
+`))
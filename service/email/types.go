@@ -0,0 +1,24 @@
+package email
+
+import (
+	"context"
+
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+type service struct {
+	cfg       model.EmailConfig
+	password  string
+	topMovers int
+}
+
+type EmailService interface {
+	Send(ctx context.Context, result *model.RunResult, environment string) error
+}
+
+// attachment is a file attached to the report email
+type attachment struct {
+	filename    string
+	contentType string
+	content     []byte
+}
@@ -0,0 +1,269 @@
+package engine
+
+import (
+	"context"
+	"sync"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service"
+	"github.com/elC0mpa/aws-doctor/service/apierror"
+	awsconfig "github.com/elC0mpa/aws-doctor/service/aws/config"
+	azureconfig "github.com/elC0mpa/aws-doctor/service/azure/config"
+	"github.com/elC0mpa/aws-doctor/service/budget"
+	"github.com/elC0mpa/aws-doctor/service/cache"
+	"github.com/elC0mpa/aws-doctor/service/warnings"
+)
+
+// NewService creates the engine every report is collected with: the CLI, the
+// servers and the MCP tools. Cost reads go through costCache, which may be nil.
+func NewService(costCache cache.CacheService, settings Settings) *engineService {
+	return &engineService{
+		costCache:   costCache,
+		settings:    settings,
+		awsConfig:   awsconfig.NewService(settings.Policy),
+		azureConfig: azureconfig.NewService(),
+	}
+}
+
+// Open sets up the credentials of an account. The caller must Close the session.
+func (e *engineService) Open(ctx context.Context, account Account) (*Session, error) {
+	return open(ctx, account, e)
+}
+
+// CollectCosts reads the month-to-date and last month costs of an account by
+// service, and evaluates its budgets. Failures are recorded in the result.
+func (e *engineService) CollectCosts(ctx context.Context, account Account, opts Options) (result model.ProviderCostResult) {
+	result = model.ProviderCostResult{Provider: account.Provider}
+
+	collector := warnings.NewCollector()
+	ctx = warnings.WithCollector(ctx, collector)
+	defer func() {
+		result.Warnings = collector.List()
+		result.Error = apierror.Classify(account.Provider, result.Error)
+	}()
+
+	session, err := e.Open(ctx, account)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	defer session.Close()
+
+	accountInfo, err := session.Identity().GetAccountInfo(ctx)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	result.AccountID = accountInfo.AccountID
+
+	costService, err := session.Costs(ctx)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	currentMonthData, err := costService.GetCurrentMonthCostsByService(ctx)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	result.CurrentMonthData = currentMonthData
+
+	lastMonthData, err := costService.GetLastMonthCostsByService(ctx)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	result.LastMonthData = lastMonthData
+
+	currentTotalCost, err := costService.GetCurrentMonthTotalCosts(ctx)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	result.CurrentTotalCost = *currentTotalCost
+
+	lastTotalCost, err := costService.GetLastMonthTotalCosts(ctx)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	result.LastTotalCost = *lastTotalCost
+
+	result.CachedAt = cache.CachedAt(costService)
+
+	var budgetService service.BudgetService
+	if opts.ImportBudgets {
+		budgetService, err = session.Budgets(ctx)
+		if err != nil {
+			warnings.Add(ctx, apierror.NewWarning(account.Provider, "provider budgets", err))
+		}
+	}
+	result.Budgets = budget.Evaluate(ctx, e.settings.Budgets, result, costService, budgetService)
+
+	return result
+}
+
+// CollectTrend reads the last six months of costs of an account
+func (e *engineService) CollectTrend(ctx context.Context, account Account, opts Options) (result model.ProviderCostResult) {
+	result = model.ProviderCostResult{Provider: account.Provider}
+
+	collector := warnings.NewCollector()
+	ctx = warnings.WithCollector(ctx, collector)
+	defer func() {
+		result.Warnings = collector.List()
+		result.Error = apierror.Classify(account.Provider, result.Error)
+	}()
+
+	session, err := e.Open(ctx, account)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	defer session.Close()
+
+	accountInfo, err := session.Identity().GetAccountInfo(ctx)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	result.AccountID = accountInfo.AccountID
+
+	costService, err := session.Costs(ctx)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	trendData, err := costService.GetLastSixMonthsCosts(ctx)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	result.TrendData = trendData
+
+	result.CachedAt = cache.CachedAt(costService)
+
+	return result
+}
+
+// CollectWaste runs every waste check of an account. A failing check does not
+// discard the others; it is reported as a warning, and only when every check
+// fails is the first failure the result's error.
+func (e *engineService) CollectWaste(ctx context.Context, account Account, opts Options) (result model.ProviderWasteResult) {
+	result = model.ProviderWasteResult{Provider: account.Provider}
+
+	collector := warnings.NewCollector()
+	ctx = warnings.WithCollector(ctx, collector)
+	defer func() {
+		result.Warnings = collector.List()
+		result.Error = apierror.Classify(account.Provider, result.Error)
+	}()
+
+	session, err := e.Open(ctx, account)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	defer session.Close()
+
+	accountInfo, err := session.Identity().GetAccountInfo(ctx)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	result.AccountID = accountInfo.AccountID
+
+	resourceService, err := session.Resources(ctx)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	var failures []error
+	checkFailed := func(check string, err error) {
+		failures = append(failures, err)
+		collector.Add(apierror.NewWarning(account.Provider, check, apierror.Classify(account.Provider, err)))
+	}
+
+	unusedIPs, err := resourceService.GetUnusedIPs(ctx)
+	if err != nil {
+		checkFailed("unused IPs", err)
+	}
+	result.UnusedIPs = unusedIPs
+
+	unusedVolumes, err := resourceService.GetUnusedVolumes(ctx)
+	if err != nil {
+		checkFailed("unused volumes", err)
+	}
+	result.UnusedVolumes = unusedVolumes
+
+	stoppedInstances, attachedVolumes, err := resourceService.GetStoppedInstances(ctx)
+	if err != nil {
+		checkFailed("stopped instances", err)
+	}
+	result.StoppedInstances = opts.StoppedInstances(stoppedInstances)
+	result.AttachedVolumes = attachedVolumes
+
+	expiringReservations, err := resourceService.GetExpiringReservations(ctx)
+	if err != nil {
+		checkFailed("expiring reservations", err)
+	}
+	result.ExpiringReservations = opts.Reservations(expiringReservations)
+
+	if len(failures) == wasteChecks {
+		result.Error = failures[0]
+	}
+
+	return result
+}
+
+// wasteChecks is the number of checks CollectWaste runs
+const wasteChecks = 4
+
+// StoppedInstances drops instances stopped for fewer than MinStoppedDays.
+// Instances whose stop time is unknown are always kept.
+func (o Options) StoppedInstances(instances []model.StoppedInstance) []model.StoppedInstance {
+	if o.MinStoppedDays <= 0 {
+		return instances
+	}
+
+	var kept []model.StoppedInstance
+	for _, instance := range instances {
+		if instance.StoppedDays < 0 || instance.StoppedDays >= o.MinStoppedDays {
+			kept = append(kept, instance)
+		}
+	}
+	return kept
+}
+
+// Reservations drops reservations expiring, or expired, outside ExpiringWithinDays
+func (o Options) Reservations(reservations []model.Reservation) []model.Reservation {
+	if o.ExpiringWithinDays <= 0 {
+		return reservations
+	}
+
+	var kept []model.Reservation
+	for _, reservation := range reservations {
+		if reservation.DaysUntilExpiry <= o.ExpiringWithinDays && reservation.DaysUntilExpiry >= -o.ExpiringWithinDays {
+			kept = append(kept, reservation)
+		}
+	}
+	return kept
+}
+
+// CollectAll runs collect for every account concurrently and returns the
+// results in the order of accounts
+func CollectAll[T any](ctx context.Context, accounts []Account, collect func(context.Context, Account) T) []T {
+	results := make([]T, len(accounts))
+	var wg sync.WaitGroup
+	for i, account := range accounts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = collect(ctx, account)
+		}()
+	}
+	wg.Wait()
+	return results
+}
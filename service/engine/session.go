@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/elC0mpa/aws-doctor/service"
+	awsbudgets "github.com/elC0mpa/aws-doctor/service/aws/budgets"
+	awscostexplorer "github.com/elC0mpa/aws-doctor/service/aws/costexplorer"
+	awsec2 "github.com/elC0mpa/aws-doctor/service/aws/ec2"
+	awsresourcetagging "github.com/elC0mpa/aws-doctor/service/aws/resourcetagging"
+	awssts "github.com/elC0mpa/aws-doctor/service/aws/sts"
+	azurecompute "github.com/elC0mpa/aws-doctor/service/azure/compute"
+	azureconsumption "github.com/elC0mpa/aws-doctor/service/azure/consumption"
+	azurecostmanagement "github.com/elC0mpa/aws-doctor/service/azure/costmanagement"
+	azureidentity "github.com/elC0mpa/aws-doctor/service/azure/identity"
+	azureresourcegraph "github.com/elC0mpa/aws-doctor/service/azure/resourcegraph"
+	"github.com/elC0mpa/aws-doctor/service/cache"
+	gcpasset "github.com/elC0mpa/aws-doctor/service/gcp/asset"
+	gcpbilling "github.com/elC0mpa/aws-doctor/service/gcp/billing"
+	gcpbudgets "github.com/elC0mpa/aws-doctor/service/gcp/budgets"
+	gcpcompute "github.com/elC0mpa/aws-doctor/service/gcp/compute"
+	gcpidentity "github.com/elC0mpa/aws-doctor/service/gcp/identity"
+)
+
+// open sets up the credentials and identity service of an account
+func open(ctx context.Context, account Account, e *engineService) (*Session, error) {
+	s := &Session{account: account, engine: e}
+
+	switch account.Provider {
+	case "aws":
+		awsCfg, err := e.awsConfig.GetAWSCfg(ctx, account.Region, account.Profile)
+		if err != nil {
+			return nil, err
+		}
+		s.awsCfg = awsCfg
+		s.identityService = awssts.NewService(awsCfg)
+
+	case "gcp":
+		if account.ProjectID == "" {
+			return nil, fmt.Errorf("a GCP project is required")
+		}
+		identityService, err := gcpidentity.NewService(ctx, account.ProjectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCP identity service: %w", err)
+		}
+		s.identityService = identityService
+
+	case "azure":
+		if account.SubscriptionID == "" {
+			return nil, fmt.Errorf("an Azure subscription is required")
+		}
+		credential, err := e.azureConfig.GetCredential()
+		if err != nil {
+			return nil, err
+		}
+		s.azureCredential = credential
+
+		identityService, err := azureidentity.NewService(account.SubscriptionID, s.azureCredential)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure identity service: %w", err)
+		}
+		s.identityService = identityService
+
+	default:
+		return nil, fmt.Errorf("unknown provider: %s", account.Provider)
+	}
+
+	return s, nil
+}
+
+// Provider is the provider of the session's account
+func (s *Session) Provider() string {
+	return s.account.Provider
+}
+
+// Identity returns the identity service of the session's account
+func (s *Session) Identity() service.IdentityService {
+	return s.identityService
+}
+
+// Costs returns the account's cost service behind the cost cache. Every call
+// returns the same service, so cache.CachedAt covers all of a collection's reads.
+func (s *Session) Costs(ctx context.Context) (service.CostService, error) {
+	if s.costService != nil {
+		return s.costService, nil
+	}
+
+	var costService service.CostService
+	switch s.account.Provider {
+	case "aws":
+		costService = awscostexplorer.NewService(s.awsCfg)
+	case "gcp":
+		if s.account.BillingAccount == "" {
+			return nil, fmt.Errorf("a GCP billing account is required for cost analysis")
+		}
+		billingService, err := gcpbilling.NewService(ctx, s.account.ProjectID, s.account.BillingAccount, s.engine.settings.Policy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCP billing service: %w", err)
+		}
+		s.closers = append(s.closers, billingService.Close)
+		costService = billingService
+	case "azure":
+		costManagementService, err := azurecostmanagement.NewService(s.account.SubscriptionID, s.azureCredential, s.engine.settings.Policy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure cost management service: %w", err)
+		}
+		costService = costManagementService
+	}
+
+	s.costService = cache.WrapCostService(costService, s.identityService, s.engine.costCache)
+	return s.costService, nil
+}
+
+// Resources returns the service behind the waste checks
+func (s *Session) Resources(ctx context.Context) (service.ResourceService, error) {
+	switch s.account.Provider {
+	case "gcp":
+		computeService, err := gcpcompute.NewService(ctx, s.account.ProjectID, s.engine.settings.Policy, s.engine.settings.OwnerTagKeys)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCP compute service: %w", err)
+		}
+		return computeService, nil
+	case "azure":
+		computeService, err := azurecompute.NewService(s.account.SubscriptionID, s.azureCredential, s.engine.settings.Policy, s.engine.settings.OwnerTagKeys)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure compute service: %w", err)
+		}
+		return computeService, nil
+	default:
+		return awsec2.NewService(s.awsCfg, s.engine.settings.OwnerTagKeys), nil
+	}
+}
+
+// Budgets returns the service that lists the provider's own budgets
+func (s *Session) Budgets(ctx context.Context) (service.BudgetService, error) {
+	switch s.account.Provider {
+	case "gcp":
+		if s.account.BillingAccount == "" {
+			return nil, fmt.Errorf("a GCP billing account is required for provider budgets")
+		}
+		budgetsService, err := gcpbudgets.NewService(ctx, s.account.BillingAccount, s.engine.settings.Policy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCP budgets service: %w", err)
+		}
+		return budgetsService, nil
+	case "azure":
+		consumptionService, err := azureconsumption.NewService(s.account.SubscriptionID, s.azureCredential, s.engine.settings.Policy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure consumption service: %w", err)
+		}
+		return consumptionService, nil
+	default:
+		return awsbudgets.NewService(s.awsCfg, s.identityService), nil
+	}
+}
+
+// Tags returns the service that lists resources with their tags or labels
+func (s *Session) Tags(ctx context.Context) (service.TagService, error) {
+	switch s.account.Provider {
+	case "gcp":
+		assetService, err := gcpasset.NewService(ctx, s.account.ProjectID, s.engine.settings.Policy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCP asset service: %w", err)
+		}
+		return assetService, nil
+	case "azure":
+		graphService, err := azureresourcegraph.NewService(s.account.SubscriptionID, s.azureCredential, s.engine.settings.Policy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure resource graph service: %w", err)
+		}
+		return graphService, nil
+	default:
+		return awsresourcetagging.NewService(s.awsCfg), nil
+	}
+}
+
+// Close releases the clients the session created
+func (s *Session) Close() {
+	for _, closeClient := range s.closers {
+		closeClient()
+	}
+	s.closers = nil
+}
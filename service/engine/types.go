@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service"
+	awsconfig "github.com/elC0mpa/aws-doctor/service/aws/config"
+	azureconfig "github.com/elC0mpa/aws-doctor/service/azure/config"
+	"github.com/elC0mpa/aws-doctor/service/cache"
+	"github.com/elC0mpa/aws-doctor/service/resilience"
+)
+
+// Account selects the provider account to collect from. Only the fields of
+// Provider are read.
+type Account struct {
+	Provider string
+	// AWS
+	Region  string
+	Profile string
+	// GCP; costs need the billing account as well
+	ProjectID      string
+	BillingAccount string
+	// Azure
+	SubscriptionID string
+}
+
+// Options tune what a collection reads
+type Options struct {
+	// ImportBudgets evaluates the budgets defined in the provider's own budget API
+	ImportBudgets bool
+	// MinStoppedDays drops instances stopped for fewer days. Zero keeps the
+	// resource services' own 30 days.
+	MinStoppedDays int
+	// ExpiringWithinDays drops reservations expiring, or expired, further
+	// away. Zero keeps the resource services' own 30 days.
+	ExpiringWithinDays int
+}
+
+// Settings are the config file sections the engine builds services with
+type Settings struct {
+	// Policy retries, rate limits and times out every provider call
+	Policy resilience.Policy
+	// OwnerTagKeys are the tags waste findings are attributed to an owner by.
+	// Empty uses ownership.DefaultTagKeys.
+	OwnerTagKeys []string
+	// Budgets are evaluated against every cost collection. They must have
+	// passed budget.Validate.
+	Budgets []model.BudgetConfig
+}
+
+type engineService struct {
+	costCache cache.CacheService
+	settings  Settings
+	awsConfig awsconfig.ConfigService
+	// azureConfig holds the one Azure credential every subscription uses
+	azureConfig azureconfig.ConfigService
+}
+
+// Session holds the credentials of one account and creates its services on
+// first use. It is not safe for concurrent use; Close releases its clients.
+type Session struct {
+	account Account
+	engine  *engineService
+
+	awsCfg          aws.Config
+	azureCredential *azidentity.DefaultAzureCredential
+
+	identityService service.IdentityService
+	costService     service.CostService
+	closers         []func() error
+}
+
+type EngineService interface {
+	Open(ctx context.Context, account Account) (*Session, error)
+	CollectCosts(ctx context.Context, account Account, opts Options) model.ProviderCostResult
+	CollectTrend(ctx context.Context, account Account, opts Options) model.ProviderCostResult
+	CollectWaste(ctx context.Context, account Account, opts Options) model.ProviderWasteResult
+}
@@ -2,6 +2,7 @@ package flag
 
 import (
 	"flag"
+	"os"
 
 	"github.com/elC0mpa/aws-doctor/model"
 )
@@ -10,29 +11,82 @@ func NewService() *service {
 	return &service{}
 }
 
+// GetParsedFlags parses the command line. flag.ErrHelp is returned when help was requested.
 func (s *service) GetParsedFlags() (model.Flags, error) {
+	flagSet := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+
 	// Common flags
-	provider := flag.String("provider", "aws", "Cloud provider: aws, gcp, azure, all")
-	trend := flag.Bool("trend", false, "Display a trend report for the last 6 months")
-	waste := flag.Bool("waste", false, "Display waste report")
+	provider := flagSet.String("provider", "aws", "Cloud provider: aws, gcp, azure, all")
+	trend := flagSet.Bool("trend", false, "Display a trend report for the last 6 months")
+	waste := flagSet.Bool("waste", false, "Display waste report")
+	tagAudit := flagSet.Bool("tag-audit", false, "Audit resources and spend against the required tag policy")
+	chargeback := flagSet.Bool("chargeback", false, "Display spend per team using the chargeback mapping file")
+	mappingPath := flagSet.String("mapping", "", "Path to the chargeback mapping file (overrides chargeback.mapping_file in the config)")
+	month := flagSet.String("month", "", "Month for the chargeback report as YYYY-MM (default: last month)")
+	csvPath := flagSet.String("csv", "", "Also write the chargeback report as CSV to this file")
+	importBudgets := flagSet.Bool("import-budgets", false, "Also show the budgets defined in AWS Budgets, GCP billing budgets and Azure Consumption budgets")
+	failOn := flagSet.String("fail-on", "", "Exit non-zero when a rule fails: budget, budget-warn, waste>N, waste-cost>N, new-waste, increase>N%, error (comma-separated)")
+	notify := flagSet.Bool("notify", false, "Send the cost or waste digest to the notification channels in the config file")
+	sendEmail := flagSet.Bool("send-email", false, "Email the cost or waste report over SMTP using the email settings in the config file")
+	emailEnv := flagSet.String("email-env", "", "Recipient list from email.recipients to send to (default: default)")
+	serve := flagSet.Bool("serve", false, "Run the scheduled checks under daemon.checks in the config file until interrupted")
+	metricsAddr := flagSet.String("metrics-addr", "", "Serve Prometheus metrics on this address, e.g. :9464 (overrides metrics.address with --serve)")
+	apiAddr := flagSet.String("api-addr", "", "Serve the REST API on this address, e.g. :8080 (overrides api.address with --serve)")
+	dashboardAddr := flagSet.String("dashboard-addr", "", "Serve the web dashboard on this loopback address, e.g. localhost:8081 (overrides dashboard.address with --serve)")
+	tui := flagSet.Bool("tui", false, "Browse costs, trend and waste in an interactive terminal UI")
+	groupBy := flagSet.String("group-by", "", "Group the waste report: owner")
+	configPath := flagSet.String("config", "", "Path to the cloud-doctor config file (default: <user config dir>/cloud-doctor/config.json)")
+	currency := flagSet.String("currency", "", "Reporting currency for multi-cloud totals (overrides the config file, default: USD)")
+	diff := flagSet.Bool("diff", false, "Show changes since the previous cost or waste snapshot")
+	diffFrom := flagSet.String("diff-from", "", "Snapshot ID or date (YYYY-MM-DD or RFC3339) to diff against instead of the previous run")
+	noHistory := flagSet.Bool("no-history", false, "Do not save this run to the local history")
+	noCache := flagSet.Bool("no-cache", false, "Always query cost APIs instead of reusing cached responses")
+	cacheTTL := flagSet.Duration("cache-ttl", 0, "How long cached cost responses are reused, e.g. 30m or 6h (default: 1h)")
+	timeout := flagSet.Duration("timeout", 0, "Timeout for each provider API call, e.g. 30s or 2m (default: 1m)")
 
 	// AWS-specific flags
-	region := flag.String("region", "us-east-1", "AWS region")
-	profile := flag.String("profile", "", "AWS profile configuration")
+	region := flagSet.String("region", "us-east-1", "AWS region")
+	profile := flagSet.String("profile", "", "AWS profile configuration")
 
 	// GCP-specific flags
-	project := flag.String("project", "", "GCP project ID")
-	billingAccount := flag.String("billing-account", "", "GCP billing account ID (format: billingAccounts/XXXXXX-XXXXXX-XXXXXX)")
+	project := flagSet.String("project", "", "GCP project ID")
+	billingAccount := flagSet.String("billing-account", "", "GCP billing account ID (format: billingAccounts/XXXXXX-XXXXXX-XXXXXX)")
 
 	// Azure-specific flags
-	subscription := flag.String("subscription", "", "Azure subscription ID")
+	subscription := flagSet.String("subscription", "", "Azure subscription ID")
 
-	flag.Parse()
+	if err := flagSet.Parse(os.Args[1:]); err != nil {
+		return model.Flags{}, err
+	}
 
 	return model.Flags{
 		Provider:       *provider,
 		Trend:          *trend,
 		Waste:          *waste,
+		TagAudit:       *tagAudit,
+		Chargeback:     *chargeback,
+		MappingPath:    *mappingPath,
+		Month:          *month,
+		CSVPath:        *csvPath,
+		GroupBy:        *groupBy,
+		ImportBudgets:  *importBudgets,
+		FailOn:         *failOn,
+		Notify:         *notify,
+		SendEmail:      *sendEmail,
+		EmailEnv:       *emailEnv,
+		Serve:          *serve,
+		MetricsAddr:    *metricsAddr,
+		APIAddr:        *apiAddr,
+		DashboardAddr:  *dashboardAddr,
+		TUI:            *tui,
+		ConfigPath:     *configPath,
+		Currency:       *currency,
+		Diff:           *diff || *diffFrom != "",
+		DiffFrom:       *diffFrom,
+		NoHistory:      *noHistory,
+		NoCache:        *noCache,
+		CacheTTL:       *cacheTTL,
+		Timeout:        *timeout,
 		Region:         *region,
 		Profile:        *profile,
 		Project:        *project,
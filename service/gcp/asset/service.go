@@ -0,0 +1,80 @@
+package gcpasset
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/resilience"
+	"google.golang.org/api/cloudasset/v1"
+	"google.golang.org/api/option"
+)
+
+// labelledAssetTypes limits the search to common resources that support
+// labels, so resources that cannot carry labels are not reported as violations
+var labelledAssetTypes = []string{
+	"compute.googleapis.com/Instance",
+	"compute.googleapis.com/Disk",
+	"compute.googleapis.com/Snapshot",
+	"compute.googleapis.com/Image",
+	"compute.googleapis.com/Address",
+	"compute.googleapis.com/ForwardingRule",
+	"storage.googleapis.com/Bucket",
+	"sqladmin.googleapis.com/Instance",
+	"container.googleapis.com/Cluster",
+	"bigquery.googleapis.com/Dataset",
+	"run.googleapis.com/Service",
+	"cloudfunctions.googleapis.com/Function",
+	"pubsub.googleapis.com/Topic",
+	"pubsub.googleapis.com/Subscription",
+	"redis.googleapis.com/Instance",
+	"spanner.googleapis.com/Instance",
+}
+
+func NewService(ctx context.Context, projectID string, policy resilience.Policy) (*service, error) {
+	opts, err := policy.GCPClientOptions(ctx, option.WithScopes(cloudasset.CloudPlatformScope))
+	if err != nil {
+		return nil, err
+	}
+
+	assetClient, err := cloudasset.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cloud Asset client: %w", err)
+	}
+
+	return &service{
+		projectID:   projectID,
+		assetClient: assetClient,
+	}, nil
+}
+
+// GetTaggedResources implements service.TagService
+// Searches the project with Cloud Asset Inventory and returns resource labels
+func (s *service) GetTaggedResources(ctx context.Context) ([]model.TaggedResource, error) {
+	var resources []model.TaggedResource
+
+	call := s.assetClient.V1.SearchAllResources("projects/" + s.projectID).
+		AssetTypes(labelledAssetTypes...).
+		PageSize(500)
+	err := call.Pages(ctx, func(page *cloudasset.SearchAllResourcesResponse) error {
+		for _, result := range page.Results {
+			name := result.DisplayName
+			if name == "" {
+				name = result.Name
+			}
+			resources = append(resources, model.TaggedResource{
+				ID:     result.Name,
+				Name:   name,
+				Type:   result.AssetType,
+				Region: result.Location,
+				Tags:   result.Labels,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search resources: %w", err)
+	}
+
+	return resources, nil
+}
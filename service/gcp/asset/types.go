@@ -0,0 +1,17 @@
+package gcpasset
+
+import (
+	"context"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"google.golang.org/api/cloudasset/v1"
+)
+
+type service struct {
+	projectID   string
+	assetClient *cloudasset.Service
+}
+
+type AssetService interface {
+	GetTaggedResources(ctx context.Context) ([]model.TaggedResource, error)
+}
@@ -8,11 +8,21 @@ import (
 
 	"cloud.google.com/go/bigquery"
 	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/resilience"
 	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
 )
 
-func NewService(ctx context.Context, projectID, billingAccount string) (*service, error) {
-	bqClient, err := bigquery.NewClient(ctx, projectID)
+// identifierEscaper escapes a name for a backtick-quoted BigQuery identifier
+var identifierEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+
+func NewService(ctx context.Context, projectID, billingAccount string, policy resilience.Policy) (*service, error) {
+	opts, err := policy.GCPClientOptions(ctx, option.WithScopes(bigquery.Scope))
+	if err != nil {
+		return nil, err
+	}
+
+	bqClient, err := bigquery.NewClient(ctx, projectID, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create BigQuery client: %w", err)
 	}
@@ -45,17 +55,13 @@ func (s *service) getMonthCostsByService(ctx context.Context, endDate time.Time)
 	endDateStr := endDate.Format("2006-01-02")
 
 	// Query BigQuery billing export table
-	// The table name format is: project.dataset.gcp_billing_export_v1_BILLING_ACCOUNT_ID
 	// User needs to provide the billing account ID and have billing export enabled
-	billingAccountID := strings.ReplaceAll(s.billingAccount, "billingAccounts/", "")
-	billingAccountID = strings.ReplaceAll(billingAccountID, "-", "_")
-
 	query := fmt.Sprintf(`
 		SELECT
 			service.description AS service_name,
 			SUM(cost) AS total_cost,
 			currency
-		FROM %s.%s.gcp_billing_export_v1_%s
+		FROM %s
 		WHERE
 			project.id = @projectID
 			AND DATE(usage_start_time) >= @startDate
@@ -63,7 +69,7 @@ func (s *service) getMonthCostsByService(ctx context.Context, endDate time.Time)
 		GROUP BY service.description, currency
 		HAVING SUM(cost) > 0
 		ORDER BY total_cost DESC
-	`, s.projectID, "billing_export", billingAccountID)
+	`, s.exportTable("gcp_billing_export_v1_"))
 
 	q := s.bqClient.Query(query)
 	q.Parameters = []bigquery.QueryParameter{
@@ -127,20 +133,17 @@ func (s *service) getMonthTotalCosts(ctx context.Context, endDate time.Time) (*s
 	startDateStr := startDate.Format("2006-01-02")
 	endDateStr := endDate.Format("2006-01-02")
 
-	billingAccountID := strings.ReplaceAll(s.billingAccount, "billingAccounts/", "")
-	billingAccountID = strings.ReplaceAll(billingAccountID, "-", "_")
-
 	query := fmt.Sprintf(`
 		SELECT
 			SUM(cost) AS total_cost,
 			currency
-		FROM %s.%s.gcp_billing_export_v1_%s
+		FROM %s
 		WHERE
 			project.id = @projectID
 			AND DATE(usage_start_time) >= @startDate
 			AND DATE(usage_start_time) < @endDate
 		GROUP BY currency
-	`, s.projectID, "billing_export", billingAccountID)
+	`, s.exportTable("gcp_billing_export_v1_"))
 
 	q := s.bqClient.Query(query)
 	q.Parameters = []bigquery.QueryParameter{
@@ -181,9 +184,6 @@ func (s *service) getMonthTotalCosts(ctx context.Context, endDate time.Time) (*s
 
 // GetLastSixMonthsCosts implements service.CostService
 func (s *service) GetLastSixMonthsCosts(ctx context.Context) ([]model.CostInfo, error) {
-	billingAccountID := strings.ReplaceAll(s.billingAccount, "billingAccounts/", "")
-	billingAccountID = strings.ReplaceAll(billingAccountID, "-", "_")
-
 	startDate := s.getFirstDayOfMonth(time.Now().AddDate(0, -6, 0))
 	endDate := s.getFirstDayOfMonth(time.Now())
 
@@ -193,14 +193,14 @@ func (s *service) GetLastSixMonthsCosts(ctx context.Context) ([]model.CostInfo,
 			FORMAT_DATE('%%Y-%%m-%%d', DATE_ADD(DATE_TRUNC(DATE(usage_start_time), MONTH), INTERVAL 1 MONTH)) AS month_end,
 			SUM(cost) AS total_cost,
 			currency
-		FROM %s.%s.gcp_billing_export_v1_%s
+		FROM %s
 		WHERE
 			project.id = @projectID
 			AND DATE(usage_start_time) >= @startDate
 			AND DATE(usage_start_time) < @endDate
 		GROUP BY month_start, month_end, currency
 		ORDER BY month_start
-	`, s.projectID, "billing_export", billingAccountID)
+	`, s.exportTable("gcp_billing_export_v1_"))
 
 	q := s.bqClient.Query(query)
 	q.Parameters = []bigquery.QueryParameter{
@@ -253,6 +253,154 @@ func (s *service) GetLastSixMonthsCosts(ctx context.Context) ([]model.CostInfo,
 	return monthlyCosts, nil
 }
 
+// GetCostsByTag implements service.CostService
+// Groups spend by the value of the resource label tagKey
+func (s *service) GetCostsByTag(ctx context.Context, tagKey string, start, end time.Time) (*model.CostInfo, error) {
+	valueExpr := "IFNULL((SELECT l.value FROM UNNEST(labels) AS l WHERE l.key = @tagKey LIMIT 1), '')"
+	return s.getGroupedCosts(ctx, groupedQuery{
+		valueExpr:   valueExpr,
+		projectOnly: true,
+		params:      []bigquery.QueryParameter{{Name: "tagKey", Value: tagKey}},
+	}, start, end)
+}
+
+// GetCostsByDimension implements service.CostService
+// Spend can be grouped by project, service or SKU. Unlike the other queries,
+// grouping by project covers every project billed to the billing account, not
+// just --project.
+func (s *service) GetCostsByDimension(ctx context.Context, dimension string, start, end time.Time) (*model.CostInfo, error) {
+	switch dimension {
+	case model.DimensionProject:
+		return s.getGroupedCosts(ctx, groupedQuery{valueExpr: "IFNULL(project.id, '')"}, start, end)
+	case model.DimensionService:
+		return s.getGroupedCosts(ctx, groupedQuery{valueExpr: "IFNULL(service.description, '')", projectOnly: true}, start, end)
+	case model.DimensionSKU:
+		return s.getGroupedCosts(ctx, groupedQuery{valueExpr: "IFNULL(sku.description, '')", projectOnly: true}, start, end)
+	default:
+		return nil, fmt.Errorf("gcp cannot group costs by %s", dimension)
+	}
+}
+
+// exportTable returns the quoted name of the billing export table with prefix:
+// project.billing_export.<prefix><billing account ID>. Backslashes and
+// backticks are escaped so the IDs cannot end the identifier.
+func (s *service) exportTable(prefix string) string {
+	billingAccountID := strings.ReplaceAll(s.billingAccount, "billingAccounts/", "")
+	billingAccountID = strings.ReplaceAll(billingAccountID, "-", "_")
+
+	name := fmt.Sprintf("%s.billing_export.%s%s", s.projectID, prefix, billingAccountID)
+	return "`" + identifierEscaper.Replace(name) + "`"
+}
+
+// GetServiceCosts implements service.CostService
+// Breaks down one service's spend by day or by resource. Resource names are
+// only in the detailed usage cost export, which has to be enabled separately
+// from the standard export.
+func (s *service) GetServiceCosts(ctx context.Context, serviceName, breakdown string, start, end time.Time) (*model.CostInfo, error) {
+	query := groupedQuery{
+		filter:      "service.description = @service AND",
+		projectOnly: true,
+		params:      []bigquery.QueryParameter{{Name: "service", Value: serviceName}},
+	}
+
+	switch breakdown {
+	case model.BreakdownDaily:
+		query.valueExpr = "FORMAT_DATE('%Y-%m-%d', DATE(usage_start_time))"
+		return s.getGroupedCosts(ctx, query, start, end)
+	case model.BreakdownResource:
+		query.valueExpr = "IFNULL(resource.name, '')"
+		query.table = "gcp_billing_export_resource_v1_"
+		costs, err := s.getGroupedCosts(ctx, query, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("resource costs need the detailed usage cost export: %w", err)
+		}
+		return costs, nil
+	default:
+		return nil, fmt.Errorf("gcp cannot break down service costs by %s", breakdown)
+	}
+}
+
+// getGroupedCosts sums spend in [start, end) as described by query. Rows with
+// an empty value are reported under model.Untagged.
+func (s *service) getGroupedCosts(ctx context.Context, query groupedQuery, start, end time.Time) (*model.CostInfo, error) {
+	startDateStr := start.Format("2006-01-02")
+	endDateStr := end.Format("2006-01-02")
+
+	table := query.table
+	if table == "" {
+		table = "gcp_billing_export_v1_"
+	}
+
+	params := append([]bigquery.QueryParameter(nil), query.params...)
+	filter := query.filter
+	if query.projectOnly {
+		filter += " project.id = @projectID AND"
+		params = append(params, bigquery.QueryParameter{Name: "projectID", Value: s.projectID})
+	}
+
+	sql := fmt.Sprintf(`
+		SELECT
+			%s AS group_value,
+			SUM(cost) AS total_cost,
+			currency
+		FROM %s
+		WHERE
+			%s DATE(usage_start_time) >= @startDate
+			AND DATE(usage_start_time) < @endDate
+		GROUP BY group_value, currency
+		HAVING SUM(cost) > 0
+	`, query.valueExpr, s.exportTable(table), filter)
+
+	q := s.bqClient.Query(sql)
+	q.Parameters = append(params,
+		bigquery.QueryParameter{Name: "startDate", Value: startDateStr},
+		bigquery.QueryParameter{Name: "endDate", Value: endDateStr},
+	)
+
+	it, err := q.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute BigQuery query: %w", err)
+	}
+
+	costGroups := make(model.CostGroup)
+
+	for {
+		var row struct {
+			GroupValue string  `bigquery:"group_value"`
+			TotalCost  float64 `bigquery:"total_cost"`
+			Currency   string  `bigquery:"currency"`
+		}
+
+		err := it.Next(&row)
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read BigQuery row: %w", err)
+		}
+
+		value := row.GroupValue
+		if value == "" {
+			value = model.Untagged
+		}
+		costGroups[value] = struct {
+			Amount float64
+			Unit   string
+		}{
+			Amount: costGroups[value].Amount + row.TotalCost,
+			Unit:   row.Currency,
+		}
+	}
+
+	return &model.CostInfo{
+		DateInterval: model.DateInterval{
+			Start: &startDateStr,
+			End:   &endDateStr,
+		},
+		CostGroup: costGroups,
+	}, nil
+}
+
 func (s *service) getFirstDayOfMonth(month time.Time) time.Time {
 	return time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
 }
@@ -0,0 +1,40 @@
+package gcpbilling
+
+import "testing"
+
+func TestExportTableIsQuoted(t *testing.T) {
+	tests := []struct {
+		name           string
+		projectID      string
+		billingAccount string
+		want           string
+	}{
+		{
+			name:           "billing account with prefix",
+			projectID:      "my-project",
+			billingAccount: "billingAccounts/012345-6789AB-CDEF01",
+			want:           "`my-project.billing_export.gcp_billing_export_v1_012345_6789AB_CDEF01`",
+		},
+		{
+			name:           "domain-scoped project",
+			projectID:      "example.com:my-project",
+			billingAccount: "012345-6789AB-CDEF01",
+			want:           "`example.com:my-project.billing_export.gcp_billing_export_v1_012345_6789AB_CDEF01`",
+		},
+		{
+			name:           "backtick and backslash are escaped",
+			projectID:      "p` WHERE 1=1 --",
+			billingAccount: `x\`,
+			want:           "`p\\` WHERE 1=1 --.billing_export.gcp_billing_export_v1_x\\\\`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &service{projectID: tt.projectID, billingAccount: tt.billingAccount}
+			if got := s.exportTable("gcp_billing_export_v1_"); got != tt.want {
+				t.Errorf("exportTable() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
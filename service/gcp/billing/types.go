@@ -2,6 +2,7 @@ package gcpbilling
 
 import (
 	"context"
+	"time"
 
 	"cloud.google.com/go/bigquery"
 	"github.com/elC0mpa/aws-doctor/model"
@@ -13,10 +14,26 @@ type service struct {
 	bqClient       *bigquery.Client
 }
 
+// groupedQuery describes how getGroupedCosts groups and filters the export
+type groupedQuery struct {
+	// valueExpr is the SQL expression rows are grouped by
+	valueExpr string
+	// table is the export table prefix, the standard export when empty
+	table string
+	// filter is an extra WHERE condition ending in AND
+	filter string
+	// projectOnly limits rows to the configured project
+	projectOnly bool
+	params      []bigquery.QueryParameter
+}
+
 type BillingService interface {
 	GetCurrentMonthCostsByService(ctx context.Context) (*model.CostInfo, error)
 	GetLastMonthCostsByService(ctx context.Context) (*model.CostInfo, error)
 	GetCurrentMonthTotalCosts(ctx context.Context) (*string, error)
 	GetLastMonthTotalCosts(ctx context.Context) (*string, error)
 	GetLastSixMonthsCosts(ctx context.Context) ([]model.CostInfo, error)
+	GetCostsByTag(ctx context.Context, tagKey string, start, end time.Time) (*model.CostInfo, error)
+	GetCostsByDimension(ctx context.Context, dimension string, start, end time.Time) (*model.CostInfo, error)
+	GetServiceCosts(ctx context.Context, serviceName, breakdown string, start, end time.Time) (*model.CostInfo, error)
 }
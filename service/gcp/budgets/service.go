@@ -0,0 +1,75 @@
+package gcpbudgets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/resilience"
+	"google.golang.org/api/billingbudgets/v1"
+	"google.golang.org/api/option"
+)
+
+func NewService(ctx context.Context, billingAccount string, policy resilience.Policy) (*service, error) {
+	opts, err := policy.GCPClientOptions(ctx, option.WithScopes(billingbudgets.CloudBillingScope))
+	if err != nil {
+		return nil, err
+	}
+
+	budgetsClient, err := billingbudgets.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Billing Budgets client: %w", err)
+	}
+
+	return &service{
+		billingAccount: strings.TrimPrefix(billingAccount, "billingAccounts/"),
+		budgetsClient:  budgetsClient,
+	}, nil
+}
+
+// GetBudgets implements service.BudgetService
+// The Budget API does not report spend, so monthly budgets with a fixed
+// amount are returned with BudgetUnknown status for comparison only.
+func (s *service) GetBudgets(ctx context.Context) ([]model.BudgetStatus, error) {
+	var statuses []model.BudgetStatus
+
+	call := s.budgetsClient.BillingAccounts.Budgets.List("billingAccounts/" + s.billingAccount)
+	err := call.Pages(ctx, func(page *billingbudgets.GoogleCloudBillingBudgetsV1ListBudgetsResponse) error {
+		for _, b := range page.Budgets {
+			if b.Amount == nil || b.Amount.SpecifiedAmount == nil {
+				continue
+			}
+
+			scope := "billing account"
+			if b.BudgetFilter != nil {
+				if b.BudgetFilter.CalendarPeriod != "" && b.BudgetFilter.CalendarPeriod != "MONTH" {
+					continue
+				}
+				if b.BudgetFilter.CustomPeriod != nil {
+					continue
+				}
+				if len(b.BudgetFilter.Projects) > 0 {
+					scope = strings.Join(b.BudgetFilter.Projects, ", ")
+				}
+			}
+
+			money := b.Amount.SpecifiedAmount
+			statuses = append(statuses, model.BudgetStatus{
+				Name:     b.DisplayName,
+				Provider: "gcp",
+				Scope:    scope,
+				Imported: true,
+				Amount:   float64(money.Units) + float64(money.Nanos)/1e9,
+				Unit:     money.CurrencyCode,
+				Status:   model.BudgetUnknown,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return statuses, nil
+}
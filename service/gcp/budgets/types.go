@@ -0,0 +1,17 @@
+package gcpbudgets
+
+import (
+	"context"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"google.golang.org/api/billingbudgets/v1"
+)
+
+type service struct {
+	billingAccount string
+	budgetsClient  *billingbudgets.Service
+}
+
+type BudgetsService interface {
+	GetBudgets(ctx context.Context) ([]model.BudgetStatus, error)
+}
@@ -0,0 +1,268 @@
+package gcpcompute
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/elC0mpa/aws-doctor/service/apierror"
+	"github.com/elC0mpa/aws-doctor/service/warnings"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/api/compute/v1"
+)
+
+// maxConcurrentLocations bounds the per-zone and per-region fallback so a
+// project with many locations does not queue hundreds of calls at once
+const maxConcurrentLocations = 8
+
+// terminatedFilter selects stopped VMs, which GCP reports as TERMINATED
+const terminatedFilter = "status = TERMINATED"
+
+// noResultsOnPage is the scoped list warning for a location with no resources
+const noResultsOnPage = "NO_RESULTS_ON_PAGE"
+
+func (s *service) aggregatedDisks(ctx context.Context) ([]*compute.Disk, error) {
+	var disks []*compute.Disk
+	err := s.computeClient.DiskAggregatedPages(ctx, s.projectID, func(page *compute.DiskAggregatedList) error {
+		reportUnreachable(ctx, page.Unreachables)
+		for scope, list := range page.Items {
+			if list.Warning != nil {
+				reportScopeWarning(ctx, scope, list.Warning.Code, list.Warning.Message)
+			}
+			disks = append(disks, list.Disks...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disks: %w", err)
+	}
+	return disks, nil
+}
+
+func (s *service) aggregatedTerminatedVMs(ctx context.Context) ([]*compute.Instance, error) {
+	var instances []*compute.Instance
+	err := s.computeClient.InstanceAggregatedPages(ctx, s.projectID, terminatedFilter, func(page *compute.InstanceAggregatedList) error {
+		reportUnreachable(ctx, page.Unreachables)
+		for scope, list := range page.Items {
+			if list.Warning != nil {
+				reportScopeWarning(ctx, scope, list.Warning.Code, list.Warning.Message)
+			}
+			instances = append(instances, list.Instances...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instances: %w", err)
+	}
+	return instances, nil
+}
+
+func (s *service) aggregatedAddresses(ctx context.Context) ([]*compute.Address, error) {
+	var addresses []*compute.Address
+	err := s.computeClient.AddressAggregatedPages(ctx, s.projectID, func(page *compute.AddressAggregatedList) error {
+		reportUnreachable(ctx, page.Unreachables)
+		for scope, list := range page.Items {
+			if list.Warning != nil {
+				reportScopeWarning(ctx, scope, list.Warning.Code, list.Warning.Message)
+			}
+			addresses = append(addresses, list.Addresses...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses: %w", err)
+	}
+	return addresses, nil
+}
+
+func (s *service) aggregatedCommitments(ctx context.Context) ([]*compute.Commitment, error) {
+	var commitments []*compute.Commitment
+	err := s.computeClient.CommitmentAggregatedPages(ctx, s.projectID, func(page *compute.CommitmentAggregatedList) error {
+		reportUnreachable(ctx, page.Unreachables)
+		for scope, list := range page.Items {
+			if list.Warning != nil {
+				reportScopeWarning(ctx, scope, list.Warning.Code, list.Warning.Message)
+			}
+			commitments = append(commitments, list.Commitments...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commitments: %w", err)
+	}
+	return commitments, nil
+}
+
+func (s *service) disksByZone(ctx context.Context) ([]*compute.Disk, error) {
+	zones, err := s.listZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return listByLocation(ctx, "zone", "disks", zones, func(ctx context.Context, zone string) ([]*compute.Disk, error) {
+		var disks []*compute.Disk
+		err := s.computeClient.DiskPages(ctx, s.projectID, zone, func(page *compute.DiskList) error {
+			disks = append(disks, page.Items...)
+			return nil
+		})
+		return disks, err
+	})
+}
+
+func (s *service) terminatedVMsByZone(ctx context.Context) ([]*compute.Instance, error) {
+	zones, err := s.listZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return listByLocation(ctx, "zone", "instances", zones, func(ctx context.Context, zone string) ([]*compute.Instance, error) {
+		var instances []*compute.Instance
+		err := s.computeClient.InstancePages(ctx, s.projectID, zone, terminatedFilter, func(page *compute.InstanceList) error {
+			instances = append(instances, page.Items...)
+			return nil
+		})
+		return instances, err
+	})
+}
+
+func (s *service) addressesByRegion(ctx context.Context) ([]*compute.Address, error) {
+	var addresses []*compute.Address
+	err := s.computeClient.GlobalAddressPages(ctx, s.projectID, func(page *compute.AddressList) error {
+		addresses = append(addresses, page.Items...)
+		return nil
+	})
+	if err != nil {
+		addresses = nil
+		warnings.Add(ctx, apierror.NewWarning("gcp", "global addresses", err))
+	}
+
+	regions, err := s.listRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	regional, err := listByLocation(ctx, "region", "addresses", regions, func(ctx context.Context, region string) ([]*compute.Address, error) {
+		var addresses []*compute.Address
+		err := s.computeClient.AddressPages(ctx, s.projectID, region, func(page *compute.AddressList) error {
+			addresses = append(addresses, page.Items...)
+			return nil
+		})
+		return addresses, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(addresses, regional...), nil
+}
+
+func (s *service) commitmentsByRegion(ctx context.Context) ([]*compute.Commitment, error) {
+	regions, err := s.listRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return listByLocation(ctx, "region", "commitments", regions, func(ctx context.Context, region string) ([]*compute.Commitment, error) {
+		var commitments []*compute.Commitment
+		err := s.computeClient.CommitmentPages(ctx, s.projectID, region, func(page *compute.CommitmentList) error {
+			commitments = append(commitments, page.Items...)
+			return nil
+		})
+		return commitments, err
+	})
+}
+
+// listByLocation runs list for every location with bounded concurrency. Failed
+// locations are reported as warnings; an error is only returned when every
+// location failed.
+func listByLocation[T any](ctx context.Context, kind, resource string, locations []string, list func(ctx context.Context, location string) ([]T, error)) ([]T, error) {
+	var (
+		mu      sync.Mutex
+		items   []T
+		failed  int
+		lastErr error
+	)
+
+	var group errgroup.Group
+	group.SetLimit(maxConcurrentLocations)
+	for _, location := range locations {
+		group.Go(func() error {
+			found, err := list(ctx, location)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed++
+				lastErr = err
+				warnings.Add(ctx, apierror.NewWarning("gcp", kind+" "+location, err))
+				return nil
+			}
+			items = append(items, found...)
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	if failed > 0 && failed == len(locations) {
+		return nil, fmt.Errorf("failed to list %s in every %s: %w", resource, kind, lastErr)
+	}
+
+	return items, nil
+}
+
+// listZones returns the name of every zone in the project
+func (s *service) listZones(ctx context.Context) ([]string, error) {
+	var zones []string
+	err := s.computeClient.ZonePages(ctx, s.projectID, func(page *compute.ZoneList) error {
+		for _, zone := range page.Items {
+			zones = append(zones, zone.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list zones: %w", err)
+	}
+	return zones, nil
+}
+
+// listRegions returns the name of every region in the project
+func (s *service) listRegions(ctx context.Context) ([]string, error) {
+	var regions []string
+	err := s.computeClient.RegionPages(ctx, s.projectID, func(page *compute.RegionList) error {
+		for _, region := range page.Items {
+			regions = append(regions, region.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list regions: %w", err)
+	}
+	return regions, nil
+}
+
+// reportUnreachable records locations the aggregated list could not reach
+func reportUnreachable(ctx context.Context, unreachables []string) {
+	for _, scope := range unreachables {
+		warnings.Add(ctx, apierror.NewWarning("gcp", scopeLabel(scope), fmt.Errorf("location unreachable")))
+	}
+}
+
+// reportScopeWarning records a per-location warning from an aggregated list,
+// ignoring the warning GCP attaches to every location without resources
+func reportScopeWarning(ctx context.Context, scope, code, message string) {
+	if code == noResultsOnPage {
+		return
+	}
+	warnings.Add(ctx, apierror.NewWarning("gcp", scopeLabel(scope), fmt.Errorf("%s: %s", code, message)))
+}
+
+// scopeLabel turns an aggregated list key such as "zones/us-central1-a" into
+// "zone us-central1-a"
+func scopeLabel(scope string) string {
+	kind, name, ok := strings.Cut(scope, "/")
+	if !ok {
+		return scope
+	}
+	return strings.TrimSuffix(kind, "s") + " " + name
+}
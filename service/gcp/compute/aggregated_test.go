@@ -0,0 +1,167 @@
+package gcpcompute
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"google.golang.org/api/compute/v1"
+)
+
+// fakeCompute serves lists in pages, the way Pages hands them to its callback.
+// Listing stops with errPage at failPage.
+type fakeCompute struct {
+	computeAPI
+
+	aggregatedDisks []*compute.DiskAggregatedList
+	aggregatedErr   error
+	zones           []*compute.ZoneList
+	zoneDisks       map[string][]*compute.DiskList
+	failPage        int
+}
+
+var errPage = errors.New("backend error")
+
+func pages[T any](list []T, failPage int, fn func(T) error) error {
+	for i, page := range list {
+		if i > 0 && i == failPage {
+			return errPage
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeCompute) DiskAggregatedPages(ctx context.Context, projectID string, fn func(*compute.DiskAggregatedList) error) error {
+	if f.aggregatedErr != nil {
+		return f.aggregatedErr
+	}
+	return pages(f.aggregatedDisks, f.failPage, fn)
+}
+
+func (f *fakeCompute) ZonePages(ctx context.Context, projectID string, fn func(*compute.ZoneList) error) error {
+	return pages(f.zones, 0, fn)
+}
+
+func (f *fakeCompute) DiskPages(ctx context.Context, projectID, zone string, fn func(*compute.DiskList) error) error {
+	return pages(f.zoneDisks[zone], f.failPage, fn)
+}
+
+// disks returns unattached disks with the given names
+func disks(names ...string) []*compute.Disk {
+	result := make([]*compute.Disk, 0, len(names))
+	for _, name := range names {
+		result = append(result, &compute.Disk{Name: name, Status: "READY"})
+	}
+	return result
+}
+
+func diskNames(disks []*compute.Disk) []string {
+	names := make([]string, 0, len(disks))
+	for _, disk := range disks {
+		names = append(names, disk.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestGetUnattachedDisksAggregatesEveryPage(t *testing.T) {
+	client := &fakeCompute{
+		aggregatedDisks: []*compute.DiskAggregatedList{
+			{Items: map[string]compute.DisksScopedList{
+				"zones/us-central1-a": {Disks: disks("disk-1", "disk-2")},
+				"zones/us-east1-b":    {Disks: disks("disk-3")},
+			}},
+			{Items: map[string]compute.DisksScopedList{
+				"zones/us-central1-a": {Disks: disks("disk-4")},
+			}},
+			{Items: map[string]compute.DisksScopedList{
+				"zones/europe-west1-b": {Disks: disks("disk-5")},
+			}},
+		},
+	}
+	s := &service{projectID: "project", computeClient: client}
+
+	got, err := s.GetUnattachedDisks(context.Background())
+	if err != nil {
+		t.Fatalf("GetUnattachedDisks() error = %v", err)
+	}
+
+	want := []string{"disk-1", "disk-2", "disk-3", "disk-4", "disk-5"}
+	if names := diskNames(got); !slices.Equal(names, want) {
+		t.Errorf("GetUnattachedDisks() = %v, want %v", names, want)
+	}
+}
+
+func TestAggregatedDisksReturnsLaterPageError(t *testing.T) {
+	client := &fakeCompute{
+		aggregatedDisks: []*compute.DiskAggregatedList{
+			{Items: map[string]compute.DisksScopedList{"zones/us-central1-a": {Disks: disks("disk-1")}}},
+			{Items: map[string]compute.DisksScopedList{"zones/us-central1-a": {Disks: disks("disk-2")}}},
+		},
+		failPage: 1,
+	}
+	s := &service{projectID: "project", computeClient: client}
+
+	if _, err := s.aggregatedDisks(context.Background()); !errors.Is(err, errPage) {
+		t.Fatalf("aggregatedDisks() error = %v, want %v", err, errPage)
+	}
+}
+
+func TestDisksByZoneAggregatesEveryPage(t *testing.T) {
+	tests := []struct {
+		name      string
+		zoneDisks map[string][]*compute.DiskList
+		failPage  int
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name: "pages of every zone",
+			zoneDisks: map[string][]*compute.DiskList{
+				"us-central1-a": {{Items: disks("disk-1")}, {Items: disks("disk-2", "disk-3")}},
+				"us-east1-b":    {{Items: disks("disk-4")}, {Items: disks("disk-5")}},
+			},
+			want: []string{"disk-1", "disk-2", "disk-3", "disk-4", "disk-5"},
+		},
+		{
+			name: "error on a later page of every zone",
+			zoneDisks: map[string][]*compute.DiskList{
+				"us-central1-a": {{Items: disks("disk-1")}, {Items: disks("disk-2")}},
+				"us-east1-b":    {{Items: disks("disk-3")}, {Items: disks("disk-4")}},
+			},
+			failPage: 1,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeCompute{
+				aggregatedErr: errors.New("aggregated list unavailable"),
+				zones:         []*compute.ZoneList{{Items: []*compute.Zone{{Name: "us-central1-a"}}}, {Items: []*compute.Zone{{Name: "us-east1-b"}}}},
+				zoneDisks:     tt.zoneDisks,
+				failPage:      tt.failPage,
+			}
+			s := &service{projectID: "project", computeClient: client}
+
+			got, err := s.GetUnattachedDisks(context.Background())
+			if tt.wantErr {
+				if !errors.Is(err, errPage) {
+					t.Fatalf("GetUnattachedDisks() error = %v, want %v", err, errPage)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetUnattachedDisks() error = %v", err)
+			}
+
+			if names := diskNames(got); !slices.Equal(names, tt.want) {
+				t.Errorf("GetUnattachedDisks() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package gcpcompute
+
+import (
+	"context"
+
+	"google.golang.org/api/compute/v1"
+)
+
+// computeClient lists resources through the generated Compute Engine client,
+// whose Pages follows nextPageToken
+type computeClient struct {
+	compute *compute.Service
+}
+
+func (c computeClient) DiskAggregatedPages(ctx context.Context, projectID string, fn func(*compute.DiskAggregatedList) error) error {
+	return c.compute.Disks.AggregatedList(projectID).ReturnPartialSuccess(true).Pages(ctx, fn)
+}
+
+func (c computeClient) InstanceAggregatedPages(ctx context.Context, projectID, filter string, fn func(*compute.InstanceAggregatedList) error) error {
+	return c.compute.Instances.AggregatedList(projectID).Filter(filter).ReturnPartialSuccess(true).Pages(ctx, fn)
+}
+
+func (c computeClient) AddressAggregatedPages(ctx context.Context, projectID string, fn func(*compute.AddressAggregatedList) error) error {
+	return c.compute.Addresses.AggregatedList(projectID).ReturnPartialSuccess(true).Pages(ctx, fn)
+}
+
+func (c computeClient) CommitmentAggregatedPages(ctx context.Context, projectID string, fn func(*compute.CommitmentAggregatedList) error) error {
+	return c.compute.RegionCommitments.AggregatedList(projectID).ReturnPartialSuccess(true).Pages(ctx, fn)
+}
+
+func (c computeClient) DiskPages(ctx context.Context, projectID, zone string, fn func(*compute.DiskList) error) error {
+	return c.compute.Disks.List(projectID, zone).Pages(ctx, fn)
+}
+
+func (c computeClient) InstancePages(ctx context.Context, projectID, zone, filter string, fn func(*compute.InstanceList) error) error {
+	return c.compute.Instances.List(projectID, zone).Filter(filter).Pages(ctx, fn)
+}
+
+func (c computeClient) GlobalAddressPages(ctx context.Context, projectID string, fn func(*compute.AddressList) error) error {
+	return c.compute.GlobalAddresses.List(projectID).Pages(ctx, fn)
+}
+
+func (c computeClient) AddressPages(ctx context.Context, projectID, region string, fn func(*compute.AddressList) error) error {
+	return c.compute.Addresses.List(projectID, region).Pages(ctx, fn)
+}
+
+func (c computeClient) CommitmentPages(ctx context.Context, projectID, region string, fn func(*compute.CommitmentList) error) error {
+	return c.compute.RegionCommitments.List(projectID, region).Pages(ctx, fn)
+}
+
+func (c computeClient) ZonePages(ctx context.Context, projectID string, fn func(*compute.ZoneList) error) error {
+	return c.compute.Zones.List(projectID).Pages(ctx, fn)
+}
+
+func (c computeClient) RegionPages(ctx context.Context, projectID string, fn func(*compute.RegionList) error) error {
+	return c.compute.Regions.List(projectID).Pages(ctx, fn)
+}
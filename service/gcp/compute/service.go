@@ -6,21 +6,33 @@ import (
 	"time"
 
 	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/apierror"
+	"github.com/elC0mpa/aws-doctor/service/ownership"
+	"github.com/elC0mpa/aws-doctor/service/resilience"
+	"github.com/elC0mpa/aws-doctor/service/warnings"
 	"google.golang.org/api/compute/v1"
 	"google.golang.org/api/option"
 )
 
-func NewService(ctx context.Context, projectID string) (*service, error) {
-	computeClient, err := compute.NewService(ctx, option.WithScopes(
+// NewService creates the Compute Engine waste checks. Owners are read from the
+// first of ownerTagKeys a resource is labelled with.
+func NewService(ctx context.Context, projectID string, policy resilience.Policy, ownerTagKeys []string) (*service, error) {
+	opts, err := policy.GCPClientOptions(ctx, option.WithScopes(
 		compute.ComputeReadonlyScope,
 	))
+	if err != nil {
+		return nil, err
+	}
+
+	computeService, err := compute.NewService(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Compute client: %w", err)
 	}
 
 	return &service{
 		projectID:     projectID,
-		computeClient: computeClient,
+		computeClient: computeClient{compute: computeService},
+		owners:        ownership.NewResolver(ownerTagKeys),
 	}, nil
 }
 
@@ -38,6 +50,9 @@ func (s *service) GetUnusedVolumes(ctx context.Context) ([]model.UnusedVolume, e
 			ID:     disk.Name,
 			SizeGB: int32(disk.SizeGb),
 			Status: "available",
+			Region: extractResourceName(disk.Zone),
+			Tags:   disk.Labels,
+			Owner:  s.owners.Resolve(disk.Labels),
 		})
 	}
 	return result, nil
@@ -45,27 +60,23 @@ func (s *service) GetUnusedVolumes(ctx context.Context) ([]model.UnusedVolume, e
 
 // GetUnattachedDisks returns all persistent disks that are not attached to any instance
 func (s *service) GetUnattachedDisks(ctx context.Context) ([]*compute.Disk, error) {
-	var unattachedDisks []*compute.Disk
-
-	// List all zones in the project
-	zonesResp, err := s.computeClient.Zones.List(s.projectID).Context(ctx).Do()
+	disks, err := s.aggregatedDisks(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list zones: %w", err)
-	}
-
-	// Check disks in each zone
-	for _, zone := range zonesResp.Items {
-		disksResp, err := s.computeClient.Disks.List(s.projectID, zone.Name).Context(ctx).Do()
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		// Fall back to querying zones individually
+		disks, err = s.disksByZone(ctx)
 		if err != nil {
-			// Skip zones with errors (might not have disks API enabled)
-			continue
+			return nil, err
 		}
+	}
 
-		for _, disk := range disksResp.Items {
-			// A disk is unattached if it has no users
-			if len(disk.Users) == 0 && disk.Status == "READY" {
-				unattachedDisks = append(unattachedDisks, disk)
-			}
+	var unattachedDisks []*compute.Disk
+	for _, disk := range disks {
+		// A disk is unattached if it has no users
+		if len(disk.Users) == 0 && disk.Status == "READY" {
+			unattachedDisks = append(unattachedDisks, disk)
 		}
 	}
 
@@ -93,12 +104,14 @@ func (s *service) GetStoppedInstances(ctx context.Context) ([]model.StoppedInsta
 			stoppedAt, err = time.Parse(time.RFC3339, instance.LastStopTimestamp)
 			if err != nil {
 				// If we can't parse the timestamp, skip this instance
+				warnings.Add(ctx, apierror.NewWarning("gcp", "instance "+instance.Name, fmt.Errorf("unparseable stop timestamp %q", instance.LastStopTimestamp)))
 				continue
 			}
 		} else {
 			// If no stop timestamp, use creation time as fallback
 			stoppedAt, err = time.Parse(time.RFC3339, instance.CreationTimestamp)
 			if err != nil {
+				warnings.Add(ctx, apierror.NewWarning("gcp", "instance "+instance.Name, fmt.Errorf("unparseable creation timestamp %q", instance.CreationTimestamp)))
 				continue
 			}
 		}
@@ -106,11 +119,18 @@ func (s *service) GetStoppedInstances(ctx context.Context) ([]model.StoppedInsta
 		// Only include instances stopped for more than 30 days
 		if stoppedAt.Before(thresholdTime) {
 			days := int(now.Sub(stoppedAt).Hours() / 24)
+			region := extractResourceName(instance.Zone)
+			owner := s.owners.Resolve(instance.Labels)
 
 			stoppedInstances = append(stoppedInstances, model.StoppedInstance{
-				ID:          instance.Name,
-				Name:        instance.Name,
-				StoppedDays: days,
+				ID:           instance.Name,
+				Name:         instance.Name,
+				StoppedDays:  days,
+				Region:       region,
+				InstanceType: extractResourceName(instance.MachineType),
+				LaunchTime:   launchTime(instance),
+				Tags:         instance.Labels,
+				Owner:        owner,
 			})
 
 			// Collect attached disks
@@ -122,6 +142,8 @@ func (s *service) GetStoppedInstances(ctx context.Context) ([]model.StoppedInsta
 						ID:     diskName,
 						SizeGB: int32(disk.DiskSizeGb),
 						Status: "attached_stopped",
+						Region: region,
+						Owner:  owner,
 					})
 				}
 			}
@@ -133,29 +155,16 @@ func (s *service) GetStoppedInstances(ctx context.Context) ([]model.StoppedInsta
 
 // GetTerminatedVMs returns all VMs in TERMINATED state
 func (s *service) GetTerminatedVMs(ctx context.Context) ([]*compute.Instance, error) {
-	var terminatedVMs []*compute.Instance
-
-	// List all zones in the project
-	zonesResp, err := s.computeClient.Zones.List(s.projectID).Context(ctx).Do()
+	instances, err := s.aggregatedTerminatedVMs(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list zones: %w", err)
-	}
-
-	// Check instances in each zone
-	for _, zone := range zonesResp.Items {
-		// Filter for TERMINATED instances
-		instancesResp, err := s.computeClient.Instances.List(s.projectID, zone.Name).
-			Filter("status = TERMINATED").
-			Context(ctx).Do()
-		if err != nil {
-			// Skip zones with errors
-			continue
+		if ctx.Err() != nil {
+			return nil, err
 		}
-
-		terminatedVMs = append(terminatedVMs, instancesResp.Items...)
+		// Fall back to querying zones individually
+		return s.terminatedVMsByZone(ctx)
 	}
 
-	return terminatedVMs, nil
+	return instances, nil
 }
 
 // GetUnusedIPs implements service.ResourceService
@@ -168,9 +177,17 @@ func (s *service) GetUnusedIPs(ctx context.Context) ([]model.UnusedIP, error) {
 
 	result := make([]model.UnusedIP, 0, len(addresses))
 	for _, addr := range addresses {
+		region := "global"
+		if addr.Region != "" {
+			region = extractResourceName(addr.Region)
+		}
+
 		result = append(result, model.UnusedIP{
 			Address:      addr.Address,
 			AllocationID: addr.Name,
+			Region:       region,
+			Tags:         addr.Labels,
+			Owner:        s.owners.Resolve(addr.Labels),
 		})
 	}
 	return result, nil
@@ -178,37 +195,24 @@ func (s *service) GetUnusedIPs(ctx context.Context) ([]model.UnusedIP, error) {
 
 // GetUnassignedExternalIPs returns all external IPs not assigned to any resource
 func (s *service) GetUnassignedExternalIPs(ctx context.Context) ([]*compute.Address, error) {
-	var unassignedIPs []*compute.Address
-
-	// Check global addresses
-	globalResp, err := s.computeClient.GlobalAddresses.List(s.projectID).Context(ctx).Do()
-	if err == nil {
-		for _, addr := range globalResp.Items {
-			// An address is unassigned if it has no users and status is RESERVED
-			if len(addr.Users) == 0 && addr.Status == "RESERVED" {
-				unassignedIPs = append(unassignedIPs, addr)
-			}
-		}
-	}
-
-	// Check regional addresses
-	regionsResp, err := s.computeClient.Regions.List(s.projectID).Context(ctx).Do()
+	// The aggregated list includes global addresses under the "global" scope
+	addresses, err := s.aggregatedAddresses(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list regions: %w", err)
-	}
-
-	for _, region := range regionsResp.Items {
-		addressesResp, err := s.computeClient.Addresses.List(s.projectID, region.Name).Context(ctx).Do()
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		// Fall back to querying global and regional addresses individually
+		addresses, err = s.addressesByRegion(ctx)
 		if err != nil {
-			// Skip regions with errors
-			continue
+			return nil, err
 		}
+	}
 
-		for _, addr := range addressesResp.Items {
-			// An address is unassigned if it has no users and status is RESERVED
-			if len(addr.Users) == 0 && addr.Status == "RESERVED" {
-				unassignedIPs = append(unassignedIPs, addr)
-			}
+	var unassignedIPs []*compute.Address
+	for _, addr := range addresses {
+		// An address is unassigned if it has no users and status is RESERVED
+		if len(addr.Users) == 0 && addr.Status == "RESERVED" {
+			unassignedIPs = append(unassignedIPs, addr)
 		}
 	}
 
@@ -233,6 +237,7 @@ func (s *service) GetExpiringReservations(ctx context.Context) ([]model.Reservat
 		// Parse end time
 		endTime, err := time.Parse(time.RFC3339, commitment.EndTimestamp)
 		if err != nil {
+			warnings.Add(ctx, apierror.NewWarning("gcp", "commitment "+commitment.Name, fmt.Errorf("unparseable end timestamp %q", commitment.EndTimestamp)))
 			continue
 		}
 
@@ -264,26 +269,30 @@ func (s *service) GetExpiringReservations(ctx context.Context) ([]model.Reservat
 
 // GetCommittedUseDiscounts returns all Committed Use Discounts in the project
 func (s *service) GetCommittedUseDiscounts(ctx context.Context) ([]*compute.Commitment, error) {
-	var allCommitments []*compute.Commitment
-
-	// List all regions
-	regionsResp, err := s.computeClient.Regions.List(s.projectID).Context(ctx).Do()
+	commitments, err := s.aggregatedCommitments(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list regions: %w", err)
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		// Fall back to querying regions individually
+		return s.commitmentsByRegion(ctx)
 	}
 
-	// Check commitments in each region
-	for _, region := range regionsResp.Items {
-		commitmentsResp, err := s.computeClient.RegionCommitments.List(s.projectID, region.Name).Context(ctx).Do()
-		if err != nil {
-			// Skip regions with errors (might not have commitments)
+	return commitments, nil
+}
+
+// launchTime returns when the instance was last started, or its creation time
+// if it has never been restarted
+func launchTime(instance *compute.Instance) *time.Time {
+	for _, timestamp := range []string{instance.LastStartTimestamp, instance.CreationTimestamp} {
+		if timestamp == "" {
 			continue
 		}
-
-		allCommitments = append(allCommitments, commitmentsResp.Items...)
+		if parsed, err := time.Parse(time.RFC3339, timestamp); err == nil {
+			return &parsed
+		}
 	}
-
-	return allCommitments, nil
+	return nil
 }
 
 // extractResourceName extracts the resource name from a GCP resource URL
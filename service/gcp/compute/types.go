@@ -4,12 +4,29 @@ import (
 	"context"
 
 	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/ownership"
 	"google.golang.org/api/compute/v1"
 )
 
 type service struct {
 	projectID     string
-	computeClient *compute.Service
+	computeClient computeAPI
+	owners        ownership.Resolver
+}
+
+// computeAPI lists Compute Engine resources, calling fn with every page
+type computeAPI interface {
+	DiskAggregatedPages(ctx context.Context, projectID string, fn func(*compute.DiskAggregatedList) error) error
+	InstanceAggregatedPages(ctx context.Context, projectID, filter string, fn func(*compute.InstanceAggregatedList) error) error
+	AddressAggregatedPages(ctx context.Context, projectID string, fn func(*compute.AddressAggregatedList) error) error
+	CommitmentAggregatedPages(ctx context.Context, projectID string, fn func(*compute.CommitmentAggregatedList) error) error
+	DiskPages(ctx context.Context, projectID, zone string, fn func(*compute.DiskList) error) error
+	InstancePages(ctx context.Context, projectID, zone, filter string, fn func(*compute.InstanceList) error) error
+	GlobalAddressPages(ctx context.Context, projectID string, fn func(*compute.AddressList) error) error
+	AddressPages(ctx context.Context, projectID, region string, fn func(*compute.AddressList) error) error
+	CommitmentPages(ctx context.Context, projectID, region string, fn func(*compute.CommitmentList) error) error
+	ZonePages(ctx context.Context, projectID string, fn func(*compute.ZoneList) error) error
+	RegionPages(ctx context.Context, projectID string, fn func(*compute.RegionList) error) error
 }
 
 type ComputeService interface {
@@ -0,0 +1,353 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/utils"
+)
+
+// snapshotIDLayout is used to derive snapshot IDs from their timestamps
+const snapshotIDLayout = "20060102T150405Z"
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// NewService creates a history store rooted at dir. An empty dir falls back to
+// the default location under the user cache directory.
+func NewService(dir string) (*service, error) {
+	if dir == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate user cache directory: %w", err)
+		}
+		dir = filepath.Join(cacheDir, "cloud-doctor", "history")
+	}
+
+	return &service{dir: dir}, nil
+}
+
+func (s *service) GetDir() string {
+	return s.dir
+}
+
+// Save appends a snapshot to the JSON-lines file for its provider and account
+func (s *service) Save(snapshot model.Snapshot) error {
+	if snapshot.ID == "" {
+		snapshot.ID = snapshot.Timestamp.UTC().Format(snapshotIDLayout)
+	}
+
+	path := s.filePath(snapshot.Provider, snapshot.AccountID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// List returns all snapshots of the given kind for a provider and account, oldest first
+func (s *service) List(provider, accountID, kind string) ([]model.Snapshot, error) {
+	f, err := os.Open(s.filePath(provider, accountID))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	var snapshots []model.Snapshot
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var snapshot model.Snapshot
+		if err := json.Unmarshal([]byte(line), &snapshot); err != nil {
+			// Skip lines truncated by an interrupted write
+			continue
+		}
+
+		if kind == "" || snapshot.Kind == kind {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Timestamp.Before(snapshots[j].Timestamp)
+	})
+
+	return snapshots, nil
+}
+
+// Find returns the baseline snapshot to diff against. An empty ref selects the most
+// recent snapshot taken before the given time; otherwise ref may be a snapshot ID or
+// a date (YYYY-MM-DD or RFC3339), selecting the latest snapshot at or before it.
+func (s *service) Find(provider, accountID, kind, ref string, before time.Time) (*model.Snapshot, error) {
+	snapshots, err := s.List(provider, accountID, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := before
+	if ref != "" {
+		for i := range snapshots {
+			if snapshots[i].ID == ref {
+				return &snapshots[i], nil
+			}
+		}
+
+		cutoff, err = parseRef(ref)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot %q not found and is not a valid date: %w", ref, err)
+		}
+	}
+
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if snapshots[i].Timestamp.Before(cutoff) || (ref != "" && snapshots[i].Timestamp.Equal(cutoff)) {
+			return &snapshots[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *service) filePath(provider, accountID string) string {
+	account := unsafePathChars.ReplaceAllString(accountID, "_")
+	if account == "" {
+		account = "default"
+	}
+	return filepath.Join(s.dir, unsafePathChars.ReplaceAllString(provider, "_"), account+".jsonl")
+}
+
+func parseRef(ref string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, ref); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", ref)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// A bare date includes every snapshot taken during that day
+	return t.Add(24*time.Hour - time.Nanosecond), nil
+}
+
+// NewCostSnapshot captures the month-to-date totals and service breakdown of a cost result
+func NewCostSnapshot(result model.ProviderCostResult, timestamp time.Time) model.Snapshot {
+	current, currency := utils.ParseTotal(result.CurrentTotalCost)
+	last, _ := utils.ParseTotal(result.LastTotalCost)
+
+	cost := &model.CostSnapshot{
+		Currency:     currency,
+		CurrentTotal: current,
+		LastTotal:    last,
+		Services:     make(map[string]float64),
+	}
+
+	if result.CurrentMonthData != nil {
+		if result.CurrentMonthData.Start != nil {
+			cost.PeriodStart = *result.CurrentMonthData.Start
+		}
+		if result.CurrentMonthData.End != nil {
+			cost.PeriodEnd = *result.CurrentMonthData.End
+		}
+		for name, group := range result.CurrentMonthData.CostGroup {
+			cost.Services[name] = group.Amount
+		}
+	}
+
+	return model.Snapshot{
+		ID:        timestamp.UTC().Format(snapshotIDLayout),
+		Timestamp: timestamp,
+		Provider:  result.Provider,
+		AccountID: result.AccountID,
+		Kind:      model.SnapshotKindCost,
+		Cost:      cost,
+	}
+}
+
+// NewWasteSnapshot captures every waste finding of a waste result
+func NewWasteSnapshot(result model.ProviderWasteResult, timestamp time.Time) model.Snapshot {
+	return model.Snapshot{
+		ID:        timestamp.UTC().Format(snapshotIDLayout),
+		Timestamp: timestamp,
+		Provider:  result.Provider,
+		AccountID: result.AccountID,
+		Kind:      model.SnapshotKindWaste,
+		Waste:     WasteFindings(result),
+	}
+}
+
+// WasteFindings flattens a waste result into individually trackable findings
+func WasteFindings(result model.ProviderWasteResult) []model.WasteFinding {
+	findings := make([]model.WasteFinding, 0)
+
+	for _, v := range result.UnusedVolumes {
+		findings = append(findings, model.WasteFinding{
+			Category: model.FindingUnusedVolume,
+			ID:       v.ID,
+			Detail:   fmt.Sprintf("%d GiB", v.SizeGB),
+		})
+	}
+
+	for _, v := range result.AttachedVolumes {
+		findings = append(findings, model.WasteFinding{
+			Category: model.FindingAttachedVolume,
+			ID:       v.ID,
+			Detail:   fmt.Sprintf("%d GiB", v.SizeGB),
+		})
+	}
+
+	for _, ip := range result.UnusedIPs {
+		id := ip.AllocationID
+		if id == "" {
+			id = ip.Address
+		}
+		findings = append(findings, model.WasteFinding{
+			Category: model.FindingUnusedIP,
+			ID:       id,
+			Detail:   ip.Address,
+		})
+	}
+
+	for _, inst := range result.StoppedInstances {
+		detail := inst.Name
+		if inst.StoppedDays >= 0 {
+			detail = fmt.Sprintf("%s (stopped %d days)", inst.Name, inst.StoppedDays)
+			if inst.StoppedAtEstimated {
+				detail = fmt.Sprintf("%s (stopped ~%d days)", inst.Name, inst.StoppedDays)
+			}
+		}
+		findings = append(findings, model.WasteFinding{
+			Category: model.FindingStoppedInstance,
+			ID:       inst.ID,
+			Detail:   detail,
+		})
+	}
+
+	for _, r := range result.ExpiringReservations {
+		findings = append(findings, model.WasteFinding{
+			Category: model.FindingReservation,
+			ID:       r.ID,
+			Detail:   fmt.Sprintf("%s (%s)", r.InstanceType, r.Status),
+		})
+	}
+
+	return findings
+}
+
+// Diff compares the current snapshot with a baseline. A nil baseline treats every
+// waste finding as new and every service cost as a change from zero.
+func Diff(baseline *model.Snapshot, current model.Snapshot) model.SnapshotDiff {
+	diff := model.SnapshotDiff{
+		Provider:  current.Provider,
+		AccountID: current.AccountID,
+		Kind:      current.Kind,
+		Baseline:  baseline,
+		Current:   &current,
+	}
+
+	switch current.Kind {
+	case model.SnapshotKindWaste:
+		previous := make(map[string]model.WasteFinding)
+		if baseline != nil {
+			for _, f := range baseline.Waste {
+				previous[findingKey(f)] = f
+			}
+		}
+
+		for _, f := range current.Waste {
+			key := findingKey(f)
+			if _, ok := previous[key]; ok {
+				diff.Persisting = append(diff.Persisting, f)
+				delete(previous, key)
+			} else {
+				diff.New = append(diff.New, f)
+			}
+		}
+
+		if baseline != nil {
+			for _, f := range baseline.Waste {
+				if _, ok := previous[findingKey(f)]; ok {
+					diff.Resolved = append(diff.Resolved, f)
+				}
+			}
+		}
+
+	case model.SnapshotKindCost:
+		services := make(map[string]*model.ServiceCostDelta)
+		if current.Cost != nil {
+			for name, amount := range current.Cost.Services {
+				services[name] = &model.ServiceCostDelta{Name: name, Current: amount}
+			}
+		}
+		if baseline != nil && baseline.Cost != nil {
+			for name, amount := range baseline.Cost.Services {
+				if delta, ok := services[name]; ok {
+					delta.Previous = amount
+				} else {
+					services[name] = &model.ServiceCostDelta{Name: name, Previous: amount}
+				}
+			}
+		}
+
+		for _, delta := range services {
+			diff.Services = append(diff.Services, *delta)
+		}
+
+		// Largest absolute movers first
+		sort.Slice(diff.Services, func(i, j int) bool {
+			di := diff.Services[i].Current - diff.Services[i].Previous
+			dj := diff.Services[j].Current - diff.Services[j].Previous
+			if di < 0 {
+				di = -di
+			}
+			if dj < 0 {
+				dj = -dj
+			}
+			if di == dj {
+				return diff.Services[i].Name < diff.Services[j].Name
+			}
+			return di > dj
+		})
+	}
+
+	return diff
+}
+
+func findingKey(f model.WasteFinding) string {
+	return f.Category + "/" + f.ID
+}
@@ -0,0 +1,18 @@
+package history
+
+import (
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+type service struct {
+	dir string
+}
+
+type HistoryService interface {
+	Save(snapshot model.Snapshot) error
+	List(provider, accountID, kind string) ([]model.Snapshot, error)
+	Find(provider, accountID, kind, ref string, before time.Time) (*model.Snapshot, error)
+	GetDir() string
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/elC0mpa/aws-doctor/model"
 )
@@ -18,6 +19,16 @@ type CostService interface {
 	GetCurrentMonthTotalCosts(ctx context.Context) (*string, error)
 	GetLastMonthTotalCosts(ctx context.Context) (*string, error)
 	GetLastSixMonthsCosts(ctx context.Context) ([]model.CostInfo, error)
+	// GetCostsByTag groups spend in [start, end) by the value of tagKey, with
+	// spend lacking the key under model.Untagged
+	GetCostsByTag(ctx context.Context, tagKey string, start, end time.Time) (*model.CostInfo, error)
+	// GetCostsByDimension groups spend in [start, end) by a provider-specific
+	// dimension such as model.DimensionResourceGroup, and errors when the
+	// provider has no such dimension
+	GetCostsByDimension(ctx context.Context, dimension string, start, end time.Time) (*model.CostInfo, error)
+	// GetServiceCosts breaks down one service's spend in [start, end) by
+	// model.BreakdownDaily or model.BreakdownResource
+	GetServiceCosts(ctx context.Context, serviceName, breakdown string, start, end time.Time) (*model.CostInfo, error)
 }
 
 // ResourceService provides compute/storage waste detection
@@ -27,3 +38,13 @@ type ResourceService interface {
 	GetStoppedInstances(ctx context.Context) ([]model.StoppedInstance, []model.UnusedVolume, error)
 	GetExpiringReservations(ctx context.Context) ([]model.Reservation, error)
 }
+
+// TagService lists resources with their tags or labels for compliance audits
+type TagService interface {
+	GetTaggedResources(ctx context.Context) ([]model.TaggedResource, error)
+}
+
+// BudgetService lists the budgets defined in the provider's own budget API
+type BudgetService interface {
+	GetBudgets(ctx context.Context) ([]model.BudgetStatus, error)
+}
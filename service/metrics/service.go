@@ -0,0 +1,272 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/budget"
+	"github.com/elC0mpa/aws-doctor/service/wastecost"
+	"github.com/elC0mpa/aws-doctor/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// reports are collected in this order on every refresh
+var reports = []string{model.RunModeCost, model.RunModeTrend, model.RunModeWaste}
+
+var (
+	costLabels    = []string{"provider", "account", "currency"}
+	serviceLabels = []string{"provider", "account", "service", "currency"}
+	budgetLabels  = []string{"provider", "budget", "scope", "currency"}
+	wasteLabels   = []string{"provider", "account", "category"}
+	reportLabels  = []string{"report"}
+
+	costMTD = prometheus.NewDesc("cloud_doctor_cost_month_to_date",
+		"Month-to-date cost of a provider account.", costLabels, nil)
+	costLastMTD = prometheus.NewDesc("cloud_doctor_cost_last_month_to_date",
+		"Cost of a provider account over the same period last month.", costLabels, nil)
+	costLastMonth = prometheus.NewDesc("cloud_doctor_cost_last_month",
+		"Cost of a provider account over the whole of last month.", costLabels, nil)
+	costForecast = prometheus.NewDesc("cloud_doctor_cost_forecast",
+		"Month-to-date cost projected linearly to the end of the month.", costLabels, nil)
+	serviceCostMTD = prometheus.NewDesc("cloud_doctor_service_cost_month_to_date",
+		"Month-to-date cost of a service.", serviceLabels, nil)
+	serviceCostLastMTD = prometheus.NewDesc("cloud_doctor_service_cost_last_month_to_date",
+		"Cost of a service over the same period last month.", serviceLabels, nil)
+	budgetAmount = prometheus.NewDesc("cloud_doctor_budget_amount",
+		"Monthly amount of a budget.", budgetLabels, nil)
+	budgetSpend = prometheus.NewDesc("cloud_doctor_budget_spend",
+		"Month-to-date spend covered by a budget.", budgetLabels, nil)
+	wasteFindings = prometheus.NewDesc("cloud_doctor_waste_findings",
+		"Number of waste findings per category.", wasteLabels, nil)
+	wasteCost = prometheus.NewDesc("cloud_doctor_waste_estimated_monthly_cost_usd",
+		"Estimated monthly cost in USD of unused volumes and IP addresses, from list prices.", wasteLabels, nil)
+	collectionErrors = prometheus.NewDesc("cloud_doctor_collection_errors_total",
+		"Failed collections per provider and report.", []string{"provider", "report"}, nil)
+	collectionSuccess = prometheus.NewDesc("cloud_doctor_collection_success",
+		"Whether the last collection of a report succeeded for every provider.", reportLabels, nil)
+	collectionTimestamp = prometheus.NewDesc("cloud_doctor_collection_timestamp_seconds",
+		"Unix time of the last collection of a report.", reportLabels, nil)
+	collectionDuration = prometheus.NewDesc("cloud_doctor_collection_duration_seconds",
+		"How long the last collection of a report took.", reportLabels, nil)
+)
+
+// NewService creates an exporter that refreshes its metrics every interval
+// using collect
+func NewService(cfg model.MetricsConfig, collect Collector) (*service, error) {
+	intervalStr := cfg.Interval
+	if intervalStr == "" {
+		intervalStr = model.DefaultMetricsInterval
+	}
+	interval, err := time.ParseDuration(intervalStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metrics interval %q: %w", intervalStr, err)
+	}
+	if interval < time.Minute {
+		return nil, fmt.Errorf("invalid metrics interval %q: must be at least 1m", intervalStr)
+	}
+
+	prices, err := wastecost.NewPrices(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &service{
+		interval:    interval,
+		prices:      prices,
+		collect:     collect,
+		registry:    prometheus.NewRegistry(),
+		samples:     make(map[string][]sample),
+		errors:      make(map[errorKey]float64),
+		succeeded:   make(map[string]bool),
+		lastRefresh: make(map[string]time.Time),
+		duration:    make(map[string]time.Duration),
+	}
+
+	if err := s.registry.Register(s); err != nil {
+		return nil, fmt.Errorf("failed to register metrics: %w", err)
+	}
+	return s, nil
+}
+
+func (s *service) Handler() http.Handler {
+	// Serve what can be gathered even if, say, two budgets share a name
+	return promhttp.HandlerFor(s.registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
+
+// Run refreshes the metrics right away and then every interval until ctx is cancelled
+func (s *service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.Refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh collects every report once and replaces its metrics
+func (s *service) Refresh(ctx context.Context) {
+	for _, report := range reports {
+		if ctx.Err() != nil {
+			return
+		}
+
+		started := time.Now()
+		result, err := s.collect(ctx, report)
+		elapsed := time.Since(started)
+
+		var samples []sample
+		failed := map[string]error{}
+		if err != nil {
+			failed[""] = err
+		} else {
+			samples, failed = s.samplesFor(result, started)
+		}
+		for provider, err := range failed {
+			log.Printf("metrics: %s collection failed: %v", strings.TrimSpace(provider+" "+report), err)
+		}
+
+		s.mu.Lock()
+		s.samples[report] = samples
+		for provider := range failed {
+			s.errors[errorKey{provider: provider, report: report}]++
+		}
+		s.succeeded[report] = len(failed) == 0
+		s.lastRefresh[report] = started
+		s.duration[report] = elapsed
+		s.mu.Unlock()
+	}
+}
+
+// samplesFor turns a report into metric values and returns the providers that failed
+func (s *service) samplesFor(result *model.RunResult, now time.Time) ([]sample, map[string]error) {
+	var samples []sample
+	failed := make(map[string]error)
+	add := func(desc *prometheus.Desc, value float64, labels ...string) {
+		samples = append(samples, sample{desc: desc, value: value, labels: labels})
+	}
+
+	for _, cost := range result.Costs {
+		if cost.Error != nil {
+			failed[cost.Provider] = cost.Error
+			continue
+		}
+
+		if result.Mode == model.RunModeTrend {
+			lastMonth := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, now.Location()).Format("2006-01")
+			for _, month := range cost.TrendData {
+				if month.Start == nil || !strings.HasPrefix(*month.Start, lastMonth) {
+					continue
+				}
+				var total float64
+				var unit string
+				for _, group := range month.CostGroup {
+					total += group.Amount
+					unit = group.Unit
+				}
+				add(costLastMonth, total, cost.Provider, cost.AccountID, unit)
+			}
+			continue
+		}
+
+		current, unit := utils.ParseTotal(cost.CurrentTotalCost)
+		last, _ := utils.ParseTotal(cost.LastTotalCost)
+		add(costMTD, current, cost.Provider, cost.AccountID, unit)
+		add(costLastMTD, last, cost.Provider, cost.AccountID, unit)
+		add(costForecast, budget.Forecast(current, now), cost.Provider, cost.AccountID, unit)
+
+		if cost.CurrentMonthData != nil {
+			for name, group := range cost.CurrentMonthData.CostGroup {
+				add(serviceCostMTD, group.Amount, cost.Provider, cost.AccountID, name, group.Unit)
+			}
+		}
+		if cost.LastMonthData != nil {
+			for name, group := range cost.LastMonthData.CostGroup {
+				add(serviceCostLastMTD, group.Amount, cost.Provider, cost.AccountID, name, group.Unit)
+			}
+		}
+
+		for _, b := range cost.Budgets {
+			add(budgetAmount, b.Amount, cost.Provider, b.Name, b.Scope, b.Unit)
+			if b.Status != model.BudgetUnknown || b.Imported {
+				add(budgetSpend, b.Actual, cost.Provider, b.Name, b.Scope, b.Unit)
+			}
+		}
+	}
+
+	for _, waste := range result.Waste {
+		if waste.Error != nil {
+			failed[waste.Provider] = waste.Error
+			continue
+		}
+
+		costs := s.prices.ByCategory(waste)
+		counts := []struct {
+			category string
+			count    int
+		}{
+			{model.FindingUnusedVolume, len(waste.UnusedVolumes)},
+			{model.FindingAttachedVolume, len(waste.AttachedVolumes)},
+			{model.FindingUnusedIP, len(waste.UnusedIPs)},
+			{model.FindingStoppedInstance, len(waste.StoppedInstances)},
+			{model.FindingReservation, len(waste.ExpiringReservations)},
+		}
+		for _, c := range counts {
+			add(wasteFindings, float64(c.count), waste.Provider, waste.AccountID, c.category)
+			// Stopped instances and reservations have no estimate
+			if cost, ok := costs[c.category]; ok {
+				add(wasteCost, cost, waste.Provider, waste.AccountID, c.category)
+			}
+		}
+	}
+
+	return samples, failed
+}
+
+// Describe implements prometheus.Collector
+func (s *service) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		costMTD, costLastMTD, costLastMonth, costForecast, serviceCostMTD, serviceCostLastMTD,
+		budgetAmount, budgetSpend, wasteFindings, wasteCost,
+		collectionErrors, collectionSuccess, collectionTimestamp, collectionDuration,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector with the values of the last refresh
+func (s *service) Collect(ch chan<- prometheus.Metric) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, samples := range s.samples {
+		for _, smp := range samples {
+			ch <- prometheus.MustNewConstMetric(smp.desc, prometheus.GaugeValue, smp.value, smp.labels...)
+		}
+	}
+
+	for key, count := range s.errors {
+		ch <- prometheus.MustNewConstMetric(collectionErrors, prometheus.CounterValue, count, key.provider, key.report)
+	}
+
+	for report, at := range s.lastRefresh {
+		success := 0.0
+		if s.succeeded[report] {
+			success = 1
+		}
+		ch <- prometheus.MustNewConstMetric(collectionSuccess, prometheus.GaugeValue, success, report)
+		ch <- prometheus.MustNewConstMetric(collectionTimestamp, prometheus.GaugeValue, float64(at.Unix()), report)
+		ch <- prometheus.MustNewConstMetric(collectionDuration, prometheus.GaugeValue, s.duration[report].Seconds(), report)
+	}
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/wastecost"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector gathers one report for the exporter: model.RunModeCost,
+// model.RunModeTrend or model.RunModeWaste
+type Collector func(ctx context.Context, mode string) (*model.RunResult, error)
+
+type service struct {
+	interval time.Duration
+	prices   wastecost.Prices
+	collect  Collector
+	registry *prometheus.Registry
+
+	mu sync.RWMutex
+	// samples holds the values of the last refresh of each report, replaced
+	// as a whole so a scrape never sees a half-updated report
+	samples     map[string][]sample
+	errors      map[errorKey]float64
+	succeeded   map[string]bool
+	lastRefresh map[string]time.Time
+	duration    map[string]time.Duration
+}
+
+type sample struct {
+	desc   *prometheus.Desc
+	value  float64
+	labels []string
+}
+
+type errorKey struct {
+	provider string
+	report   string
+}
+
+type MetricsService interface {
+	Handler() http.Handler
+	Refresh(ctx context.Context)
+	Run(ctx context.Context) error
+}
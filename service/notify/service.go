@@ -0,0 +1,332 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/utils"
+)
+
+// sendTimeout bounds each request to a notification endpoint
+const sendTimeout = 30 * time.Second
+
+// defaultTemplate is the message text for Slack and Teams. Paragraphs
+// separated by a blank line become separate blocks.
+const defaultTemplate = `{{if .Costs}}{{bold "Cost comparison"}} (month to date vs same period last month)
+{{range .Costs}}{{bullet}}{{bold (upper .Provider)}}{{with .AccountID}} {{.}}{{end}}: {{if .Error}}failed: {{.Error}}{{else}}{{money .Current}} {{.Unit}} vs {{money .Last}} {{.Unit}} ({{pct .ChangePercent}}){{end}}
+{{end}}{{if .ReportingCurrency}}{{bullet}}{{bold "Total"}}: {{money .TotalCurrent}} {{.ReportingCurrency}} vs {{money .TotalLast}} {{.ReportingCurrency}}
+{{end}}{{end}}
+{{if .Anomalies}}{{bold "Anomalies"}}
+{{range .Anomalies}}{{bullet}}{{upper .Provider}} {{.Service}}: {{signed .Change}} {{.Unit}} ({{money .Last}} → {{money .Current}})
+{{end}}{{end}}
+{{if .TopMovers}}{{bold "Top movers"}}
+{{range .TopMovers}}{{bullet}}{{upper .Provider}} {{.Service}}: {{signed .Change}} {{.Unit}} ({{money .Last}} → {{money .Current}})
+{{end}}{{end}}
+{{if .Waste}}{{bold "Waste"}}
+{{range .Waste}}{{bullet}}{{bold (upper .Provider)}}{{with .AccountID}} {{.}}{{end}}: {{if .Error}}failed: {{.Error}}{{else}}{{.Total}} finding(s): {{.UnusedVolumes}} volumes, {{.UnusedIPs}} IPs, {{.StoppedInstances}} stopped instances, {{.ExpiringReservations}} expiring reservations{{end}}
+{{end}}{{end}}`
+
+// NewService validates the configured channels and parses their templates
+func NewService(cfg model.NotificationsConfig) (*service, error) {
+	s := &service{
+		topMovers: cfg.TopMovers,
+		client:    &http.Client{Timeout: sendTimeout},
+	}
+	if s.topMovers <= 0 {
+		s.topMovers = model.DefaultTopMovers
+	}
+
+	for i, channelCfg := range cfg.Channels {
+		if channelCfg.Name == "" {
+			channelCfg.Name = fmt.Sprintf("%s #%d", channelCfg.Type, i+1)
+		}
+
+		switch channelCfg.Type {
+		case model.ChannelSlack, model.ChannelTeams, model.ChannelWebhook:
+		default:
+			return nil, fmt.Errorf("notification channel %s: unknown type %q. Supported types: slack, teams, webhook", channelCfg.Name, channelCfg.Type)
+		}
+
+		endpoint, err := url.Parse(channelCfg.URL)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return nil, fmt.Errorf("notification channel %s: url must be an http or https URL", channelCfg.Name)
+		}
+
+		text := channelCfg.Template
+		if text == "" && channelCfg.Type != model.ChannelWebhook {
+			text = defaultTemplate
+		}
+
+		var tmpl *template.Template
+		if text != "" {
+			tmpl, err = template.New(channelCfg.Name).Funcs(templateFuncs(channelCfg.Type)).Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("notification channel %s: invalid template: %w", channelCfg.Name, err)
+			}
+		}
+
+		s.channels = append(s.channels, channel{config: channelCfg, template: tmpl})
+	}
+
+	return s, nil
+}
+
+// Notify sends the digest of a run to every channel
+func (s *service) Notify(ctx context.Context, result *model.RunResult) error {
+	return s.Send(ctx, BuildDigest(result, s.topMovers))
+}
+
+// Send delivers the digest to every channel. A failing channel does not stop
+// the others; all failures are returned together.
+func (s *service) Send(ctx context.Context, digest model.Digest) error {
+	if len(s.channels) == 0 {
+		return fmt.Errorf("no notification channels configured")
+	}
+
+	var errs []error
+	for _, ch := range s.channels {
+		if err := s.send(ctx, ch, digest); err != nil {
+			errs = append(errs, fmt.Errorf("notification channel %s: %w", ch.config.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *service) send(ctx context.Context, ch channel, digest model.Digest) error {
+	var body []byte
+	var err error
+
+	switch ch.config.Type {
+	case model.ChannelSlack:
+		text, err := render(ch.template, digest)
+		if err != nil {
+			return err
+		}
+		body, err = json.Marshal(slackPayload(digest, text))
+		if err != nil {
+			return err
+		}
+
+	case model.ChannelTeams:
+		text, err := render(ch.template, digest)
+		if err != nil {
+			return err
+		}
+		body, err = json.Marshal(teamsPayload(digest, text))
+		if err != nil {
+			return err
+		}
+
+	default:
+		if ch.template == nil {
+			body, err = json.Marshal(digest)
+		} else {
+			var text string
+			text, err = render(ch.template, digest)
+			body = []byte(text)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ch.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range ch.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// BuildDigest summarizes the cost comparison, top movers and waste of a run
+func BuildDigest(result *model.RunResult, topMovers int) model.Digest {
+	digest := model.Digest{}
+	if result == nil {
+		return digest
+	}
+
+	digest.Mode = result.Mode
+	digest.Timestamp = result.Timestamp
+	digest.Title = fmt.Sprintf("Cloud Doctor %s report, %s", result.Mode, result.Timestamp.Format("2006-01-02"))
+
+	if result.Mode == model.RunModeCost {
+		addCosts(&digest, result.Costs, topMovers)
+	}
+
+	for _, waste := range result.Waste {
+		entry := model.DigestWaste{Provider: waste.Provider, AccountID: waste.AccountID}
+		if waste.Error != nil {
+			entry.Error = waste.Error.Error()
+		} else {
+			entry.UnusedVolumes = len(waste.UnusedVolumes) + len(waste.AttachedVolumes)
+			entry.UnusedIPs = len(waste.UnusedIPs)
+			entry.StoppedInstances = len(waste.StoppedInstances)
+			entry.ExpiringReservations = len(waste.ExpiringReservations)
+			entry.Total = entry.UnusedVolumes + entry.UnusedIPs + entry.StoppedInstances + entry.ExpiringReservations
+		}
+		digest.Waste = append(digest.Waste, entry)
+	}
+
+	return digest
+}
+
+// addCosts fills in the per-provider comparison, the totals in the reporting
+// currency when every provider could be converted, and the top movers
+func addCosts(digest *model.Digest, results []model.ProviderCostResult, topMovers int) {
+	converted := len(results) > 1
+	var movers []model.CostMover
+
+	for _, result := range results {
+		entry := model.DigestCost{Provider: result.Provider, AccountID: result.AccountID}
+		if result.Error != nil {
+			entry.Error = result.Error.Error()
+			digest.Costs = append(digest.Costs, entry)
+			continue
+		}
+
+		entry.Current, entry.Unit = utils.ParseTotal(result.CurrentTotalCost)
+		entry.Last, _ = utils.ParseTotal(result.LastTotalCost)
+		if entry.Last > 0 {
+			entry.ChangePercent = (entry.Current - entry.Last) / entry.Last * 100
+		}
+		digest.Costs = append(digest.Costs, entry)
+
+		if result.Converted == nil {
+			converted = false
+		} else {
+			digest.ReportingCurrency = result.Converted.Currency
+			digest.TotalCurrent += result.Converted.CurrentTotalCost
+			digest.TotalLast += result.Converted.LastTotalCost
+		}
+
+		movers = append(movers, serviceMovers(result)...)
+		digest.Anomalies = append(digest.Anomalies, result.Anomalies...)
+	}
+
+	if !converted {
+		digest.ReportingCurrency = ""
+		digest.TotalCurrent = 0
+		digest.TotalLast = 0
+	}
+
+	// Largest absolute change first
+	sort.Slice(movers, func(i, j int) bool {
+		ci, cj := math.Abs(movers[i].Change), math.Abs(movers[j].Change)
+		if ci == cj {
+			return movers[i].Service < movers[j].Service
+		}
+		return ci > cj
+	})
+	if len(movers) > topMovers {
+		movers = movers[:topMovers]
+	}
+	digest.TopMovers = movers
+}
+
+// serviceMovers compares each service's cost in the two periods of a result
+func serviceMovers(result model.ProviderCostResult) []model.CostMover {
+	movers := make(map[string]*model.CostMover)
+	mover := func(name, unit string) *model.CostMover {
+		m, ok := movers[name]
+		if !ok {
+			m = &model.CostMover{Provider: result.Provider, Service: name}
+			movers[name] = m
+		}
+		if unit != "" {
+			m.Unit = unit
+		}
+		return m
+	}
+
+	if result.CurrentMonthData != nil {
+		for name, cost := range result.CurrentMonthData.CostGroup {
+			mover(name, cost.Unit).Current = cost.Amount
+		}
+	}
+	if result.LastMonthData != nil {
+		for name, cost := range result.LastMonthData.CostGroup {
+			mover(name, cost.Unit).Last = cost.Amount
+		}
+	}
+
+	list := make([]model.CostMover, 0, len(movers))
+	for _, m := range movers {
+		m.Change = m.Current - m.Last
+		if m.Change != 0 {
+			list = append(list, *m)
+		}
+	}
+	return list
+}
+
+// templateFuncs returns the helpers available to templates. bold and bullet
+// produce the markup of the channel: Slack mrkdwn or Teams markdown.
+func templateFuncs(channelType string) template.FuncMap {
+	bold := func(s string) string { return s }
+	bullet := "• "
+	switch channelType {
+	case model.ChannelSlack:
+		bold = func(s string) string { return "*" + s + "*" }
+	case model.ChannelTeams:
+		bold = func(s string) string { return "**" + s + "**" }
+		bullet = "- "
+	}
+
+	return template.FuncMap{
+		"bold":   bold,
+		"bullet": func() string { return bullet },
+		"upper":  strings.ToUpper,
+		"money":  func(v float64) string { return fmt.Sprintf("%.2f", v) },
+		"signed": func(v float64) string { return fmt.Sprintf("%+.2f", v) },
+		"pct":    func(v float64) string { return fmt.Sprintf("%+.1f%%", v) },
+		"json": func(v any) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}
+}
+
+func render(tmpl *template.Template, digest model.Digest) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, digest); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// paragraphs splits rendered text on blank lines, dropping empty paragraphs
+func paragraphs(text string) []string {
+	var result []string
+	for _, p := range strings.Split(text, "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
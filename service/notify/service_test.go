@@ -0,0 +1,246 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+// request is what the test endpoint received
+type request struct {
+	body    []byte
+	headers http.Header
+}
+
+// endpoint records every request and answers with status and body
+func endpoint(t *testing.T, status int, body string) (*httptest.Server, *[]request) {
+	t.Helper()
+
+	var received []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read request body: %v", err)
+		}
+		received = append(received, request{body: data, headers: r.Header.Clone()})
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &received
+}
+
+var digest = model.Digest{
+	Title:     "Cloud Doctor cost report, 2026-10-18",
+	Mode:      model.RunModeCost,
+	Timestamp: time.Date(2026, 10, 18, 8, 30, 0, 0, time.UTC),
+	Costs: []model.DigestCost{
+		{Provider: "aws", AccountID: "123456789012", Current: 120, Last: 100, Unit: "USD", ChangePercent: 20},
+	},
+}
+
+func TestSendPayloads(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel model.NotificationChannel
+		check   func(t *testing.T, r request)
+	}{
+		{
+			name:    "slack block kit message",
+			channel: model.NotificationChannel{Type: model.ChannelSlack},
+			check: func(t *testing.T, r request) {
+				var message slackMessage
+				if err := json.Unmarshal(r.body, &message); err != nil {
+					t.Fatalf("payload is not a Slack message: %v", err)
+				}
+				if message.Text != digest.Title {
+					t.Errorf("text = %q, want %q", message.Text, digest.Title)
+				}
+
+				var types []string
+				for _, block := range message.Blocks {
+					types = append(types, block.Type)
+				}
+				if got := strings.Join(types, ","); got != "header,section,context" {
+					t.Fatalf("blocks = %s, want header,section,context", got)
+				}
+				section := message.Blocks[1].Text
+				if section.Type != "mrkdwn" || !strings.Contains(section.Text, "*AWS* 123456789012: 120.00 USD vs 100.00 USD (+20.0%)") {
+					t.Errorf("section = %+v, want the AWS comparison in mrkdwn", section)
+				}
+			},
+		},
+		{
+			name:    "teams adaptive card",
+			channel: model.NotificationChannel{Type: model.ChannelTeams},
+			check: func(t *testing.T, r request) {
+				var message teamsMessage
+				if err := json.Unmarshal(r.body, &message); err != nil {
+					t.Fatalf("payload is not a Teams message: %v", err)
+				}
+				if message.Type != "message" || len(message.Attachments) != 1 {
+					t.Fatalf("message = %+v, want one attachment", message)
+				}
+				attachment := message.Attachments[0]
+				if attachment.ContentType != "application/vnd.microsoft.card.adaptive" || attachment.Content.Type != "AdaptiveCard" {
+					t.Errorf("attachment = %s %s, want an adaptive card", attachment.ContentType, attachment.Content.Type)
+				}
+
+				body := attachment.Content.Body
+				if len(body) != 3 {
+					t.Fatalf("card has %d text blocks, want title, comparison and footer", len(body))
+				}
+				if body[0].Text != digest.Title || body[0].Weight != "Bolder" {
+					t.Errorf("title block = %+v", body[0])
+				}
+				if !strings.Contains(body[1].Text, "**AWS** 123456789012: 120.00 USD vs 100.00 USD (+20.0%)") {
+					t.Errorf("comparison block = %q, want the AWS comparison in markdown", body[1].Text)
+				}
+			},
+		},
+		{
+			name: "webhook digest as json with headers",
+			channel: model.NotificationChannel{
+				Type:    model.ChannelWebhook,
+				Headers: map[string]string{"Authorization": "Bearer secret"},
+			},
+			check: func(t *testing.T, r request) {
+				var got model.Digest
+				if err := json.Unmarshal(r.body, &got); err != nil {
+					t.Fatalf("payload is not a digest: %v", err)
+				}
+				if got.Title != digest.Title || len(got.Costs) != 1 || got.Costs[0].Current != 120 {
+					t.Errorf("digest = %+v, want %+v", got, digest)
+				}
+				if auth := r.headers.Get("Authorization"); auth != "Bearer secret" {
+					t.Errorf("Authorization = %q, want the configured header", auth)
+				}
+				if contentType := r.headers.Get("Content-Type"); contentType != "application/json" {
+					t.Errorf("Content-Type = %q, want application/json", contentType)
+				}
+			},
+		},
+		{
+			name: "webhook template as body",
+			channel: model.NotificationChannel{
+				Type:     model.ChannelWebhook,
+				Template: `{"summary": {{json .Title}}, "providers": {{len .Costs}}}`,
+			},
+			check: func(t *testing.T, r request) {
+				want := `{"summary": "Cloud Doctor cost report, 2026-10-18", "providers": 1}`
+				if string(r.body) != want {
+					t.Errorf("body = %s, want %s", r.body, want)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, received := endpoint(t, http.StatusOK, "ok")
+			tt.channel.URL = server.URL
+
+			s, err := NewService(model.NotificationsConfig{Channels: []model.NotificationChannel{tt.channel}})
+			if err != nil {
+				t.Fatalf("NewService() error = %v", err)
+			}
+			if err := s.Send(context.Background(), digest); err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+
+			if len(*received) != 1 {
+				t.Fatalf("endpoint received %d requests, want 1", len(*received))
+			}
+			tt.check(t, (*received)[0])
+		})
+	}
+}
+
+func TestSendReportsNon2xx(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{name: "created", status: http.StatusCreated},
+		{name: "no content", status: http.StatusNoContent},
+		{name: "multiple choices", status: http.StatusMultipleChoices, wantErr: "unexpected status 300"},
+		{name: "client error with detail", status: http.StatusNotFound, body: "no_service\n", wantErr: "unexpected status 404 Not Found: no_service"},
+		{name: "server error", status: http.StatusInternalServerError, body: "oops", wantErr: "unexpected status 500 Internal Server Error: oops"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failing, _ := endpoint(t, tt.status, tt.body)
+			healthy, received := endpoint(t, http.StatusOK, "ok")
+
+			s, err := NewService(model.NotificationsConfig{Channels: []model.NotificationChannel{
+				{Name: "ops", Type: model.ChannelSlack, URL: failing.URL},
+				{Name: "audit", Type: model.ChannelWebhook, URL: healthy.URL},
+			}})
+			if err != nil {
+				t.Fatalf("NewService() error = %v", err)
+			}
+
+			err = s.Send(context.Background(), digest)
+			if len(*received) != 1 {
+				t.Errorf("second channel received %d requests, want 1", len(*received))
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Send() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "notification channel ops: "+tt.wantErr) {
+				t.Fatalf("Send() error = %v, want %q", err, tt.wantErr)
+			}
+			if strings.Contains(err.Error(), "audit") {
+				t.Errorf("Send() error = %v, want only the failing channel", err)
+			}
+		})
+	}
+}
+
+func TestSlackPayloadTruncatesOnRuneBoundary(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{name: "ascii", text: strings.Repeat("a", slackSectionLimit+10)},
+		{name: "multi-byte runes", text: strings.Repeat("→", slackSectionLimit)},
+		{name: "multi-byte rune across the cut", text: strings.Repeat("a", slackSectionLimit-4) + strings.Repeat("€", 10)},
+		{name: "at the limit", text: strings.Repeat("é", slackSectionLimit/2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			section := slackPayload(digest, tt.text).Blocks[1].Text.Text
+
+			if !utf8.ValidString(section) {
+				t.Fatalf("section is not valid UTF-8")
+			}
+			if len(section) > slackSectionLimit {
+				t.Errorf("section is %d bytes, want at most %d", len(section), slackSectionLimit)
+			}
+			if len(tt.text) <= slackSectionLimit {
+				if section != tt.text {
+					t.Errorf("section within the limit was changed")
+				}
+				return
+			}
+			if !strings.HasSuffix(section, "...") || !strings.HasPrefix(tt.text, strings.TrimSuffix(section, "...")) {
+				t.Errorf("section = %q..., want a prefix of the text ending in ...", section[:20])
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+// slackSectionLimit is the maximum length of a section block's text
+const slackSectionLimit = 3000
+
+// slackPayload builds a Block Kit message with a header, one section per
+// paragraph of text and a context line with the run time
+func slackPayload(digest model.Digest, text string) slackMessage {
+	message := slackMessage{
+		Text: digest.Title,
+		Blocks: []slackBlock{{
+			Type: "header",
+			Text: &slackText{Type: "plain_text", Text: digest.Title},
+		}},
+	}
+
+	for _, p := range paragraphs(text) {
+		message.Blocks = append(message.Blocks, slackBlock{
+			Type: "section",
+			Text: &slackText{Type: "mrkdwn", Text: truncate(p, slackSectionLimit)},
+		})
+	}
+
+	message.Blocks = append(message.Blocks, slackBlock{
+		Type: "context",
+		Elements: []slackText{{
+			Type: "mrkdwn",
+			Text: fmt.Sprintf("Sent by cloud-doctor at %s", digest.Timestamp.Format("2006-01-02 15:04 MST")),
+		}},
+	})
+
+	return message
+}
+
+// truncate shortens text to at most limit bytes ending in "...". It cuts on a
+// rune boundary so that multi-byte characters such as "→" stay valid UTF-8.
+func truncate(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+
+	cut := limit - len("...")
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "..."
+}
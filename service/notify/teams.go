@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"fmt"
+
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+// teamsPayload wraps an Adaptive Card in the message format accepted by Teams
+// incoming webhooks and workflow webhooks
+func teamsPayload(digest model.Digest, text string) teamsMessage {
+	card := teamsCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body: []teamsTextBlock{{
+			Type:   "TextBlock",
+			Text:   digest.Title,
+			Wrap:   true,
+			Weight: "Bolder",
+			Size:   "Medium",
+		}},
+	}
+
+	for _, p := range paragraphs(text) {
+		card.Body = append(card.Body, teamsTextBlock{Type: "TextBlock", Text: p, Wrap: true})
+	}
+
+	card.Body = append(card.Body, teamsTextBlock{
+		Type:     "TextBlock",
+		Text:     fmt.Sprintf("Sent by cloud-doctor at %s", digest.Timestamp.Format("2006-01-02 15:04 MST")),
+		Wrap:     true,
+		Size:     "Small",
+		IsSubtle: true,
+	})
+
+	return teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content:     card,
+		}},
+	}
+}
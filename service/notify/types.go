@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"text/template"
+
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+type service struct {
+	channels  []channel
+	topMovers int
+	client    *http.Client
+}
+
+// channel is a configured destination with its parsed message template
+type channel struct {
+	config   model.NotificationChannel
+	template *template.Template
+}
+
+type NotifyService interface {
+	Notify(ctx context.Context, result *model.RunResult) error
+	Send(ctx context.Context, digest model.Digest) error
+}
+
+// slackMessage is an incoming webhook payload using Block Kit
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// teamsMessage is an incoming webhook payload carrying an Adaptive Card
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+type teamsCard struct {
+	Schema  string           `json:"$schema"`
+	Type    string           `json:"type"`
+	Version string           `json:"version"`
+	Body    []teamsTextBlock `json:"body"`
+}
+
+type teamsTextBlock struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Wrap     bool   `json:"wrap"`
+	Weight   string `json:"weight,omitempty"`
+	Size     string `json:"size,omitempty"`
+	IsSubtle bool   `json:"isSubtle,omitempty"`
+}
@@ -2,111 +2,176 @@ package orchestrator
 
 import (
 	"context"
+	"time"
 
 	"github.com/elC0mpa/aws-doctor/model"
 	"github.com/elC0mpa/aws-doctor/service"
+	"github.com/elC0mpa/aws-doctor/service/budget"
+	"github.com/elC0mpa/aws-doctor/service/cache"
+	"github.com/elC0mpa/aws-doctor/service/ownership"
+	"github.com/elC0mpa/aws-doctor/service/warnings"
 	"github.com/elC0mpa/aws-doctor/utils"
 )
 
-func NewService(identityService service.IdentityService, costService service.CostService, resourceService service.ResourceService) *orchestratorService {
+// NewService creates the single-provider orchestrator. budgetService is only
+// set when the provider's own budgets should be imported; budgets are the
+// validated budgets of the config file.
+func NewService(identityService service.IdentityService, costService service.CostService, resourceService service.ResourceService, budgetService service.BudgetService, budgets []model.BudgetConfig) *orchestratorService {
 	return &orchestratorService{
 		identityService: identityService,
 		costService:     costService,
 		resourceService: resourceService,
+		budgetService:   budgetService,
+		budgets:         budgets,
 	}
 }
 
-func (s *orchestratorService) Orchestrate(flags model.Flags) error {
+func (s *orchestratorService) Orchestrate(flags model.Flags) (*model.RunResult, error) {
+	// Services report skipped zones, regions and resources to the collector
+	collector := warnings.NewCollector()
+	ctx := warnings.WithCollector(context.Background(), collector)
+
 	if flags.Waste {
-		return s.wasteWorkflow()
+		return s.wasteWorkflow(ctx, collector, flags.GroupBy)
 	}
 
 	if flags.Trend {
-		return s.trendWorkflow()
+		return s.trendWorkflow(ctx, collector)
 	}
 
-	return s.defaultWorkflow()
+	return s.defaultWorkflow(ctx, collector)
 }
 
-func (s *orchestratorService) defaultWorkflow() error {
-	currentMonthData, err := s.costService.GetCurrentMonthCostsByService(context.Background())
+func (s *orchestratorService) defaultWorkflow(ctx context.Context, collector warnings.Collector) (*model.RunResult, error) {
+	currentMonthData, err := s.costService.GetCurrentMonthCostsByService(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	lastMonthData, err := s.costService.GetLastMonthCostsByService(context.Background())
+	lastMonthData, err := s.costService.GetLastMonthCostsByService(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	currentTotalCost, err := s.costService.GetCurrentMonthTotalCosts(context.Background())
+	currentTotalCost, err := s.costService.GetCurrentMonthTotalCosts(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	lastTotalCost, err := s.costService.GetLastMonthTotalCosts(context.Background())
+	lastTotalCost, err := s.costService.GetLastMonthTotalCosts(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	accountInfo, err := s.identityService.GetAccountInfo(context.Background())
+	accountInfo, err := s.identityService.GetAccountInfo(ctx)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	result := model.ProviderCostResult{
+		Provider:         accountInfo.Provider,
+		AccountID:        accountInfo.AccountID,
+		CurrentMonthData: currentMonthData,
+		LastMonthData:    lastMonthData,
+		CurrentTotalCost: *currentTotalCost,
+		LastTotalCost:    *lastTotalCost,
+		CachedAt:         cache.CachedAt(s.costService),
 	}
+	result.Budgets = budget.Evaluate(ctx, s.budgets, result, s.costService, s.budgetService)
+	result.Warnings = collector.List()
 
 	utils.StopSpinner()
 
 	utils.DrawCostTable(accountInfo.AccountID, *lastTotalCost, *currentTotalCost, lastMonthData, currentMonthData, "UnblendedCost")
-	return nil
+	utils.DrawBudgetTable(result.Budgets)
+	utils.DrawCacheNotice(accountInfo.Provider, result.CachedAt)
+	utils.DrawWarnings(accountInfo.Provider, result.Warnings)
+
+	return &model.RunResult{
+		Mode:      model.RunModeCost,
+		Timestamp: time.Now(),
+		Costs:     []model.ProviderCostResult{result},
+	}, nil
 }
 
-func (s *orchestratorService) trendWorkflow() error {
-	costInfo, err := s.costService.GetLastSixMonthsCosts(context.Background())
+func (s *orchestratorService) trendWorkflow(ctx context.Context, collector warnings.Collector) (*model.RunResult, error) {
+	costInfo, err := s.costService.GetLastSixMonthsCosts(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	accountInfo, err := s.identityService.GetAccountInfo(context.Background())
+	accountInfo, err := s.identityService.GetAccountInfo(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	utils.StopSpinner()
 
 	utils.DrawTrendChart(accountInfo.AccountID, costInfo)
-
-	return nil
+	utils.DrawCacheNotice(accountInfo.Provider, cache.CachedAt(s.costService))
+	utils.DrawWarnings(accountInfo.Provider, collector.List())
+
+	return &model.RunResult{
+		Mode:      model.RunModeTrend,
+		Timestamp: time.Now(),
+		Costs: []model.ProviderCostResult{{
+			Provider:  accountInfo.Provider,
+			AccountID: accountInfo.AccountID,
+			TrendData: costInfo,
+			CachedAt:  cache.CachedAt(s.costService),
+			Warnings:  collector.List(),
+		}},
+	}, nil
 }
 
-func (s *orchestratorService) wasteWorkflow() error {
-	unusedIPs, err := s.resourceService.GetUnusedIPs(context.Background())
+func (s *orchestratorService) wasteWorkflow(ctx context.Context, collector warnings.Collector, groupBy string) (*model.RunResult, error) {
+	unusedIPs, err := s.resourceService.GetUnusedIPs(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	unusedVolumes, err := s.resourceService.GetUnusedVolumes(context.Background())
+	unusedVolumes, err := s.resourceService.GetUnusedVolumes(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	stoppedInstances, attachedVolumes, err := s.resourceService.GetStoppedInstances(context.Background())
+	stoppedInstances, attachedVolumes, err := s.resourceService.GetStoppedInstances(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	expiringReservations, err := s.resourceService.GetExpiringReservations(context.Background())
+	expiringReservations, err := s.resourceService.GetExpiringReservations(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	accountInfo, err := s.identityService.GetAccountInfo(context.Background())
+	accountInfo, err := s.identityService.GetAccountInfo(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	utils.StopSpinner()
 
+	result := model.ProviderWasteResult{
+		Provider:             accountInfo.Provider,
+		AccountID:            accountInfo.AccountID,
+		UnusedVolumes:        unusedVolumes,
+		AttachedVolumes:      attachedVolumes,
+		UnusedIPs:            unusedIPs,
+		StoppedInstances:     stoppedInstances,
+		ExpiringReservations: expiringReservations,
+		Warnings:             collector.List(),
+	}
+
 	utils.DrawWasteTable(accountInfo.AccountID, unusedIPs, unusedVolumes, attachedVolumes, expiringReservations, stoppedInstances)
+	if groupBy == model.GroupByOwner {
+		utils.DrawOwnerWasteTable(ownership.GroupWaste([]model.ProviderWasteResult{result}))
+	}
+	utils.DrawWarnings(accountInfo.Provider, collector.List())
 
-	return nil
+	return &model.RunResult{
+		Mode:      model.RunModeWaste,
+		Timestamp: time.Now(),
+		Waste:     []model.ProviderWasteResult{result},
+	}, nil
 }
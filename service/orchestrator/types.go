@@ -9,8 +9,10 @@ type orchestratorService struct {
 	identityService service.IdentityService
 	costService     service.CostService
 	resourceService service.ResourceService
+	budgetService   service.BudgetService
+	budgets         []model.BudgetConfig
 }
 
 type OrchestratorService interface {
-	Orchestrate(model.Flags) error
+	Orchestrate(model.Flags) (*model.RunResult, error)
 }
@@ -0,0 +1,106 @@
+package ownership
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+// Unowned is the owner reported for resources without any owner tag
+const Unowned = "(no owner)"
+
+// DefaultTagKeys are checked in order when no owner_tag_keys are configured
+var DefaultTagKeys = []string{"owner", "team", "cost-center"}
+
+// Resolver resolves the owner of a resource from its tags. The zero value
+// checks DefaultTagKeys.
+type Resolver struct {
+	tagKeys []string
+}
+
+// NewResolver returns a resolver checking the owner_tag_keys of the config
+// file in order. An empty list uses the defaults.
+func NewResolver(keys []string) Resolver {
+	return Resolver{tagKeys: keys}
+}
+
+// Resolve returns the value of the first owner key present in tags. Keys are
+// matched case-insensitively since GCP labels are lowercase while AWS and
+// Azure tags keep their case.
+func (r Resolver) Resolve(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	keys := r.tagKeys
+	if len(keys) == 0 {
+		keys = DefaultTagKeys
+	}
+
+	for _, key := range keys {
+		for tagKey, value := range tags {
+			if strings.EqualFold(tagKey, key) && value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// GroupWaste groups the findings of every successful provider by owner, with
+// unowned findings last
+func GroupWaste(results []model.ProviderWasteResult) []model.OwnerWaste {
+	groups := make(map[string]*model.OwnerWaste)
+	group := func(owner string) *model.OwnerWaste {
+		if owner == "" {
+			owner = Unowned
+		}
+		g, ok := groups[owner]
+		if !ok {
+			g = &model.OwnerWaste{Owner: owner}
+			groups[owner] = g
+		}
+		return g
+	}
+
+	for _, result := range results {
+		if result.Error != nil {
+			continue
+		}
+
+		for _, v := range result.UnusedVolumes {
+			g := group(v.Owner)
+			g.UnusedVolumes++
+			g.Resources = append(g.Resources, result.Provider+":"+v.ID)
+		}
+		for _, v := range result.AttachedVolumes {
+			g := group(v.Owner)
+			g.AttachedVolumes++
+			g.Resources = append(g.Resources, result.Provider+":"+v.ID)
+		}
+		for _, ip := range result.UnusedIPs {
+			g := group(ip.Owner)
+			g.UnusedIPs++
+			g.Resources = append(g.Resources, result.Provider+":"+ip.Address)
+		}
+		for _, inst := range result.StoppedInstances {
+			g := group(inst.Owner)
+			g.StoppedInstances++
+			g.Resources = append(g.Resources, result.Provider+":"+inst.ID)
+		}
+	}
+
+	owned := make([]model.OwnerWaste, 0, len(groups))
+	for _, g := range groups {
+		owned = append(owned, *g)
+	}
+	sort.Slice(owned, func(i, j int) bool {
+		if (owned[i].Owner == Unowned) != (owned[j].Owner == Unowned) {
+			return owned[j].Owner == Unowned
+		}
+		return owned[i].Owner < owned[j].Owner
+	})
+
+	return owned
+}
@@ -0,0 +1,236 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/history"
+	"github.com/elC0mpa/aws-doctor/service/wastecost"
+	"github.com/elC0mpa/aws-doctor/utils"
+)
+
+// exitCodes maps each rule kind to the exit code it sets when it fails
+var exitCodes = map[string]int{
+	model.PolicyBudgetBreach:  model.ExitBudget,
+	model.PolicyBudgetWarn:    model.ExitBudget,
+	model.PolicyWaste:         model.ExitWaste,
+	model.PolicyWasteCost:     model.ExitWaste,
+	model.PolicyNewWaste:      model.ExitNewWaste,
+	model.PolicyCostIncrease:  model.ExitCostIncrease,
+	model.PolicyProviderError: model.ExitProviderError,
+}
+
+// NewService parses a --fail-on spec, a comma-separated list of rules such as
+// "budget,waste>10,waste-cost>100,new-waste,increase>20%,error". Waste cost
+// is estimated with prices.
+func NewService(spec string, prices wastecost.Prices) (*service, error) {
+	var rules []model.PolicyRule
+	for _, token := range strings.Split(spec, ",") {
+		token = strings.ToLower(strings.TrimSpace(token))
+		if token == "" {
+			continue
+		}
+
+		rule, err := parseRule(token)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("--fail-on needs at least one rule")
+	}
+
+	return &service{rules: rules, prices: prices}, nil
+}
+
+func (s *service) GetRules() []model.PolicyRule {
+	return s.rules
+}
+
+// NeedsHistory reports whether a rule compares the run with the previous snapshot
+func (s *service) NeedsHistory() bool {
+	for _, rule := range s.rules {
+		if rule.Kind == model.PolicyNewWaste {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate summarizes the run and checks every rule against it. Rules that do
+// not apply to the run's mode, such as waste rules in a cost run, pass.
+// New waste findings only count when diffs have a baseline snapshot.
+func (s *service) Evaluate(result *model.RunResult, diffs []model.SnapshotDiff) model.PolicyReport {
+	report := Summarize(result, diffs, s.prices)
+
+	for _, rule := range s.rules {
+		message := ""
+		switch rule.Kind {
+		case model.PolicyBudgetBreach:
+			if report.BudgetBreaches > 0 {
+				message = fmt.Sprintf("%d budget(s) breached", report.BudgetBreaches)
+			}
+		case model.PolicyBudgetWarn:
+			if report.BudgetBreaches+report.BudgetWarnings > 0 {
+				message = fmt.Sprintf("%d budget(s) breached, %d at warning level", report.BudgetBreaches, report.BudgetWarnings)
+			}
+		case model.PolicyWaste:
+			if float64(report.WasteFindings) > rule.Threshold {
+				message = fmt.Sprintf("%d waste finding(s), more than %.0f", report.WasteFindings, rule.Threshold)
+			}
+		case model.PolicyWasteCost:
+			if report.WasteCost > rule.Threshold {
+				message = fmt.Sprintf("waste estimated at %.2f USD a month, more than %.2f USD", report.WasteCost, rule.Threshold)
+			}
+		case model.PolicyNewWaste:
+			if report.NewWasteFindings > 0 {
+				message = fmt.Sprintf("%d new waste finding(s) since the previous run", report.NewWasteFindings)
+			}
+		case model.PolicyCostIncrease:
+			if report.MaxIncreasePercent > rule.Threshold {
+				message = fmt.Sprintf("cost increased %.1f%%, more than %.1f%%", report.MaxIncreasePercent, rule.Threshold)
+			}
+		case model.PolicyProviderError:
+			if report.ProviderErrors > 0 {
+				message = fmt.Sprintf("%d provider(s) failed", report.ProviderErrors)
+			}
+		}
+		if message == "" {
+			continue
+		}
+
+		report.Violations = append(report.Violations, model.PolicyViolation{
+			Rule:     FormatRule(rule),
+			Message:  message,
+			ExitCode: exitCodes[rule.Kind],
+		})
+		if report.ExitCode == model.ExitOK {
+			report.ExitCode = exitCodes[rule.Kind]
+		}
+	}
+
+	return report
+}
+
+// Summarize counts the budgets, waste findings, cost increases and provider
+// errors of a run, and estimates the waste cost with prices
+func Summarize(result *model.RunResult, diffs []model.SnapshotDiff, prices wastecost.Prices) model.PolicyReport {
+	report := model.PolicyReport{}
+	if result == nil {
+		return report
+	}
+	report.Mode = result.Mode
+
+	for _, cost := range result.Costs {
+		report.Providers++
+		if cost.Error != nil {
+			report.ProviderErrors++
+			continue
+		}
+
+		for _, status := range cost.Budgets {
+			switch status.Status {
+			case model.BudgetBreach:
+				report.BudgetBreaches++
+			case model.BudgetWarn:
+				report.BudgetWarnings++
+			}
+		}
+
+		current, _ := utils.ParseTotal(cost.CurrentTotalCost)
+		last, _ := utils.ParseTotal(cost.LastTotalCost)
+		if last > 0 {
+			increase := (current - last) / last * 100
+			if increase > report.MaxIncreasePercent {
+				report.MaxIncreasePercent = increase
+			}
+		}
+	}
+
+	for _, waste := range result.Waste {
+		report.Providers++
+		if waste.Error != nil {
+			report.ProviderErrors++
+			continue
+		}
+		report.WasteFindings += len(history.WasteFindings(waste))
+		report.WasteCost += prices.Total(waste)
+	}
+
+	for _, audit := range result.TagAudits {
+		report.Providers++
+		if audit.Error != nil {
+			report.ProviderErrors++
+		}
+	}
+
+	if result.Chargeback != nil {
+		for _, provider := range result.Chargeback.Providers {
+			report.Providers++
+			if provider.Error != nil {
+				report.ProviderErrors++
+			}
+		}
+	}
+
+	for _, diff := range diffs {
+		if diff.Kind == model.SnapshotKindWaste && diff.Baseline != nil {
+			report.NewWasteFindings += len(diff.New)
+		}
+	}
+
+	return report
+}
+
+// FormatRule renders a rule the way it is written in --fail-on
+func FormatRule(rule model.PolicyRule) string {
+	switch rule.Kind {
+	case model.PolicyWaste:
+		return fmt.Sprintf("waste>%g", rule.Threshold)
+	case model.PolicyWasteCost:
+		return fmt.Sprintf("waste-cost>%g", rule.Threshold)
+	case model.PolicyCostIncrease:
+		return fmt.Sprintf("increase>%g%%", rule.Threshold)
+	default:
+		return rule.Kind
+	}
+}
+
+func parseRule(token string) (model.PolicyRule, error) {
+	kind, threshold, hasThreshold := strings.Cut(token, ">")
+	kind = strings.TrimSpace(kind)
+
+	switch kind {
+	case model.PolicyBudgetBreach, model.PolicyBudgetWarn, model.PolicyNewWaste, model.PolicyProviderError:
+		if hasThreshold {
+			return model.PolicyRule{}, fmt.Errorf("--fail-on rule %q takes no threshold", kind)
+		}
+		return model.PolicyRule{Kind: kind}, nil
+
+	case model.PolicyWaste, model.PolicyWasteCost, model.PolicyCostIncrease:
+		if !hasThreshold {
+			switch kind {
+			case model.PolicyWaste:
+				return model.PolicyRule{Kind: kind}, nil
+			case model.PolicyWasteCost:
+				return model.PolicyRule{}, fmt.Errorf("--fail-on rule %q needs a threshold, e.g. waste-cost>100", kind)
+			}
+			return model.PolicyRule{}, fmt.Errorf("--fail-on rule %q needs a threshold, e.g. increase>20%%", kind)
+		}
+
+		// Allow "waste-cost>$100" and "increase>20%"
+		threshold = strings.TrimPrefix(strings.TrimSpace(threshold), "$")
+		value, err := strconv.ParseFloat(strings.TrimSuffix(threshold, "%"), 64)
+		if err != nil || value < 0 {
+			return model.PolicyRule{}, fmt.Errorf("invalid --fail-on threshold in %q", token)
+		}
+		return model.PolicyRule{Kind: kind, Threshold: value}, nil
+
+	default:
+		return model.PolicyRule{}, fmt.Errorf("unknown --fail-on rule %q. Supported rules: budget, budget-warn, waste>N, waste-cost>N, new-waste, increase>N%%, error", kind)
+	}
+}
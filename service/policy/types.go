@@ -0,0 +1,17 @@
+package policy
+
+import (
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/wastecost"
+)
+
+type service struct {
+	rules  []model.PolicyRule
+	prices wastecost.Prices
+}
+
+type PolicyService interface {
+	GetRules() []model.PolicyRule
+	NeedsHistory() bool
+	Evaluate(result *model.RunResult, diffs []model.SnapshotDiff) model.PolicyReport
+}
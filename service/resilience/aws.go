@@ -0,0 +1,39 @@
+package resilience
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// awsBackoff adapts the policy backoff to the AWS SDK retryer
+type awsBackoff struct {
+	policy *policy
+}
+
+func (b awsBackoff) BackoffDelay(attempt int, _ error) (time.Duration, error) {
+	return b.policy.Backoff(attempt - 1), nil
+}
+
+// AWSConfigOptions configures the AWS SDK to retry with the policy's backoff
+// and send requests through the rate limited, timed out transport. The SDK
+// retryer already classifies throttling and 5xx errors, so the transport
+// does not retry on its own.
+func (p *policy) AWSConfigOptions() []func(*config.LoadOptions) error {
+	return []func(*config.LoadOptions) error{
+		config.WithRetryer(func() aws.Retryer {
+			return retry.NewStandard(func(o *retry.StandardOptions) {
+				o.MaxAttempts = p.maxRetries + 1
+				o.MaxBackoff = p.maxDelay
+				o.Backoff = awsBackoff{policy: p}
+				// Client-side rate limiting is done by the transport instead of
+				// the SDK retry token bucket, which fails fast once drained
+				o.RateLimiter = ratelimit.None
+			})
+		}),
+		config.WithHTTPClient(p.HTTPClient("aws", false)),
+	}
+}
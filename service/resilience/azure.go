@@ -0,0 +1,29 @@
+package resilience
+
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	azpolicy "github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// AzureClientOptions configures the ARM retry policy from the policy and sends
+// requests through the rate limited, timed out transport. azcore retries 408,
+// 429 and 5xx responses itself, so the transport does not retry on its own.
+func (p *policy) AzureClientOptions() *arm.ClientOptions {
+	// azcore reads 0 as its default of 3 retries and a negative count as none
+	maxRetries := int32(p.maxRetries)
+	if maxRetries == 0 {
+		maxRetries = -1
+	}
+
+	return &arm.ClientOptions{
+		ClientOptions: azpolicy.ClientOptions{
+			Retry: azpolicy.RetryOptions{
+				MaxRetries:    maxRetries,
+				RetryDelay:    p.baseDelay,
+				MaxRetryDelay: p.maxDelay,
+				TryTimeout:    p.timeout,
+			},
+			Transport: p.HTTPClient("azure", false),
+		},
+	}
+}
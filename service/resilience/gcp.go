@@ -0,0 +1,22 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+)
+
+// GCPClientOptions returns client options whose authenticated HTTP client
+// retries, rate limits and times out calls. The generated Google API clients
+// do not retry list calls, so the transport handles retries itself.
+func (p *policy) GCPClientOptions(ctx context.Context, opts ...option.ClientOption) ([]option.ClientOption, error) {
+	authTransport, err := htransport.NewTransport(ctx, p.Transport("gcp", http.DefaultTransport, true), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCP transport: %w", err)
+	}
+
+	return append(opts, option.WithHTTPClient(&http.Client{Transport: authTransport})), nil
+}
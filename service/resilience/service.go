@@ -0,0 +1,240 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"golang.org/x/time/rate"
+)
+
+const (
+	DefaultMaxRetries = 5
+	DefaultBaseDelay  = 500 * time.Millisecond
+	DefaultMaxDelay   = 30 * time.Second
+	DefaultTimeout    = time.Minute
+)
+
+// DefaultRateLimits are requests per second per provider. They stay below the
+// documented quotas of the slowest API each provider is queried through
+// (Cost Explorer, Compute Engine list calls and ARM reads respectively).
+var DefaultRateLimits = map[string]float64{
+	"aws":   5,
+	"gcp":   20,
+	"azure": 10,
+}
+
+// NewPolicy builds a policy from the config file. A non-zero timeout (from
+// --timeout) overrides the configured per-call timeout.
+func NewPolicy(cfg model.ResilienceConfig, timeout time.Duration) (*policy, error) {
+	p := &policy{
+		maxRetries: DefaultMaxRetries,
+		baseDelay:  DefaultBaseDelay,
+		maxDelay:   DefaultMaxDelay,
+		timeout:    DefaultTimeout,
+		limiters:   make(map[string]*rate.Limiter),
+	}
+
+	if cfg.MaxRetries != nil {
+		if *cfg.MaxRetries < 0 {
+			return nil, fmt.Errorf("invalid resilience max_retries %d: must not be negative", *cfg.MaxRetries)
+		}
+		p.maxRetries = *cfg.MaxRetries
+	}
+
+	durations := []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"base_delay", cfg.BaseDelay, &p.baseDelay},
+		{"max_delay", cfg.MaxDelay, &p.maxDelay},
+		{"timeout", cfg.Timeout, &p.timeout},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resilience %s %q: %w", d.name, d.value, err)
+		}
+		*d.dest = parsed
+	}
+
+	if timeout > 0 {
+		p.timeout = timeout
+	}
+
+	for provider, limit := range DefaultRateLimits {
+		if configured, ok := cfg.RateLimits[provider]; ok && configured > 0 {
+			limit = configured
+		}
+		// Allow a short burst so a handful of parallel calls are not serialized
+		p.limiters[provider] = rate.NewLimiter(rate.Limit(limit), max(1, int(limit)))
+	}
+
+	return p, nil
+}
+
+func (p *policy) GetMaxRetries() int {
+	return p.maxRetries
+}
+
+func (p *policy) GetBaseDelay() time.Duration {
+	return p.baseDelay
+}
+
+func (p *policy) GetMaxDelay() time.Duration {
+	return p.maxDelay
+}
+
+func (p *policy) GetTimeout() time.Duration {
+	return p.timeout
+}
+
+// HTTPClient returns a client that rate limits and times out each call to
+// provider and, when retry is set, retries throttled and 5xx responses
+func (p *policy) HTTPClient(provider string, retry bool) *http.Client {
+	return &http.Client{Transport: p.Transport(provider, http.DefaultTransport, retry)}
+}
+
+// Transport wraps base with the policy for provider
+func (p *policy) Transport(provider string, base http.RoundTripper, retry bool) http.RoundTripper {
+	return &transport{
+		base:    base,
+		policy:  p,
+		limiter: p.limiters[provider],
+		retry:   retry,
+	}
+}
+
+// Backoff returns the delay before retry attempt n (starting at 0) using
+// exponential backoff with full jitter
+func (p *policy) Backoff(attempt int) time.Duration {
+	ceiling := p.baseDelay << min(attempt, 30)
+	if ceiling <= 0 || ceiling > p.maxDelay {
+		ceiling = p.maxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if t.retry {
+		attempts += t.policy.maxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.roundTrip(req)
+
+		if attempt+1 >= attempts || !retryable(req, resp, err) {
+			return resp, err
+		}
+
+		delay := t.policy.Backoff(attempt)
+		if resp != nil {
+			delay = max(delay, min(retryAfter(resp), t.policy.maxDelay))
+			resp.Body.Close()
+		}
+
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+
+		if req.Body != nil && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind request body for retry: %w", err)
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// roundTrip sends a single attempt, waiting for the rate limiter first
+func (t *transport) roundTrip(req *http.Request) (*http.Response, error) {
+	if t.limiter != nil {
+		if err := t.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+
+	if t.policy.timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.policy.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) && req.Context().Err() == nil {
+			return nil, fmt.Errorf("request to %s timed out after %s: %w", req.URL.Host, t.policy.timeout, err)
+		}
+		return nil, err
+	}
+
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// retryable reports whether an attempt failed with throttling, a server error
+// or a transient network error, and the request can safely be sent again
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	if err != nil {
+		var netErr net.Error
+		return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) || errors.Is(err, net.ErrClosed)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter honours a Retry-After header given in seconds
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
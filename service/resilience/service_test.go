@@ -0,0 +1,46 @@
+package resilience
+
+import (
+	"testing"
+
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+func TestNewPolicyMaxRetries(t *testing.T) {
+	zero, eight, negative := 0, 8, -1
+
+	tests := []struct {
+		name       string
+		maxRetries *int
+		want       int
+		wantAzure  int32
+		wantErr    bool
+	}{
+		{name: "unset keeps the default", want: DefaultMaxRetries, wantAzure: DefaultMaxRetries},
+		{name: "zero turns retries off", maxRetries: &zero, want: 0, wantAzure: -1},
+		{name: "configured", maxRetries: &eight, want: 8, wantAzure: 8},
+		{name: "negative", maxRetries: &negative, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPolicy(model.ResilienceConfig{MaxRetries: tt.maxRetries}, 0)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NewPolicy() error = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewPolicy() error = %v", err)
+			}
+
+			if got := p.GetMaxRetries(); got != tt.want {
+				t.Errorf("GetMaxRetries() = %d, want %d", got, tt.want)
+			}
+			if got := p.AzureClientOptions().Retry.MaxRetries; got != tt.wantAzure {
+				t.Errorf("Azure MaxRetries = %d, want %d", got, tt.wantAzure)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package resilience
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"golang.org/x/time/rate"
+	"google.golang.org/api/option"
+)
+
+type policy struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	timeout    time.Duration
+	limiters   map[string]*rate.Limiter
+}
+
+// Policy describes how provider API calls are retried, rate limited and timed out
+type Policy interface {
+	GetMaxRetries() int
+	GetBaseDelay() time.Duration
+	GetMaxDelay() time.Duration
+	GetTimeout() time.Duration
+	HTTPClient(provider string, retry bool) *http.Client
+	AWSConfigOptions() []func(*config.LoadOptions) error
+	AzureClientOptions() *arm.ClientOptions
+	GCPClientOptions(ctx context.Context, opts ...option.ClientOption) ([]option.ClientOption, error)
+}
+
+// transport applies the policy to every request sent to one provider.
+// When retry is false the SDK's own retryer is expected to handle retries.
+type transport struct {
+	base    http.RoundTripper
+	policy  *policy
+	limiter *rate.Limiter
+	retry   bool
+}
+
+// cancelBody releases a per-call timeout once the response body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
@@ -0,0 +1,184 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// bits returns the field set holding values
+func bits(values ...int) uint64 {
+	var set uint64
+	for _, v := range values {
+		set |= 1 << uint(v)
+	}
+	return set
+}
+
+// span returns the field set holding every value from low to high
+func span(low, high int) uint64 {
+	var set uint64
+	for v := low; v <= high; v++ {
+		set |= 1 << uint(v)
+	}
+	return set
+}
+
+func TestNewService(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    service
+		wantErr string
+	}{
+		{
+			spec: "*/15 9-17 * * mon-fri",
+			want: service{minutes: bits(0, 15, 30, 45), hours: span(9, 17), days: span(1, 31), months: span(1, 12), weekdays: span(1, 5), anyDay: true},
+		},
+		{
+			spec: "5/20 0 1,15 jan,JUL sun",
+			want: service{minutes: bits(5, 25, 45), hours: bits(0), days: bits(1, 15), months: bits(1, 7), weekdays: bits(0)},
+		},
+		{
+			spec: "0 0 ? * 7",
+			want: service{minutes: bits(0), hours: bits(0), days: span(1, 31), months: span(1, 12), weekdays: bits(0, 7), anyDay: true},
+		},
+		{
+			spec: "  @Daily ",
+			want: service{minutes: bits(0), hours: bits(0), days: span(1, 31), months: span(1, 12), weekdays: span(0, 7), anyDay: true, anyWeekday: true},
+		},
+		{
+			spec: "@every 1h30m",
+			want: service{every: 90 * time.Minute},
+		},
+		{spec: "* * * *", wantErr: "expected 5 fields, got 4"},
+		{spec: "60 * * * *", wantErr: `invalid minute "60"`},
+		{spec: "* 24 * * *", wantErr: `invalid hour "24"`},
+		{spec: "* * 0 * *", wantErr: `invalid day of month "0"`},
+		{spec: "* * * foo *", wantErr: `invalid month "foo"`},
+		{spec: "* * * * 8", wantErr: `invalid day of week "8"`},
+		{spec: "*/0 * * * *", wantErr: `invalid step "0" in minute`},
+		{spec: "30-10 * * * *", wantErr: `invalid range "30-10" in minute`},
+		{spec: "@fortnightly", wantErr: "unknown descriptor"},
+		{spec: "@every 30s", wantErr: "interval must be at least 1m"},
+		{spec: "@every soon", wantErr: `invalid duration "soon"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := NewService(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewService(%q) error = %v, want %q", tt.spec, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewService(%q) error = %v", tt.spec, err)
+			}
+
+			tt.want.spec = strings.TrimSpace(tt.spec)
+			if *got != tt.want {
+				t.Errorf("NewService(%q) = %+v, want %+v", tt.spec, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	load := func(name string) *time.Location {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			t.Fatalf("failed to load %s: %v", name, err)
+		}
+		return loc
+	}
+	kolkata := load("Asia/Kolkata")
+	stJohns := load("America/St_Johns")
+
+	tests := []struct {
+		name  string
+		spec  string
+		after time.Time
+		want  time.Time
+	}{
+		{
+			name:  "daily in UTC rolls over to the next day",
+			spec:  "0 9 * * *",
+			after: time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC),
+			want:  time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "minute step in UTC",
+			spec:  "*/15 * * * *",
+			after: time.Date(2026, 10, 18, 10, 7, 30, 0, time.UTC),
+			want:  time.Date(2026, 10, 18, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			name:  "day of month or day of week",
+			spec:  "0 0 1,15 * fri",
+			after: time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC),
+			want:  time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "next hour in a half-hour offset zone",
+			spec:  "0 9 * * *",
+			after: time.Date(2026, 10, 18, 8, 10, 0, 0, kolkata),
+			want:  time.Date(2026, 10, 18, 9, 0, 0, 0, kolkata),
+		},
+		{
+			name:  "half past in a half-hour offset zone",
+			spec:  "30 14 * * *",
+			after: time.Date(2026, 10, 18, 14, 45, 0, 0, kolkata),
+			want:  time.Date(2026, 10, 19, 14, 30, 0, 0, kolkata),
+		},
+		{
+			name:  "business hours skip the weekend",
+			spec:  "0 9-17 * * mon-fri",
+			after: time.Date(2026, 10, 16, 17, 30, 0, 0, kolkata),
+			want:  time.Date(2026, 10, 19, 9, 0, 0, 0, kolkata),
+		},
+		{
+			name:  "next hour in Newfoundland daylight time",
+			spec:  "0 6 * * *",
+			after: time.Date(2026, 10, 18, 5, 20, 0, 0, stJohns),
+			want:  time.Date(2026, 10, 18, 6, 0, 0, 0, stJohns),
+		},
+		{
+			name:  "across the end of daylight time",
+			spec:  "0 3 * * *",
+			after: time.Date(2026, 10, 31, 12, 0, 0, 0, stJohns),
+			want:  time.Date(2026, 11, 1, 3, 0, 0, 0, stJohns),
+		},
+		{
+			name:  "hour skipped by the start of daylight time",
+			spec:  "30 2 * * *",
+			after: time.Date(2026, 3, 8, 0, 0, 0, 0, stJohns),
+			want:  time.Date(2026, 3, 9, 2, 30, 0, 0, stJohns),
+		},
+		{
+			name:  "every interval",
+			spec:  "@every 90m",
+			after: time.Date(2026, 10, 18, 10, 7, 30, 0, kolkata),
+			want:  time.Date(2026, 10, 18, 11, 37, 30, 0, kolkata),
+		},
+		{
+			name:  "never",
+			spec:  "0 0 30 2 *",
+			after: time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewService(tt.spec)
+			if err != nil {
+				t.Fatalf("NewService(%q) error = %v", tt.spec, err)
+			}
+
+			got := s.Next(tt.after)
+			if !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.after, got, tt.want)
+			}
+		})
+	}
+}
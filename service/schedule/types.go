@@ -0,0 +1,24 @@
+package schedule
+
+import "time"
+
+// service is a parsed schedule. Each field set holds one bit per allowed
+// value; every is set instead for "@every" schedules.
+type service struct {
+	spec     string
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+	// A restricted day of month and day of week match when either matches,
+	// as in cron
+	anyDay     bool
+	anyWeekday bool
+	every      time.Duration
+}
+
+type ScheduleService interface {
+	Next(after time.Time) time.Time
+	String() string
+}
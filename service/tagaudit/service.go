@@ -0,0 +1,159 @@
+package tagaudit
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service"
+)
+
+// DefaultRequiredKeys are enforced when the config declares no required_keys
+var DefaultRequiredKeys = []string{"team", "env", "cost-center"}
+
+// NewService creates an auditor for the tag policy. Keys and values are
+// compared case-insensitively since GCP labels are always lowercase.
+func NewService(cfg model.TaggingConfig) *tagAuditService {
+	requiredKeys := cfg.RequiredKeys
+	if len(requiredKeys) == 0 {
+		requiredKeys = DefaultRequiredKeys
+	}
+
+	allowedValues := make(map[string][]string, len(cfg.AllowedValues))
+	for key, values := range cfg.AllowedValues {
+		allowedValues[strings.ToLower(key)] = values
+	}
+
+	return &tagAuditService{
+		requiredKeys:  requiredKeys,
+		allowedValues: allowedValues,
+	}
+}
+
+func (s *tagAuditService) GetRequiredKeys() []string {
+	return s.requiredKeys
+}
+
+// Audit checks the provider's resources against the policy and, when
+// costService is set, reads spend by each required key for the month to date
+// (the whole previous month on the 1st). Failing to read spend is recorded in
+// SpendError instead of failing the audit.
+func (s *tagAuditService) Audit(ctx context.Context, tagService service.TagService, costService service.CostService) (*model.ProviderTagAudit, error) {
+	resources, err := tagService.GetTaggedResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := &model.ProviderTagAudit{ResourceCount: len(resources)}
+	audit.Violations, audit.NonCompliantResources = s.AuditResources(resources)
+
+	if costService == nil {
+		return audit, nil
+	}
+
+	now := time.Now()
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	lastDay := end.AddDate(0, 0, -1)
+	start := time.Date(lastDay.Year(), lastDay.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	for _, key := range s.requiredKeys {
+		costs, err := costService.GetCostsByTag(ctx, key, start, end)
+		if err != nil {
+			audit.SpendError = err
+			break
+		}
+		audit.Spend = append(audit.Spend, s.AuditSpend(key, costs))
+	}
+
+	return audit, nil
+}
+
+// AuditResources checks every resource against the policy and returns the
+// violations along with the number of resources that have at least one
+func (s *tagAuditService) AuditResources(resources []model.TaggedResource) ([]model.TagViolation, int) {
+	var violations []model.TagViolation
+	nonCompliant := 0
+
+	for _, resource := range resources {
+		found := false
+		for _, key := range s.requiredKeys {
+			value := lookup(resource.Tags, key)
+
+			reason := ""
+			switch {
+			case value == "":
+				reason = model.TagViolationMissing
+			case !s.isAllowed(key, value):
+				reason = model.TagViolationInvalid
+			}
+			if reason == "" {
+				continue
+			}
+
+			found = true
+			violations = append(violations, model.TagViolation{
+				ResourceID:   resource.ID,
+				ResourceType: resource.Type,
+				Key:          key,
+				Reason:       reason,
+				Value:        value,
+			})
+		}
+		if found {
+			nonCompliant++
+		}
+	}
+
+	return violations, nonCompliant
+}
+
+// AuditSpend splits costs grouped by the values of key into untagged spend and
+// spend carrying a value the policy does not allow
+func (s *tagAuditService) AuditSpend(key string, costs *model.CostInfo) model.TagSpend {
+	spend := model.TagSpend{Key: key}
+	if costs == nil {
+		return spend
+	}
+
+	for value, cost := range costs.CostGroup {
+		spend.TotalCost += cost.Amount
+		if cost.Unit != "" {
+			spend.Unit = cost.Unit
+		}
+
+		switch {
+		case value == model.Untagged || strings.TrimSpace(value) == "":
+			spend.UntaggedCost += cost.Amount
+		case !s.isAllowed(key, value):
+			spend.InvalidCost += cost.Amount
+		}
+	}
+
+	return spend
+}
+
+func (s *tagAuditService) isAllowed(key, value string) bool {
+	allowed, ok := s.allowedValues[strings.ToLower(key)]
+	if !ok || len(allowed) == 0 {
+		return true
+	}
+
+	for _, candidate := range allowed {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the trimmed value of key in tags, matching the key
+// case-insensitively
+func lookup(tags map[string]string, key string) string {
+	for tagKey, value := range tags {
+		if strings.EqualFold(tagKey, key) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,21 @@
+package tagaudit
+
+import (
+	"context"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service"
+)
+
+type tagAuditService struct {
+	requiredKeys []string
+	// allowedValues is keyed by the lower-cased tag key
+	allowedValues map[string][]string
+}
+
+type TagAuditService interface {
+	GetRequiredKeys() []string
+	Audit(ctx context.Context, tagService service.TagService, costService service.CostService) (*model.ProviderTagAudit, error)
+	AuditResources(resources []model.TaggedResource) ([]model.TagViolation, int)
+	AuditSpend(key string, costs *model.CostInfo) model.TagSpend
+}
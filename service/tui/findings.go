@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+// findingColumns are the columns of the waste tab, in the order "s" sorts by them
+var findingColumns = []string{"Category", "ID", "Name", "Region", "Detail", "Owner"}
+
+// categoryOrder is the order "c" cycles through the categories
+var categoryOrder = []string{
+	model.WasteUnusedVolumes,
+	model.WasteAttachedVolumes,
+	model.WasteUnusedIPs,
+	model.WasteStoppedInstances,
+	model.WasteExpiringReservations,
+}
+
+var categoryNames = map[string]string{
+	model.WasteUnusedVolumes:        "Unused volume",
+	model.WasteAttachedVolumes:      "Attached volume",
+	model.WasteUnusedIPs:            "Unused IP",
+	model.WasteStoppedInstances:     "Stopped instance",
+	model.WasteExpiringReservations: "Reservation",
+}
+
+// wasteFindings flattens one provider's findings into rows
+func wasteFindings(result model.ProviderWasteResult) []finding {
+	var findings []finding
+	for _, v := range result.UnusedVolumes {
+		findings = append(findings, finding{category: model.WasteUnusedVolumes, id: v.ID, region: v.Region, detail: fmt.Sprintf("%d GiB", v.SizeGB), owner: v.Owner})
+	}
+	for _, v := range result.AttachedVolumes {
+		findings = append(findings, finding{category: model.WasteAttachedVolumes, id: v.ID, region: v.Region, detail: fmt.Sprintf("%d GiB on a stopped instance", v.SizeGB), owner: v.Owner})
+	}
+	for _, ip := range result.UnusedIPs {
+		findings = append(findings, finding{category: model.WasteUnusedIPs, id: ip.Address, name: ip.AllocationID, region: ip.Region, owner: ip.Owner})
+	}
+	for _, inst := range result.StoppedInstances {
+		detail := fmt.Sprintf("stopped %d days", inst.StoppedDays)
+		if inst.StoppedDays < 0 {
+			detail = "stopped, stop time unknown"
+		} else if inst.StoppedAtEstimated {
+			detail = fmt.Sprintf("stopped ~%d days, estimated", inst.StoppedDays)
+		}
+		if inst.InstanceType != "" {
+			detail = inst.InstanceType + ", " + detail
+		}
+		findings = append(findings, finding{category: model.WasteStoppedInstances, id: inst.ID, name: inst.Name, region: inst.Region, detail: detail, owner: inst.Owner})
+	}
+	for _, r := range result.ExpiringReservations {
+		detail := fmt.Sprintf("%s, expires in %d days", r.InstanceType, r.DaysUntilExpiry)
+		if r.Status == "expired" {
+			detail = fmt.Sprintf("%s, expired %d days ago", r.InstanceType, -r.DaysUntilExpiry)
+		}
+		findings = append(findings, finding{category: model.WasteExpiringReservations, id: r.ID, detail: detail})
+	}
+	return findings
+}
+
+// column is the text of one of findingColumns
+func (f finding) column(i int) string {
+	switch i {
+	case 0:
+		return categoryNames[f.category]
+	case 1:
+		return f.id
+	case 2:
+		return f.name
+	case 3:
+		return f.region
+	case 4:
+		return f.detail
+	default:
+		return f.owner
+	}
+}
+
+// matches reports whether any column contains the lowercase text
+func (f finding) matches(text string) bool {
+	for i := range findingColumns {
+		if strings.Contains(strings.ToLower(f.column(i)), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectFindings filters findings by category and text, then sorts them by a column
+func selectFindings(findings []finding, category, text string, sortCol int, desc bool) []finding {
+	text = strings.ToLower(strings.TrimSpace(text))
+
+	var selected []finding
+	for _, f := range findings {
+		if category != "" && f.category != category {
+			continue
+		}
+		if text != "" && !f.matches(text) {
+			continue
+		}
+		selected = append(selected, f)
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		a, b := selected[i].column(sortCol), selected[j].column(sortCol)
+		if desc {
+			return a > b
+		}
+		return a < b
+	})
+	return selected
+}
+
+// nextCategory cycles from all categories through those with findings
+func nextCategory(current string, findings []finding) string {
+	present := make(map[string]bool)
+	for _, f := range findings {
+		present[f.category] = true
+	}
+
+	var categories []string
+	for _, category := range categoryOrder {
+		if present[category] {
+			categories = append(categories, category)
+		}
+	}
+
+	for i, category := range categories {
+		if category == current && i+1 < len(categories) {
+			return categories[i+1]
+		}
+	}
+	if current == "" && len(categories) > 0 {
+		return categories[0]
+	}
+	return ""
+}
@@ -0,0 +1,366 @@
+package tui
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/muesli/termenv"
+)
+
+// tabModes are the report each tab shows
+var tabModes = [...]string{model.CheckCost, model.CheckTrend, model.CheckWaste}
+
+func NewService(report Reporter, drill Driller) *service {
+	return &service{report: report, drill: drill}
+}
+
+// Run shows the UI until the user quits or ctx is cancelled
+func (s *service) Run(ctx context.Context) error {
+	filter := textinput.New()
+	filter.Prompt = "Filter: "
+	filter.Placeholder = "ID, name, region, detail or owner"
+
+	// The table only moves the cursor; letters are left to the UI's own keys
+	keys := table.KeyMap{
+		LineUp:       key.NewBinding(key.WithKeys("up", "k")),
+		LineDown:     key.NewBinding(key.WithKeys("down", "j")),
+		PageUp:       key.NewBinding(key.WithKeys("pgup")),
+		PageDown:     key.NewBinding(key.WithKeys("pgdown")),
+		HalfPageUp:   key.NewBinding(key.WithKeys("ctrl+u")),
+		HalfPageDown: key.NewBinding(key.WithKeys("ctrl+d")),
+		GotoTop:      key.NewBinding(key.WithKeys("home", "g")),
+		GotoBottom:   key.NewBinding(key.WithKeys("end", "G")),
+	}
+
+	a := &app{
+		ctx:     ctx,
+		report:  s.report,
+		drill:   s.drill,
+		filter:  filter,
+		table:   table.New(table.WithFocused(true), table.WithKeyMap(keys), table.WithStyles(tableStyles())),
+		spinner: spinner.New(spinner.WithSpinner(spinner.Dot)),
+	}
+
+	if _, err := tea.NewProgram(a, tea.WithAltScreen(), tea.WithContext(ctx)).Run(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+func (a *app) Init() tea.Cmd {
+	return a.load(tabCosts)
+}
+
+func (a *app) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		a.width, a.height = msg.Width, msg.Height
+		a.refreshTable()
+
+	case reportMsg:
+		r := &a.reports[msg.tab]
+		if msg.gen != r.gen {
+			return a, nil
+		}
+		r.loading = false
+		r.err = msg.err
+		// A failed refresh keeps showing the last report
+		if msg.err == nil {
+			r.result = msg.result
+			r.fetchedAt = time.Now()
+		}
+		a.refreshTable()
+
+	case drillMsg:
+		if a.drilled == nil || msg.gen != a.drilled.gen {
+			return a, nil
+		}
+		a.drilled.loading = false
+		a.drilled.result = &msg.result
+		a.refreshTable()
+
+	case statusMsg:
+		a.status = string(msg)
+
+	case spinner.TickMsg:
+		if !a.loading() {
+			return a, nil
+		}
+		var cmd tea.Cmd
+		a.spinner, cmd = a.spinner.Update(msg)
+		return a, cmd
+
+	case tea.KeyMsg:
+		return a, a.handleKey(msg)
+	}
+
+	return a, nil
+}
+
+func (a *app) handleKey(msg tea.KeyMsg) tea.Cmd {
+	if msg.String() == "ctrl+c" {
+		return tea.Quit
+	}
+
+	if a.filtering {
+		switch msg.String() {
+		case "enter":
+			a.filtering = false
+			a.filter.Blur()
+		case "esc":
+			a.filtering = false
+			a.filter.Blur()
+			a.filter.SetValue("")
+			a.refreshTable()
+		default:
+			var cmd tea.Cmd
+			a.filter, cmd = a.filter.Update(msg)
+			a.table.GotoTop()
+			a.refreshTable()
+			return cmd
+		}
+		a.refreshTable()
+		return nil
+	}
+
+	a.status = ""
+	switch msg.String() {
+	case "q":
+		return tea.Quit
+	case "tab":
+		return a.switchTab((a.tab + 1) % len(a.reports))
+	case "shift+tab":
+		return a.switchTab((a.tab + len(a.reports) - 1) % len(a.reports))
+	case "1", "2", "3":
+		return a.switchTab(int(msg.String()[0] - '1'))
+	case "left", "h":
+		a.switchProvider(-1)
+		return nil
+	case "right", "l":
+		a.switchProvider(1)
+		return nil
+	case "r":
+		if a.tab == tabCosts && a.drilled != nil {
+			return a.loadDrill()
+		}
+		return a.load(a.tab)
+	case "y":
+		if id := a.selectedKey(); id != "" {
+			return copyToClipboard(id)
+		}
+		return nil
+	}
+
+	switch {
+	case a.tab == tabCosts && a.drilled != nil:
+		if cmd, ok := a.handleDrillKey(msg); ok {
+			return cmd
+		}
+	case a.tab == tabCosts:
+		if msg.String() == "enter" {
+			if name := a.selectedKey(); name != "" {
+				a.drilled = &drillView{provider: a.provider, service: name, breakdown: model.BreakdownDaily}
+				a.table.GotoTop()
+				return a.loadDrill()
+			}
+			return nil
+		}
+	case a.tab == tabWaste:
+		if a.handleWasteKey(msg) {
+			return nil
+		}
+	}
+
+	var cmd tea.Cmd
+	a.table, cmd = a.table.Update(msg)
+	return cmd
+}
+
+// handleDrillKey switches the breakdown or the period of a drilled service.
+// It reports whether the key was used.
+func (a *app) handleDrillKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch msg.String() {
+	case "esc", "backspace":
+		a.drilled = nil
+		a.table.GotoTop()
+		a.refreshTable()
+		return nil, true
+	case "d", "t":
+		breakdown := model.BreakdownDaily
+		if msg.String() == "t" {
+			breakdown = model.BreakdownResource
+		}
+		if a.drilled.breakdown == breakdown {
+			return nil, true
+		}
+		a.drilled.breakdown = breakdown
+		a.drilled.result = nil
+		a.table.GotoTop()
+		return a.loadDrill(), true
+	case "p":
+		a.drilled.lastPeriod = !a.drilled.lastPeriod
+		a.drilled.result = nil
+		a.table.GotoTop()
+		return a.loadDrill(), true
+	}
+	return nil, false
+}
+
+// handleWasteKey filters and sorts the findings. It reports whether the key
+// was used.
+func (a *app) handleWasteKey(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "/":
+		a.filtering = true
+		a.filter.Focus()
+	case "esc":
+		a.filter.SetValue("")
+		a.category = ""
+	case "c":
+		a.category = nextCategory(a.category, a.findings())
+	case "s":
+		a.sortCol = (a.sortCol + 1) % len(findingColumns)
+	case "S":
+		a.sortDesc = !a.sortDesc
+	default:
+		return false
+	}
+
+	a.table.GotoTop()
+	a.refreshTable()
+	return true
+}
+
+func (a *app) switchTab(tab int) tea.Cmd {
+	if tab == a.tab || tab < 0 || tab >= len(a.reports) {
+		return nil
+	}
+	a.tab = tab
+	a.table.GotoTop()
+	a.refreshTable()
+
+	if r := a.reports[tab]; r.result == nil && !r.loading {
+		return a.load(tab)
+	}
+	return nil
+}
+
+// switchProvider selects the next or previous provider of the current report
+func (a *app) switchProvider(step int) {
+	providers := a.providers()
+	if len(providers) < 2 {
+		return
+	}
+
+	i := 0
+	for j, p := range providers {
+		if p == a.provider {
+			i = j
+		}
+	}
+	a.provider = providers[(i+step+len(providers))%len(providers)]
+	a.drilled = nil
+	a.table.GotoTop()
+	a.refreshTable()
+}
+
+// load gathers a tab's report again; the current one stays visible meanwhile
+func (a *app) load(tab int) tea.Cmd {
+	r := &a.reports[tab]
+	r.gen++
+	r.loading = true
+	a.refreshTable()
+
+	ctx, report, gen, mode := a.ctx, a.report, r.gen, tabModes[tab]
+	fetch := func() tea.Msg {
+		result, err := report(ctx, mode)
+		return reportMsg{tab: tab, gen: gen, result: result, err: err}
+	}
+	return tea.Batch(fetch, a.spinner.Tick)
+}
+
+// loadDrill breaks down the drilled service over the period of the cost report
+func (a *app) loadDrill() tea.Cmd {
+	d := a.drilled
+	d.gen++
+	d.loading = true
+
+	start, end, ok := a.period(d.provider, d.lastPeriod)
+	if !ok {
+		d.loading = false
+		d.result = &model.ServiceCosts{Provider: d.provider, Service: d.service, Breakdown: d.breakdown}
+		a.refreshTable()
+		return nil
+	}
+	a.refreshTable()
+
+	ctx, drill, gen := a.ctx, a.drill, d.gen
+	provider, serviceName, breakdown := d.provider, d.service, d.breakdown
+	fetch := func() tea.Msg {
+		return drillMsg{gen: gen, result: drill(ctx, provider, serviceName, breakdown, start, end)}
+	}
+	return tea.Batch(fetch, a.spinner.Tick)
+}
+
+// period is the current or the last period of a provider's cost report
+func (a *app) period(provider string, last bool) (time.Time, time.Time, bool) {
+	result := a.costResult(provider)
+	if result == nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	data := result.CurrentMonthData
+	if last {
+		data = result.LastMonthData
+	}
+	if data == nil || data.Start == nil || data.End == nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	start, err := time.Parse("2006-01-02", *data.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	end, err := time.Parse("2006-01-02", *data.End)
+	if err != nil || !end.After(start) {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+func (a *app) loading() bool {
+	for _, r := range a.reports {
+		if r.loading {
+			return true
+		}
+	}
+	return a.drilled != nil && a.drilled.loading
+}
+
+// selectedKey identifies the selected row: a service, a day, a resource or a finding
+func (a *app) selectedKey() string {
+	cursor := a.table.Cursor()
+	if cursor < 0 || cursor >= len(a.keys) {
+		return ""
+	}
+	return a.keys[cursor]
+}
+
+// copyToClipboard copies text to the system clipboard. Without a clipboard
+// tool, such as over SSH, it falls back to the terminal's OSC 52 sequence.
+func copyToClipboard(text string) tea.Cmd {
+	return func() tea.Msg {
+		if err := clipboard.WriteAll(text); err != nil {
+			termenv.NewOutput(os.Stdout).Copy(text)
+		}
+		return statusMsg("Copied " + text)
+	}
+}
@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"context"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+// Reporter gathers one report: model.CheckCost, model.CheckTrend or
+// model.CheckWaste
+type Reporter func(ctx context.Context, mode string) (*model.RunResult, error)
+
+// Driller breaks down one provider's spend on a service in [start, end) by
+// model.BreakdownDaily or model.BreakdownResource
+type Driller func(ctx context.Context, provider, serviceName, breakdown string, start, end time.Time) model.ServiceCosts
+
+type service struct {
+	report Reporter
+	drill  Driller
+}
+
+// Tabs of the UI, in display order
+const (
+	tabCosts = iota
+	tabTrend
+	tabWaste
+)
+
+// app is the bubbletea model of the UI. Reports are kept while they are
+// refreshed, so a refresh updates the view in place.
+type app struct {
+	ctx    context.Context
+	report Reporter
+	drill  Driller
+
+	width  int
+	height int
+
+	tab      int
+	provider string
+	reports  [3]tabReport
+
+	// drilled is the service being drilled into on the costs tab, if any
+	drilled *drillView
+
+	// Waste tab filters and sort order
+	category  string
+	sortCol   int
+	sortDesc  bool
+	filtering bool
+	filter    textinput.Model
+
+	table   table.Model
+	spinner spinner.Model
+
+	// keys identify the table rows for drilling and copying
+	keys []string
+	// info is the text between the tabs and the table
+	info   string
+	status string
+}
+
+// tabReport is the latest report of a tab
+type tabReport struct {
+	result    *model.RunResult
+	err       error
+	loading   bool
+	gen       int
+	fetchedAt time.Time
+}
+
+// drillView is a service's breakdown, over the current or the last period of
+// the cost report
+type drillView struct {
+	provider   string
+	service    string
+	breakdown  string
+	lastPeriod bool
+	result     *model.ServiceCosts
+	loading    bool
+	gen        int
+}
+
+// finding is a waste finding as a row of the waste tab
+type finding struct {
+	category string
+	id       string
+	name     string
+	region   string
+	detail   string
+	owner    string
+}
+
+type reportMsg struct {
+	tab    int
+	gen    int
+	result *model.RunResult
+	err    error
+}
+
+type drillMsg struct {
+	gen    int
+	result model.ServiceCosts
+}
+
+type statusMsg string
+
+type TUIService interface {
+	Run(ctx context.Context) error
+}
@@ -0,0 +1,570 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+var (
+	titleStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	activeTabStyle = lipgloss.NewStyle().Bold(true).Underline(true)
+	mutedStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	errorStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	warningStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+)
+
+var tabTitles = [...]string{"Costs", "Trend", "Waste"}
+
+// Lines above and below the info text and the table
+const (
+	headerLines = 2
+	footerLines = 2
+)
+
+func tableStyles() table.Styles {
+	styles := table.DefaultStyles()
+	styles.Header = styles.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("8")).
+		BorderBottom(true)
+	styles.Selected = styles.Selected.
+		Foreground(lipgloss.Color("0")).
+		Background(lipgloss.Color("12"))
+	return styles
+}
+
+func (a *app) View() string {
+	if a.width == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(a.header())
+	b.WriteString("\n\n")
+	if a.info != "" {
+		b.WriteString(a.info)
+		b.WriteString("\n")
+	}
+	b.WriteString(a.table.View())
+	b.WriteString("\n")
+	b.WriteString(a.footer())
+	return b.String()
+}
+
+func (a *app) header() string {
+	parts := []string{titleStyle.Render("cloud-doctor")}
+
+	var tabs []string
+	for i, title := range tabTitles {
+		label := fmt.Sprintf("%d %s", i+1, title)
+		if i == a.tab {
+			tabs = append(tabs, activeTabStyle.Render(label))
+		} else {
+			tabs = append(tabs, mutedStyle.Render(label))
+		}
+	}
+	parts = append(parts, strings.Join(tabs, "  "))
+
+	var providers []string
+	for _, p := range a.providers() {
+		if p == a.provider {
+			providers = append(providers, activeTabStyle.Render(strings.ToUpper(p)))
+		} else {
+			providers = append(providers, mutedStyle.Render(strings.ToUpper(p)))
+		}
+	}
+	if len(providers) > 0 {
+		parts = append(parts, "‹ "+strings.Join(providers, " ")+" ›")
+	}
+
+	if a.loading() {
+		parts = append(parts, a.spinner.View()+" loading")
+	}
+	return " " + strings.Join(parts, "   ")
+}
+
+func (a *app) footer() string {
+	status := a.status
+	if status == "" {
+		if r := a.reports[a.tab]; !r.fetchedAt.IsZero() {
+			status = "Updated " + r.fetchedAt.Format("15:04:05")
+		}
+	}
+
+	var help string
+	switch {
+	case a.filtering:
+		help = "type to filter · enter apply · esc clear"
+	case a.tab == tabCosts && a.drilled != nil:
+		help = "d daily · t top resources · p other period · y copy · r refresh · esc back · q quit"
+	case a.tab == tabCosts:
+		help = "↑/↓ select · enter drill down · ←/→ provider · tab switch · y copy · r refresh · q quit"
+	case a.tab == tabTrend:
+		help = "←/→ provider · tab switch · r refresh · q quit"
+	default:
+		help = "/ filter · c category · s sort · S reverse · y copy ID · ←/→ provider · tab switch · r refresh · q quit"
+	}
+
+	return " " + status + "\n " + mutedStyle.Render(help)
+}
+
+// refreshTable rebuilds the info text and the table of the current view
+func (a *app) refreshTable() {
+	if providers := a.providers(); len(providers) > 0 && !contains(providers, a.provider) {
+		a.provider = providers[0]
+	}
+
+	var cols []table.Column
+	var rows []table.Row
+	var info []string
+	switch {
+	case a.tab == tabCosts && a.drilled != nil:
+		cols, rows, info = a.drillTable()
+	case a.tab == tabCosts:
+		cols, rows, info = a.costsTable()
+	case a.tab == tabTrend:
+		cols, rows, info = a.trendTable()
+	default:
+		cols, rows, info = a.wasteTable()
+	}
+	a.info = strings.Join(info, "\n")
+
+	// Rows are cleared first so they never have more cells than the columns
+	cursor := a.table.Cursor()
+	a.table.SetRows(nil)
+	a.table.SetColumns(cols)
+	a.table.SetRows(rows)
+	a.table.SetCursor(cursor)
+
+	infoLines := 0
+	if a.info != "" {
+		infoLines = lipgloss.Height(a.info)
+	}
+	a.table.SetHeight(max(a.height-headerLines-infoLines-footerLines-1, 3))
+}
+
+// reportInfo describes a report that has not arrived or failed to refresh
+func (a *app) reportInfo(tab int) []string {
+	r := a.reports[tab]
+	switch {
+	case r.err != nil:
+		return []string{errorStyle.Render(" Error: " + r.err.Error())}
+	case r.result == nil && r.loading:
+		return []string{mutedStyle.Render(" Collecting the report…")}
+	}
+	return nil
+}
+
+func (a *app) costsTable() ([]table.Column, []table.Row, []string) {
+	cols := a.layout([]table.Column{
+		{Title: "Service"},
+		{Title: "Last period", Width: 14},
+		{Title: "Current period", Width: 14},
+		{Title: "Change", Width: 8},
+	})
+	a.keys = nil
+
+	info := a.reportInfo(tabCosts)
+	result := a.costResult(a.provider)
+	if result == nil {
+		return cols, nil, info
+	}
+	if result.Error != nil {
+		return cols, nil, append(info, errorStyle.Render(fmt.Sprintf(" %s failed: %v", strings.ToUpper(result.Provider), result.Error)))
+	}
+
+	line := fmt.Sprintf(" Account %s · current %s (%s) · last %s (%s)",
+		result.AccountID,
+		result.CurrentTotalCost, periodText(result.CurrentMonthData),
+		result.LastTotalCost, periodText(result.LastMonthData))
+	if result.CachedAt != nil {
+		line += mutedStyle.Render(" · cached " + result.CachedAt.Format("15:04"))
+	}
+	info = append(info, line)
+	info = append(info, budgetLines(result.Budgets)...)
+	info = append(info, warningLines(result.Warnings)...)
+
+	current, last := model.CostGroup{}, model.CostGroup{}
+	if result.CurrentMonthData != nil {
+		current = result.CurrentMonthData.CostGroup
+	}
+	if result.LastMonthData != nil {
+		last = result.LastMonthData.CostGroup
+	}
+
+	names := make(map[string]bool)
+	for name := range current {
+		names[name] = true
+	}
+	for name := range last {
+		names[name] = true
+	}
+	for name := range names {
+		a.keys = append(a.keys, name)
+	}
+	sort.Slice(a.keys, func(i, j int) bool {
+		ci, cj := current[a.keys[i]].Amount, current[a.keys[j]].Amount
+		if ci != cj {
+			return ci > cj
+		}
+		return a.keys[i] < a.keys[j]
+	})
+
+	rows := make([]table.Row, 0, len(a.keys))
+	for _, name := range a.keys {
+		cur, prev := current[name].Amount, last[name].Amount
+		rows = append(rows, table.Row{
+			name,
+			alignRight(fmt.Sprintf("%.2f", prev), cols[1].Width),
+			alignRight(fmt.Sprintf("%.2f", cur), cols[2].Width),
+			alignRight(changeText(cur, prev), cols[3].Width),
+		})
+	}
+	return cols, rows, info
+}
+
+func (a *app) drillTable() ([]table.Column, []table.Row, []string) {
+	d := a.drilled
+	a.keys = nil
+
+	label, period := "Daily costs", "current period"
+	keyTitle := "Day"
+	if d.breakdown == model.BreakdownResource {
+		label, keyTitle = "Top resources", "Resource"
+	}
+	if d.lastPeriod {
+		period = "last period"
+	}
+
+	var cols []table.Column
+	if d.breakdown == model.BreakdownResource {
+		cols = a.layout([]table.Column{{Title: keyTitle}, {Title: "Amount", Width: 14}, {Title: "Share", Width: 7}})
+	} else {
+		cols = a.layout([]table.Column{{Title: keyTitle, Width: 10}, {Title: "Amount", Width: 14}, {Title: ""}})
+	}
+
+	info := []string{fmt.Sprintf(" %s › %s › %s, %s", strings.ToUpper(d.provider), d.service, label, period)}
+	switch {
+	case d.result == nil:
+		return cols, nil, append(info, mutedStyle.Render(" Collecting the breakdown…"))
+	case d.result.Error != nil:
+		return cols, nil, append(info, errorStyle.Render(" Error: "+d.result.Error.Error()))
+	case d.result.Costs == nil:
+		return cols, nil, append(info, mutedStyle.Render(" The period has no days yet; press p for the other period"))
+	}
+
+	costs := d.result.Costs
+	var total, largest float64
+	var unit string
+	for key, cost := range costs.CostGroup {
+		a.keys = append(a.keys, key)
+		total += cost.Amount
+		largest = max(largest, cost.Amount)
+		unit = cost.Unit
+	}
+
+	if d.breakdown == model.BreakdownResource {
+		sort.Slice(a.keys, func(i, j int) bool {
+			ai, aj := costs.CostGroup[a.keys[i]].Amount, costs.CostGroup[a.keys[j]].Amount
+			if ai != aj {
+				return ai > aj
+			}
+			return a.keys[i] < a.keys[j]
+		})
+	} else {
+		sort.Strings(a.keys)
+	}
+
+	info = append(info, fmt.Sprintf(" %s · total %.2f %s across %d %s",
+		periodText(costs), total, unit, len(a.keys), strings.ToLower(keyTitle)+"s"))
+	if d.breakdown == model.BreakdownResource && d.provider == "aws" {
+		info = append(info, mutedStyle.Render(" Cost Explorer keeps resource-level data for the last 14 days only"))
+	}
+	info = append(info, warningLines(d.result.Warnings)...)
+
+	rows := make([]table.Row, 0, len(a.keys))
+	for _, key := range a.keys {
+		amount := costs.CostGroup[key].Amount
+		last := bar(amount, largest, cols[2].Width)
+		if d.breakdown == model.BreakdownResource {
+			last = alignRight(fmt.Sprintf("%.1f%%", amount/total*100), cols[2].Width)
+		}
+		rows = append(rows, table.Row{key, alignRight(fmt.Sprintf("%.2f", amount), cols[1].Width), last})
+	}
+	return cols, rows, info
+}
+
+func (a *app) trendTable() ([]table.Column, []table.Row, []string) {
+	cols := a.layout([]table.Column{
+		{Title: "Month", Width: 7},
+		{Title: "Total", Width: 14},
+		{Title: "Change", Width: 8},
+		{Title: ""},
+	})
+	a.keys = nil
+
+	info := a.reportInfo(tabTrend)
+	result := a.trendResult(a.provider)
+	if result == nil {
+		return cols, nil, info
+	}
+	if result.Error != nil {
+		return cols, nil, append(info, errorStyle.Render(fmt.Sprintf(" %s failed: %v", strings.ToUpper(result.Provider), result.Error)))
+	}
+
+	var largest float64
+	var unit string
+	totals := make([]float64, len(result.TrendData))
+	for i, month := range result.TrendData {
+		totals[i] = month.CostGroup["Total"].Amount
+		largest = max(largest, totals[i])
+		if cost, ok := month.CostGroup["Total"]; ok && unit == "" {
+			unit = cost.Unit
+		}
+	}
+
+	line := fmt.Sprintf(" Account %s · monthly totals in %s", result.AccountID, unit)
+	if result.CachedAt != nil {
+		line += mutedStyle.Render(" · cached " + result.CachedAt.Format("15:04"))
+	}
+	info = append(info, line)
+	info = append(info, warningLines(result.Warnings)...)
+
+	rows := make([]table.Row, 0, len(result.TrendData))
+	for i, month := range result.TrendData {
+		label := ""
+		if month.Start != nil && len(*month.Start) >= 7 {
+			label = (*month.Start)[:7]
+		}
+		change := ""
+		if i > 0 {
+			change = changeText(totals[i], totals[i-1])
+		}
+
+		a.keys = append(a.keys, label)
+		rows = append(rows, table.Row{
+			label,
+			alignRight(fmt.Sprintf("%.2f", totals[i]), cols[1].Width),
+			alignRight(change, cols[2].Width),
+			bar(totals[i], largest, cols[3].Width),
+		})
+	}
+	return cols, rows, info
+}
+
+func (a *app) wasteTable() ([]table.Column, []table.Row, []string) {
+	cols := a.layout([]table.Column{
+		{Title: "Category", Width: 16},
+		{Title: "ID"},
+		{Title: "Name"},
+		{Title: "Region", Width: 14},
+		{Title: "Detail"},
+		{Title: "Owner", Width: 14},
+	})
+	arrow := " ↑"
+	if a.sortDesc {
+		arrow = " ↓"
+	}
+	cols[a.sortCol].Title += arrow
+	a.keys = nil
+
+	info := a.reportInfo(tabWaste)
+	result := a.wasteResult(a.provider)
+	if result == nil {
+		return cols, nil, info
+	}
+	if result.Error != nil {
+		return cols, nil, append(info, errorStyle.Render(fmt.Sprintf(" %s failed: %v", strings.ToUpper(result.Provider), result.Error)))
+	}
+
+	findings := wasteFindings(*result)
+	counts := make(map[string]int)
+	for _, f := range findings {
+		counts[f.category]++
+	}
+	var summary []string
+	for _, category := range categoryOrder {
+		summary = append(summary, fmt.Sprintf("%s %d", categoryNames[category], counts[category]))
+	}
+	info = append(info, fmt.Sprintf(" Account %s · %s", result.AccountID, strings.Join(summary, " · ")))
+
+	category := "all categories"
+	if a.category != "" {
+		category = categoryNames[a.category]
+	}
+	if a.filtering {
+		info = append(info, " "+a.filter.View())
+	} else {
+		line := " Category: " + category
+		if text := a.filter.Value(); text != "" {
+			line += fmt.Sprintf(" · filter: %q", text)
+		}
+		info = append(info, mutedStyle.Render(line))
+	}
+	info = append(info, warningLines(result.Warnings)...)
+
+	selected := selectFindings(findings, a.category, a.filter.Value(), a.sortCol, a.sortDesc)
+	rows := make([]table.Row, 0, len(selected))
+	for _, f := range selected {
+		row := make(table.Row, len(findingColumns))
+		for i := range findingColumns {
+			row[i] = f.column(i)
+		}
+		a.keys = append(a.keys, f.id)
+		rows = append(rows, row)
+	}
+	return cols, rows, info
+}
+
+// layout sizes the columns to the window: fixed columns keep their width and
+// the others, with no width, share what is left
+func (a *app) layout(cols []table.Column) []table.Column {
+	free := a.width
+	flexible := 0
+	for _, col := range cols {
+		// Each cell is padded by one space on both sides
+		free -= 2
+		if col.Width == 0 {
+			flexible++
+		} else {
+			free -= col.Width
+		}
+	}
+
+	for i := range cols {
+		if cols[i].Width == 0 {
+			cols[i].Width = max(free/flexible, 8)
+		}
+	}
+	return cols
+}
+
+// providers lists the providers of the current tab's report
+func (a *app) providers() []string {
+	result := a.reports[a.tab].result
+	if result == nil {
+		return nil
+	}
+
+	var providers []string
+	for _, r := range result.Costs {
+		providers = append(providers, r.Provider)
+	}
+	for _, r := range result.Waste {
+		providers = append(providers, r.Provider)
+	}
+	return providers
+}
+
+func (a *app) costResult(provider string) *model.ProviderCostResult {
+	return findCostResult(a.reports[tabCosts].result, provider)
+}
+
+func (a *app) trendResult(provider string) *model.ProviderCostResult {
+	return findCostResult(a.reports[tabTrend].result, provider)
+}
+
+func (a *app) wasteResult(provider string) *model.ProviderWasteResult {
+	result := a.reports[tabWaste].result
+	if result == nil {
+		return nil
+	}
+	for i := range result.Waste {
+		if result.Waste[i].Provider == provider {
+			return &result.Waste[i]
+		}
+	}
+	return nil
+}
+
+// findings are the selected provider's waste findings, before filtering
+func (a *app) findings() []finding {
+	result := a.wasteResult(a.provider)
+	if result == nil {
+		return nil
+	}
+	return wasteFindings(*result)
+}
+
+func findCostResult(result *model.RunResult, provider string) *model.ProviderCostResult {
+	if result == nil {
+		return nil
+	}
+	for i := range result.Costs {
+		if result.Costs[i].Provider == provider {
+			return &result.Costs[i]
+		}
+	}
+	return nil
+}
+
+func budgetLines(budgets []model.BudgetStatus) []string {
+	if len(budgets) == 0 {
+		return nil
+	}
+
+	var parts []string
+	for _, b := range budgets {
+		text := fmt.Sprintf("%s %.2f/%.2f %s", b.Name, b.Actual, b.Amount, b.Unit)
+		switch b.Status {
+		case model.BudgetBreach:
+			parts = append(parts, errorStyle.Render(text))
+		case model.BudgetWarn:
+			parts = append(parts, warningStyle.Render(text))
+		default:
+			parts = append(parts, text)
+		}
+	}
+	return []string{" Budgets: " + strings.Join(parts, " · ")}
+}
+
+func warningLines(warnings []model.Warning) []string {
+	var lines []string
+	for _, w := range warnings {
+		lines = append(lines, warningStyle.Render(" ⚠ "+w.String()))
+	}
+	return lines
+}
+
+func periodText(data *model.CostInfo) string {
+	if data == nil || data.Start == nil || data.End == nil {
+		return "no data"
+	}
+	return *data.Start + " → " + *data.End
+}
+
+func changeText(current, previous float64) string {
+	switch {
+	case previous == 0 && current == 0:
+		return ""
+	case previous == 0:
+		return "new"
+	}
+	return fmt.Sprintf("%+.0f%%", (current-previous)/previous*100)
+}
+
+// bar draws amount as a share of largest across width cells
+func bar(amount, largest float64, width int) string {
+	if largest <= 0 || amount <= 0 {
+		return ""
+	}
+	return strings.Repeat("█", max(int(amount/largest*float64(width)), 1))
+}
+
+func alignRight(text string, width int) string {
+	return fmt.Sprintf("%*s", width, text)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
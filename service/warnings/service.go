@@ -0,0 +1,35 @@
+package warnings
+
+import (
+	"context"
+
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+func NewCollector() *collector {
+	return &collector{}
+}
+
+// WithCollector returns a context whose services report warnings to c
+func WithCollector(ctx context.Context, c Collector) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// Add reports a warning to the collector attached to ctx, if any
+func Add(ctx context.Context, warning model.Warning) {
+	if c, ok := ctx.Value(contextKey{}).(Collector); ok {
+		c.Add(warning)
+	}
+}
+
+func (c *collector) Add(warning model.Warning) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warnings = append(c.warnings, warning)
+}
+
+func (c *collector) List() []model.Warning {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]model.Warning(nil), c.warnings...)
+}
@@ -0,0 +1,20 @@
+package warnings
+
+import (
+	"sync"
+
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+type collector struct {
+	mu       sync.Mutex
+	warnings []model.Warning
+}
+
+// Collector gathers warnings for partial results during a single collection
+type Collector interface {
+	Add(warning model.Warning)
+	List() []model.Warning
+}
+
+type contextKey struct{}
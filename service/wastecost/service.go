@@ -0,0 +1,76 @@
+package wastecost
+
+import (
+	"fmt"
+
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+// Rough list prices used when the config file sets none: per GiB-month of
+// storage and per IP address a month, in USD
+const (
+	DefaultVolumeGBPrice = 0.08
+	DefaultIPMonthPrice  = 3.65
+)
+
+// Prices estimate what waste findings cost a month. A zero price leaves that
+// kind of waste unpriced.
+type Prices struct {
+	VolumeGBPrice float64
+	IPMonthPrice  float64
+}
+
+// NewPrices reads the waste prices of the metrics config, using the defaults
+// for prices it leaves unset
+func NewPrices(cfg model.MetricsConfig) (Prices, error) {
+	prices := Prices{VolumeGBPrice: DefaultVolumeGBPrice, IPMonthPrice: DefaultIPMonthPrice}
+
+	configured := []struct {
+		name  string
+		value *float64
+		dest  *float64
+	}{
+		{"volume_gb_price", cfg.VolumeGBPrice, &prices.VolumeGBPrice},
+		{"ip_month_price", cfg.IPMonthPrice, &prices.IPMonthPrice},
+	}
+	for _, c := range configured {
+		if c.value == nil {
+			continue
+		}
+		if *c.value < 0 {
+			return Prices{}, fmt.Errorf("invalid metrics %s %g: must not be negative", c.name, *c.value)
+		}
+		*c.dest = *c.value
+	}
+
+	return prices, nil
+}
+
+// ByCategory estimates the monthly USD cost of a provider's unused volumes,
+// volumes of stopped instances and unused IP addresses. Stopped instances and
+// reservations have no estimate and are left out.
+func (p Prices) ByCategory(waste model.ProviderWasteResult) map[string]float64 {
+	var volumeGB int32
+	for _, v := range waste.UnusedVolumes {
+		volumeGB += v.SizeGB
+	}
+	var attachedGB int32
+	for _, v := range waste.AttachedVolumes {
+		attachedGB += v.SizeGB
+	}
+
+	return map[string]float64{
+		model.FindingUnusedVolume:   float64(volumeGB) * p.VolumeGBPrice,
+		model.FindingAttachedVolume: float64(attachedGB) * p.VolumeGBPrice,
+		model.FindingUnusedIP:       float64(len(waste.UnusedIPs)) * p.IPMonthPrice,
+	}
+}
+
+// Total estimates the monthly USD cost of all of a provider's priced findings
+func (p Prices) Total(waste model.ProviderWasteResult) float64 {
+	var total float64
+	for _, cost := range p.ByCategory(waste) {
+		total += cost
+	}
+	return total
+}
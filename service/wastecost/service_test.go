@@ -0,0 +1,56 @@
+package wastecost
+
+import (
+	"testing"
+
+	"github.com/elC0mpa/aws-doctor/model"
+)
+
+func TestNewPrices(t *testing.T) {
+	zero, custom, negative := 0.0, 0.1, -1.0
+
+	tests := []struct {
+		name    string
+		cfg     model.MetricsConfig
+		want    Prices
+		wantErr bool
+	}{
+		{
+			name: "unset prices use the defaults",
+			want: Prices{VolumeGBPrice: DefaultVolumeGBPrice, IPMonthPrice: DefaultIPMonthPrice},
+		},
+		{
+			name: "zero is kept",
+			cfg:  model.MetricsConfig{VolumeGBPrice: &zero, IPMonthPrice: &zero},
+			want: Prices{},
+		},
+		{
+			name: "one price set",
+			cfg:  model.MetricsConfig{VolumeGBPrice: &custom},
+			want: Prices{VolumeGBPrice: custom, IPMonthPrice: DefaultIPMonthPrice},
+		},
+		{
+			name:    "negative price",
+			cfg:     model.MetricsConfig{IPMonthPrice: &negative},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewPrices(tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NewPrices() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewPrices() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("NewPrices() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
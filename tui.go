@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/elC0mpa/aws-doctor/service/apierror"
+	"github.com/elC0mpa/aws-doctor/service/cache"
+	"github.com/elC0mpa/aws-doctor/service/engine"
+	"github.com/elC0mpa/aws-doctor/service/tui"
+	"github.com/elC0mpa/aws-doctor/service/warnings"
+)
+
+// runTUI opens the interactive terminal UI for the providers selected by the
+// flags. Reports are gathered with the same collectors as the daemon and the
+// REST API, so the cost cache serves repeated views and refreshes.
+func runTUI(flags model.Flags, cfg *model.Config, engineService engine.EngineService) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report := func(ctx context.Context, mode string) (*model.RunResult, error) {
+		return collectCheck(ctx, flags, cfg, engineService, model.ScheduledCheck{Mode: mode})
+	}
+
+	drill := func(ctx context.Context, provider, serviceName, breakdown string, start, end time.Time) model.ServiceCosts {
+		return collectServiceCosts(ctx, engineService, flagAccount(flags, provider), serviceName, breakdown, start, end)
+	}
+
+	return tui.NewService(report, drill).Run(ctx)
+}
+
+// collectServiceCosts breaks down one service's spend in [start, end) for the drill-down view
+func collectServiceCosts(ctx context.Context, engineService engine.EngineService, account engine.Account, serviceName, breakdown string, start, end time.Time) (result model.ServiceCosts) {
+	result = model.ServiceCosts{Provider: account.Provider, Service: serviceName, Breakdown: breakdown}
+
+	collector := warnings.NewCollector()
+	ctx = warnings.WithCollector(ctx, collector)
+	defer func() {
+		result.Warnings = collector.List()
+		result.Error = apierror.Classify(account.Provider, result.Error)
+	}()
+
+	session, err := engineService.Open(ctx, account)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	defer session.Close()
+
+	accountInfo, err := session.Identity().GetAccountInfo(ctx)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	result.AccountID = accountInfo.AccountID
+
+	costService, err := session.Costs(ctx)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	costs, err := costService.GetServiceCosts(ctx, serviceName, breakdown, start, end)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	result.Costs = costs
+	result.CachedAt = cache.CachedAt(costService)
+
+	return result
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// DrawBudgetTable displays month-to-date and forecast spend against each
+// budget. Nothing is drawn when no budgets apply.
+func DrawBudgetTable(statuses []model.BudgetStatus) {
+	if len(statuses) == 0 {
+		return
+	}
+
+	tw := table.NewWriter()
+	tw.SetOutputMirror(os.Stdout)
+	tw.SetTitle("Budgets")
+	tw.AppendHeader(table.Row{"Provider", "Budget", "Scope", "Amount", "Actual", "Forecast", "Used", "Status"})
+	tw.SetStyle(table.StyleRounded)
+
+	tw.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 4, Align: text.AlignRight},
+		{Number: 5, Align: text.AlignRight},
+		{Number: 6, Align: text.AlignRight},
+		{Number: 7, Align: text.AlignCenter},
+		{Number: 8, Align: text.AlignCenter},
+	})
+
+	for _, status := range statuses {
+		name := status.Name
+		if status.Imported {
+			name += text.FgHiBlack.Sprint(" (imported)")
+		}
+
+		actual, forecast, used := "-", "-", "-"
+		if status.Status != model.BudgetUnknown {
+			actual = fmt.Sprintf("%.2f %s", status.Actual, status.Unit)
+			forecast = fmt.Sprintf("%.2f %s", status.Forecast, status.Unit)
+			if status.Amount > 0 {
+				used = fmt.Sprintf("%.1f%%", status.Actual/status.Amount*100)
+			}
+		}
+
+		tw.AppendRow(table.Row{
+			text.FgHiCyan.Sprint(strings.ToUpper(status.Provider)),
+			name,
+			status.Scope,
+			fmt.Sprintf("%.2f %s", status.Amount, status.Unit),
+			actual,
+			forecast,
+			used,
+			formatBudgetStatus(status.Status),
+		})
+	}
+
+	tw.Render()
+}
+
+// budgetSummary counts a provider's budgets by status, worst first
+func budgetSummary(statuses []model.BudgetStatus) string {
+	counts := make(map[string]int)
+	for _, status := range statuses {
+		counts[status.Status]++
+	}
+
+	var parts []string
+	for _, s := range []string{model.BudgetBreach, model.BudgetWarn, model.BudgetUnknown, model.BudgetOK} {
+		if counts[s] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[s], formatBudgetStatus(s)))
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatBudgetStatus(status string) string {
+	switch status {
+	case model.BudgetBreach:
+		return text.FgHiRed.Sprint("breach")
+	case model.BudgetWarn:
+		return text.FgHiYellow.Sprint("warn")
+	case model.BudgetOK:
+		return text.FgHiGreen.Sprint("ok")
+	default:
+		return text.FgHiBlack.Sprint("n/a")
+	}
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// DrawCacheNotice tells the user that a provider's costs were served from the local cache
+func DrawCacheNotice(provider string, cachedAt *time.Time) {
+	if cachedAt == nil {
+		return
+	}
+
+	fmt.Printf(" %s %s %s\n",
+		text.FgHiCyan.Sprint("⚡"),
+		text.FgHiYellow.Sprint(strings.ToUpper(provider)),
+		text.FgHiBlack.Sprintf("costs served from cache (fetched %s, %s ago); use --no-cache to refresh",
+			cachedAt.Local().Format("2006-01-02 15:04"),
+			time.Since(*cachedAt).Round(time.Minute)))
+}
@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// DrawChargebackReport displays each provider's allocation source followed by
+// the spend per team in the reporting currency
+func DrawChargebackReport(report model.ChargebackReport) {
+	fmt.Printf("\n%s\n", text.FgHiWhite.Sprint(" 💳 CHARGEBACK REPORT"))
+	fmt.Println(text.FgHiBlue.Sprint(" ------------------------------------------------"))
+	fmt.Printf(" Period: %s to %s (%s)\n", report.Start, report.End, report.Currency)
+
+	drawChargebackSourceTable(report.Providers)
+	drawTeamChargeTable(report)
+
+	for _, result := range report.Providers {
+		if result.Error != nil {
+			fmt.Printf("\n %s %s: %s\n",
+				text.FgHiRed.Sprint("⚠"),
+				text.FgHiYellow.Sprint(strings.ToUpper(result.Provider)),
+				text.FgRed.Sprint(result.Error.Error()))
+			continue
+		}
+		if result.ConversionError != nil {
+			fmt.Printf(" %s %s excluded from teams: %s\n",
+				text.FgHiYellow.Sprint("*"),
+				strings.ToUpper(result.Provider),
+				result.ConversionError.Error())
+		}
+		DrawCacheNotice(result.Provider, result.CachedAt)
+		DrawWarnings(result.Provider, result.Warnings)
+	}
+}
+
+func drawChargebackSourceTable(results []model.ProviderChargeback) {
+	tw := table.NewWriter()
+	tw.SetOutputMirror(os.Stdout)
+	tw.SetTitle("Spend by Provider")
+	tw.AppendHeader(table.Row{"Provider", "Account/Project ID", "Allocated By", "Spend", "Untagged"})
+	tw.SetStyle(table.StyleRounded)
+
+	tw.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 4, Align: text.AlignRight},
+		{Number: 5, Align: text.AlignRight},
+	})
+
+	for _, result := range results {
+		if result.Error != nil {
+			tw.AppendRow(table.Row{
+				text.FgHiYellow.Sprint(strings.ToUpper(result.Provider)),
+				text.FgRed.Sprint("Error"),
+				formatAllocationSource(result.Source),
+				"-",
+				text.FgRed.Sprint("Failed to retrieve"),
+			})
+			continue
+		}
+
+		var total, untagged float64
+		if result.Costs != nil {
+			for value, cost := range result.Costs.CostGroup {
+				total += cost.Amount
+				if value == model.Untagged {
+					untagged += cost.Amount
+				}
+			}
+		}
+
+		tw.AppendRow(table.Row{
+			text.FgHiCyan.Sprint(strings.ToUpper(result.Provider)),
+			result.AccountID,
+			formatAllocationSource(result.Source),
+			fmt.Sprintf("%.2f %s", total, result.Currency),
+			fmt.Sprintf("%.2f %s", untagged, result.Currency),
+		})
+	}
+
+	tw.Render()
+}
+
+func drawTeamChargeTable(report model.ChargebackReport) {
+	providers := chargebackProviders(report.Providers)
+
+	tw := table.NewWriter()
+	tw.SetOutputMirror(os.Stdout)
+	tw.SetTitle(fmt.Sprintf("Spend by Team (%s)", report.Currency))
+	header := table.Row{"Team"}
+	for _, provider := range providers {
+		header = append(header, strings.ToUpper(provider))
+	}
+	header = append(header, "Shared", "Unallocated", "Total")
+	tw.AppendHeader(header)
+	tw.SetStyle(table.StyleRounded)
+
+	configs := make([]table.ColumnConfig, 0, len(providers)+3)
+	for i := 2; i <= len(providers)+4; i++ {
+		configs = append(configs, table.ColumnConfig{Number: i, Align: text.AlignRight})
+	}
+	tw.SetColumnConfigs(configs)
+
+	var grandTotal float64
+	for _, team := range report.Teams {
+		name := text.FgHiCyan.Sprint(team.Team)
+		if team.Team == model.UnallocatedTeam {
+			name = text.FgHiYellow.Sprint(team.Team)
+		}
+
+		row := table.Row{name}
+		for _, provider := range providers {
+			row = append(row, fmt.Sprintf("%.2f", team.Direct[provider]))
+		}
+		row = append(row,
+			fmt.Sprintf("%.2f", team.Shared),
+			fmt.Sprintf("%.2f", team.Unallocated),
+			text.FgHiWhite.Sprintf("%.2f", team.Total))
+		tw.AppendRow(row)
+		grandTotal += team.Total
+	}
+
+	tw.AppendSeparator()
+	totalRow := table.Row{text.FgHiWhite.Sprint("TOTAL")}
+	for range providers {
+		totalRow = append(totalRow, "")
+	}
+	totalRow = append(totalRow, "", "", text.FgHiWhite.Sprintf("%.2f %s", grandTotal, report.Currency))
+	tw.AppendRow(totalRow)
+
+	tw.Render()
+}
+
+// WriteChargebackCSV writes one row per team with its spend per provider,
+// shared and unallocated portions and total in the reporting currency
+func WriteChargebackCSV(w io.Writer, report model.ChargebackReport) error {
+	providers := chargebackProviders(report.Providers)
+
+	writer := csv.NewWriter(w)
+	header := []string{"period_start", "period_end", "currency", "team"}
+	header = append(header, providers...)
+	header = append(header, "shared", "unallocated", "total")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, team := range report.Teams {
+		record := []string{report.Start, report.End, report.Currency, team.Team}
+		for _, provider := range providers {
+			record = append(record, fmt.Sprintf("%.2f", team.Direct[provider]))
+		}
+		record = append(record,
+			fmt.Sprintf("%.2f", team.Shared),
+			fmt.Sprintf("%.2f", team.Unallocated),
+			fmt.Sprintf("%.2f", team.Total))
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// chargebackProviders lists the providers that contributed spend, in display order
+func chargebackProviders(results []model.ProviderChargeback) []string {
+	var providers []string
+	for _, result := range results {
+		if result.Error == nil && result.ConversionError == nil {
+			providers = append(providers, result.Provider)
+		}
+	}
+	return providers
+}
+
+func formatAllocationSource(source model.AllocationSource) string {
+	if source.Dimension == model.DimensionTag {
+		return fmt.Sprintf("tag %s", source.Key)
+	}
+	return strings.ReplaceAll(source.Dimension, "_", " ")
+}
+
+func SortProviderChargebacks(results []model.ProviderChargeback) {
+	providerOrder := map[string]int{"aws": 1, "gcp": 2, "azure": 3}
+	sort.Slice(results, func(i, j int) bool {
+		return providerOrder[results[i].Provider] < providerOrder[results[j].Provider]
+	})
+}
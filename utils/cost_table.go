@@ -147,18 +147,9 @@ func mergeCostServices(lastMonthGroups, currentMonthGroups *model.CostGroup) []m
 }
 
 func populateFirstRow(lastTotalCost, currentTotalCost string) table.Row {
-	currentTotalSplitted := strings.Split(currentTotalCost, " ")
-	lastTotalSplitted := strings.Split(lastTotalCost, " ")
-
-	currentTotalAmount, err := strconv.ParseFloat(currentTotalSplitted[0], 64)
-	if err != nil {
-		panic("Error parsing current month total cost")
-	}
-
-	lastTotalAmount, err := strconv.ParseFloat(lastTotalSplitted[0], 64)
-	if err != nil {
-		panic("Error parsing last month total cost")
-	}
+	// Totals are "<amount> <unit>"; anything unparseable is shown as-is and counted as zero
+	currentTotalAmount, currentUnit := splitTotalCost(currentTotalCost)
+	lastTotalAmount, _ := splitTotalCost(lastTotalCost)
 
 	difference := currentTotalAmount - lastTotalAmount
 
@@ -166,17 +157,34 @@ func populateFirstRow(lastTotalCost, currentTotalCost string) table.Row {
 	row[0] = text.FgHiGreen.Sprint("Total Costs")
 	row[1] = text.FgHiYellow.Sprintf("%s", lastTotalCost)
 	row[2] = text.FgHiGreen.Sprintf("%s", currentTotalCost)
-	row[3] = text.FgHiGreen.Sprintf("%.2f %s", difference, currentTotalSplitted[1])
+	row[3] = text.FgHiGreen.Sprintf("%.2f %s", difference, currentUnit)
 
 	if difference > 0 {
 		row[2] = text.FgHiRed.Sprintf("%s", currentTotalCost)
 		row[0] = text.FgHiRed.Sprintf("Total Costs")
-		row[3] = text.FgHiRed.Sprintf("%.2f %s", difference, currentTotalSplitted[1])
+		row[3] = text.FgHiRed.Sprintf("%.2f %s", difference, currentUnit)
 	}
 
 	return row
 }
 
+func splitTotalCost(totalCost string) (float64, string) {
+	parts := strings.Fields(totalCost)
+	if len(parts) == 0 {
+		return 0, ""
+	}
+
+	amount, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0, ""
+	}
+
+	if len(parts) < 2 {
+		return amount, ""
+	}
+	return amount, parts[1]
+}
+
 func populateRow(lastMonthGroups model.CostInfo, currentMonthGroup model.ServiceCost) table.Row {
 	row := make(table.Row, 4)
 
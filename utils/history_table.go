@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+var findingCategoryLabels = map[string]string{
+	model.FindingUnusedVolume:    "Unused Volume",
+	model.FindingAttachedVolume:  "Volume on Stopped Instance",
+	model.FindingUnusedIP:        "Unused IP",
+	model.FindingStoppedInstance: "Stopped Instance",
+	model.FindingReservation:     "Reservation",
+}
+
+// DrawSnapshotDiffs displays run-over-run changes for each provider
+func DrawSnapshotDiffs(diffs []model.SnapshotDiff) {
+	fmt.Printf("\n%s\n", text.FgHiWhite.Sprint(" 🕑 CHANGES SINCE PREVIOUS RUN"))
+	fmt.Println(text.FgHiBlue.Sprint(" ------------------------------------------------"))
+
+	for _, diff := range diffs {
+		fmt.Printf("\n %s\n", text.FgHiCyan.Sprintf("%s (Account: %s)", strings.ToUpper(diff.Provider), diff.AccountID))
+
+		if diff.Baseline == nil {
+			fmt.Println(text.FgYellow.Sprint(" No earlier snapshot found; this run will be used as the baseline."))
+			continue
+		}
+
+		fmt.Printf(" Compared with snapshot %s (%s)\n",
+			text.FgBlue.Sprint(diff.Baseline.ID),
+			diff.Baseline.Timestamp.Local().Format("2006-01-02 15:04"))
+
+		switch diff.Kind {
+		case model.SnapshotKindWaste:
+			drawWasteDiffTable(diff)
+		case model.SnapshotKindCost:
+			drawCostDiffTable(diff)
+		}
+	}
+}
+
+func drawWasteDiffTable(diff model.SnapshotDiff) {
+	fmt.Printf(" %s new, %s resolved, %s persisting\n",
+		text.FgHiRed.Sprintf("%d", len(diff.New)),
+		text.FgHiGreen.Sprintf("%d", len(diff.Resolved)),
+		text.FgHiYellow.Sprintf("%d", len(diff.Persisting)))
+
+	if len(diff.New) == 0 && len(diff.Resolved) == 0 && len(diff.Persisting) == 0 {
+		return
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetStyle(table.StyleRounded)
+	t.SetTitle("Waste Changes")
+	t.AppendHeader(table.Row{"Change", "Category", "Resource ID", "Detail"})
+
+	appendFindings := func(label string, findings []model.WasteFinding) {
+		if len(findings) == 0 {
+			return
+		}
+		if t.Length() > 0 {
+			t.AppendSeparator()
+		}
+		for _, f := range findings {
+			t.AppendRow(table.Row{label, findingCategoryLabels[f.Category], f.ID, f.Detail})
+		}
+	}
+
+	appendFindings(text.FgHiRed.Sprint("NEW"), diff.New)
+	appendFindings(text.FgHiGreen.Sprint("RESOLVED"), diff.Resolved)
+	appendFindings(text.FgHiYellow.Sprint("PERSISTING"), diff.Persisting)
+
+	t.Render()
+}
+
+func drawCostDiffTable(diff model.SnapshotDiff) {
+	if diff.Current.Cost == nil || diff.Baseline.Cost == nil {
+		return
+	}
+
+	current := diff.Current.Cost
+	previous := diff.Baseline.Cost
+	currency := current.Currency
+
+	if previous.PeriodStart != current.PeriodStart {
+		fmt.Println(text.FgYellow.Sprintf(" Note: the previous snapshot covers %s to %s; month-to-date figures have reset since.",
+			previous.PeriodStart, previous.PeriodEnd))
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetStyle(table.StyleRounded)
+	t.SetTitle("Month-to-Date Cost Changes")
+	t.AppendHeader(table.Row{"Service", "Previous Run", "This Run", "Change"})
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 2, Align: text.AlignRight},
+		{Number: 3, Align: text.AlignRight},
+		{Number: 4, Align: text.AlignRight},
+	})
+
+	t.AppendRow(table.Row{
+		text.FgHiWhite.Sprint("Total Costs"),
+		fmt.Sprintf("%.2f %s", previous.CurrentTotal, currency),
+		fmt.Sprintf("%.2f %s", current.CurrentTotal, currency),
+		formatCostChange(current.CurrentTotal-previous.CurrentTotal, currency),
+	})
+	t.AppendSeparator()
+
+	for _, service := range diff.Services {
+		change := service.Current - service.Previous
+		if change == 0 {
+			continue
+		}
+		t.AppendRow(table.Row{
+			service.Name,
+			fmt.Sprintf("%.2f %s", service.Previous, currency),
+			fmt.Sprintf("%.2f %s", service.Current, currency),
+			formatCostChange(change, currency),
+		})
+	}
+
+	t.Render()
+}
+
+func formatCostChange(change float64, currency string) string {
+	if change > 0 {
+		return text.FgHiRed.Sprintf("+%.2f %s", change, currency)
+	}
+	if change < 0 {
+		return text.FgHiGreen.Sprintf("%.2f %s", change, currency)
+	}
+	return fmt.Sprintf("%.2f %s", change, currency)
+}
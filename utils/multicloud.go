@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/elC0mpa/aws-doctor/model"
@@ -11,13 +12,14 @@ import (
 	"github.com/jedib0t/go-pretty/v6/text"
 )
 
-// DrawMultiCloudCostTable displays cost comparison across multiple providers
-func DrawMultiCloudCostTable(results []model.ProviderCostResult) {
+// DrawMultiCloudCostTable displays cost comparison across multiple providers.
+// Totals are summed in the reporting currency using each result's converted amounts.
+func DrawMultiCloudCostTable(results []model.ProviderCostResult, reportingCurrency string) {
 	fmt.Printf("\n%s\n", text.FgHiWhite.Sprint(" 💰 MULTI-CLOUD COST DIAGNOSIS"))
 	fmt.Println(text.FgHiBlue.Sprint(" ------------------------------------------------"))
 
 	// Show summary table first
-	drawCostSummaryTable(results)
+	drawCostSummaryTable(results, reportingCurrency)
 
 	// Then show per-provider details
 	for _, result := range results {
@@ -26,6 +28,7 @@ func DrawMultiCloudCostTable(results []model.ProviderCostResult) {
 				text.FgHiRed.Sprint("⚠"),
 				text.FgHiYellow.Sprint(strings.ToUpper(result.Provider)),
 				text.FgRed.Sprint(result.Error.Error()))
+			DrawWarnings(result.Provider, result.Warnings)
 			continue
 		}
 
@@ -33,51 +36,79 @@ func DrawMultiCloudCostTable(results []model.ProviderCostResult) {
 			fmt.Printf("\n %s\n", text.FgHiCyan.Sprintf("📊 %s Details", strings.ToUpper(result.Provider)))
 			DrawCostTable(result.AccountID, result.LastTotalCost, result.CurrentTotalCost, result.LastMonthData, result.CurrentMonthData, "UnblendedCost")
 		}
+		DrawBudgetTable(result.Budgets)
+		DrawWarnings(result.Provider, result.Warnings)
 	}
 }
 
-func drawCostSummaryTable(results []model.ProviderCostResult) {
+func drawCostSummaryTable(results []model.ProviderCostResult, reportingCurrency string) {
+	// Converted columns are only needed when some provider bills in another currency
+	showConverted := false
+	for _, result := range results {
+		if result.Error == nil && result.Currency != "" && result.Currency != reportingCurrency {
+			showConverted = true
+			break
+		}
+	}
+
+	// The budget column is only shown when some provider has budgets
+	showBudgets := false
+	for _, result := range results {
+		if len(result.Budgets) > 0 {
+			showBudgets = true
+			break
+		}
+	}
+
 	tw := table.NewWriter()
 	tw.SetOutputMirror(os.Stdout)
 	tw.SetTitle("Cost Summary by Provider")
-	tw.AppendHeader(table.Row{"Provider", "Account/Project ID", "Last Month", "Current Month", "Difference"})
+	header := table.Row{"Provider", "Account/Project ID", "Last Month", "Current Month", "Difference"}
+	if showConverted {
+		header = append(header,
+			fmt.Sprintf("Last Month (%s)", reportingCurrency),
+			fmt.Sprintf("Current Month (%s)", reportingCurrency))
+	}
+	if showBudgets {
+		header = append(header, "Budgets")
+	}
+	tw.AppendHeader(header)
 	tw.SetStyle(table.StyleRounded)
 
 	tw.SetColumnConfigs([]table.ColumnConfig{
 		{Number: 3, Align: text.AlignRight},
 		{Number: 4, Align: text.AlignRight},
 		{Number: 5, Align: text.AlignRight},
+		{Number: 6, Align: text.AlignRight},
+		{Number: 7, Align: text.AlignRight},
 	})
 
 	var totalLast, totalCurrent float64
-	var currency string
+	var excluded []string
 
 	for _, result := range results {
 		if result.Error != nil {
-			tw.AppendRow(table.Row{
+			row := table.Row{
 				text.FgHiYellow.Sprint(strings.ToUpper(result.Provider)),
 				text.FgRed.Sprint("Error"),
 				"-",
 				"-",
 				text.FgRed.Sprint("Failed to retrieve"),
-			})
+			}
+			if showConverted {
+				row = append(row, "-", "-")
+			}
+			if showBudgets {
+				row = append(row, "-")
+			}
+			tw.AppendRow(row)
 			continue
 		}
 
-		lastCost := parseCost(result.LastTotalCost)
-		currentCost := parseCost(result.CurrentTotalCost)
+		lastCost, _ := ParseTotal(result.LastTotalCost)
+		currentCost, _ := ParseTotal(result.CurrentTotalCost)
 		diff := currentCost - lastCost
-
-		totalLast += lastCost
-		totalCurrent += currentCost
-
-		// Extract currency
-		if currency == "" {
-			parts := strings.Split(result.CurrentTotalCost, " ")
-			if len(parts) > 1 {
-				currency = parts[1]
-			}
-		}
+		currency := result.Currency
 
 		diffStr := fmt.Sprintf("%.2f %s", diff, currency)
 		currentStr := result.CurrentTotalCost
@@ -92,40 +123,85 @@ func drawCostSummaryTable(results []model.ProviderCostResult) {
 			currentStr = text.FgHiGreen.Sprint(result.CurrentTotalCost)
 		}
 
-		tw.AppendRow(table.Row{
+		row := table.Row{
 			providerColor.Sprint(strings.ToUpper(result.Provider)),
 			result.AccountID,
 			result.LastTotalCost,
 			currentStr,
 			diffStr,
-		})
+		}
+
+		if result.Converted != nil {
+			totalLast += result.Converted.LastTotalCost
+			totalCurrent += result.Converted.CurrentTotalCost
+			if showConverted {
+				row = append(row,
+					fmt.Sprintf("%.2f %s", result.Converted.LastTotalCost, reportingCurrency),
+					fmt.Sprintf("%.2f %s", result.Converted.CurrentTotalCost, reportingCurrency))
+			}
+		} else {
+			excluded = append(excluded, strings.ToUpper(result.Provider))
+			if showConverted {
+				row = append(row, text.FgRed.Sprint("n/a"), text.FgRed.Sprint("n/a"))
+			}
+		}
+		if showBudgets {
+			row = append(row, budgetSummary(result.Budgets))
+		}
+
+		tw.AppendRow(row)
 	}
 
 	// Add total row
 	if len(results) > 1 {
 		tw.AppendSeparator()
 		totalDiff := totalCurrent - totalLast
-		totalDiffStr := fmt.Sprintf("%.2f %s", totalDiff, currency)
-		totalCurrentStr := fmt.Sprintf("%.2f %s", totalCurrent, currency)
+		totalDiffStr := fmt.Sprintf("%.2f %s", totalDiff, reportingCurrency)
+		totalCurrentStr := fmt.Sprintf("%.2f %s", totalCurrent, reportingCurrency)
 
 		if totalDiff > 0 {
-			totalDiffStr = text.FgHiRed.Sprintf("+%.2f %s", totalDiff, currency)
-			totalCurrentStr = text.FgHiRed.Sprintf("%.2f %s", totalCurrent, currency)
+			totalDiffStr = text.FgHiRed.Sprintf("+%.2f %s", totalDiff, reportingCurrency)
+			totalCurrentStr = text.FgHiRed.Sprintf("%.2f %s", totalCurrent, reportingCurrency)
 		} else if totalDiff < 0 {
-			totalDiffStr = text.FgHiGreen.Sprintf("%.2f %s", totalDiff, currency)
-			totalCurrentStr = text.FgHiGreen.Sprintf("%.2f %s", totalCurrent, currency)
+			totalDiffStr = text.FgHiGreen.Sprintf("%.2f %s", totalDiff, reportingCurrency)
+			totalCurrentStr = text.FgHiGreen.Sprintf("%.2f %s", totalCurrent, reportingCurrency)
 		}
 
-		tw.AppendRow(table.Row{
-			text.FgHiWhite.Sprint("TOTAL"),
-			"",
-			fmt.Sprintf("%.2f %s", totalLast, currency),
-			totalCurrentStr,
-			totalDiffStr,
-		})
+		totalLabel := "TOTAL"
+		if len(excluded) > 0 {
+			totalLabel = "TOTAL*"
+		}
+
+		// With converted columns the totals go under them; a sum of the native
+		// amounts would mix currencies
+		row := table.Row{text.FgHiWhite.Sprint(totalLabel), ""}
+		if showConverted {
+			row = append(row, "", "", totalDiffStr, fmt.Sprintf("%.2f %s", totalLast, reportingCurrency), totalCurrentStr)
+		} else {
+			row = append(row, fmt.Sprintf("%.2f %s", totalLast, reportingCurrency), totalCurrentStr, totalDiffStr)
+		}
+		if showBudgets {
+			row = append(row, "")
+		}
+		tw.AppendRow(row)
 	}
 
 	tw.Render()
+
+	for _, result := range results {
+		if result.Error == nil && result.ConversionError != nil {
+			fmt.Printf(" %s %s excluded from total: %s\n",
+				text.FgHiYellow.Sprint("*"),
+				strings.ToUpper(result.Provider),
+				result.ConversionError.Error())
+		}
+	}
+
+	for _, result := range results {
+		if result.Error == nil {
+			DrawCacheNotice(result.Provider, result.CachedAt)
+		}
+	}
 }
 
 // DrawMultiCloudTrendChart displays trend analysis across multiple providers
@@ -145,7 +221,9 @@ func DrawMultiCloudTrendChart(results []model.ProviderCostResult) {
 		if len(result.TrendData) > 0 {
 			fmt.Printf("\n %s\n", text.FgHiCyan.Sprintf("📊 %s Trend (Account: %s)", strings.ToUpper(result.Provider), result.AccountID))
 			DrawTrendChart(result.AccountID, result.TrendData)
+			DrawCacheNotice(result.Provider, result.CachedAt)
 		}
+		DrawWarnings(result.Provider, result.Warnings)
 	}
 }
 
@@ -177,6 +255,7 @@ func DrawMultiCloudWasteTable(results []model.ProviderWasteResult) {
 			fmt.Printf("\n %s\n", text.FgHiCyan.Sprintf("🔍 %s Details (Account: %s)", strings.ToUpper(result.Provider), result.AccountID))
 			DrawWasteTable(result.AccountID, result.UnusedIPs, result.UnusedVolumes, result.AttachedVolumes, result.ExpiringReservations, result.StoppedInstances)
 		}
+		DrawWarnings(result.Provider, result.Warnings)
 	}
 }
 
@@ -228,6 +307,9 @@ func drawWasteSummaryTable(results []model.ProviderWasteResult) {
 		if volumes > 0 || ips > 0 || instances > 0 || ris > 0 {
 			status = text.FgHiRed.Sprint("⚠ Waste Found")
 		}
+		if len(result.Warnings) > 0 {
+			status += text.FgHiYellow.Sprint(" (partial)")
+		}
 
 		tw.AppendRow(table.Row{
 			text.FgHiCyan.Sprint(strings.ToUpper(result.Provider)),
@@ -269,14 +351,19 @@ func formatWasteCount(count int) string {
 	return text.FgHiRed.Sprintf("%d", count)
 }
 
-func parseCost(costStr string) float64 {
-	parts := strings.Split(costStr, " ")
-	if len(parts) > 0 {
-		var cost float64
-		fmt.Sscanf(parts[0], "%f", &cost)
-		return cost
+// ParseTotal splits a formatted total such as "123.45 USD" into its amount and
+// currency. A missing or malformed amount reads as 0.
+func ParseTotal(total string) (float64, string) {
+	parts := strings.Fields(total)
+	if len(parts) == 0 {
+		return 0, ""
+	}
+
+	amount, _ := strconv.ParseFloat(parts[0], 64)
+	if len(parts) < 2 {
+		return amount, ""
 	}
-	return 0
+	return amount, parts[1]
 }
 
 // SortProviderResults sorts results by provider name for consistent display
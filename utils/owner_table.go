@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// maxOwnerResources limits how many resource IDs are listed per owner
+const maxOwnerResources = 5
+
+// DrawOwnerWasteTable displays waste findings grouped by resolved owner
+func DrawOwnerWasteTable(groups []model.OwnerWaste) {
+	if len(groups) == 0 {
+		return
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetStyle(table.StyleRounded)
+	t.SetTitle("Waste by Owner")
+
+	t.AppendHeader(table.Row{"Owner", "Unused Volumes", "Volumes on Stopped", "Unused IPs", "Stopped Instances", "Resources"})
+
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 2, Align: text.AlignCenter},
+		{Number: 3, Align: text.AlignCenter},
+		{Number: 4, Align: text.AlignCenter},
+		{Number: 5, Align: text.AlignCenter},
+	})
+
+	for _, group := range groups {
+		resources := group.Resources
+		more := ""
+		if len(resources) > maxOwnerResources {
+			more = fmt.Sprintf("\n+%d more", len(resources)-maxOwnerResources)
+			resources = resources[:maxOwnerResources]
+		}
+
+		t.AppendRow(table.Row{
+			text.FgHiCyan.Sprint(group.Owner),
+			formatWasteCount(group.UnusedVolumes),
+			formatWasteCount(group.AttachedVolumes),
+			formatWasteCount(group.UnusedIPs),
+			formatWasteCount(group.StoppedInstances),
+			strings.Join(resources, "\n") + more,
+		})
+	}
+
+	fmt.Println()
+	t.Render()
+	fmt.Println()
+}
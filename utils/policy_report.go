@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// DrawPolicyReport lists the failed --fail-on rules and ends with the summary line
+func DrawPolicyReport(report model.PolicyReport) {
+	if len(report.Violations) > 0 {
+		fmt.Printf("\n%s\n", text.FgHiRed.Sprint(" ⛔ POLICY CHECK FAILED"))
+		for _, violation := range report.Violations {
+			fmt.Printf(" %s %s: %s (exit %d)\n",
+				text.FgHiRed.Sprint("✗"),
+				text.FgHiWhite.Sprint(violation.Rule),
+				violation.Message,
+				violation.ExitCode)
+		}
+	} else if report.ExitCode == model.ExitOK {
+		fmt.Printf("\n%s\n", text.FgHiGreen.Sprint(" ✅ POLICY CHECK PASSED"))
+	}
+
+	fmt.Println(FormatPolicySummary(report))
+}
+
+// FormatPolicySummary renders the report as a single line of key=value pairs
+// for CI logs, e.g.
+// cloud-doctor-summary status=fail exit=3 mode=cost providers=2 ... failed=budget
+func FormatPolicySummary(report model.PolicyReport) string {
+	status := "pass"
+	switch {
+	case report.ExitCode == model.ExitError:
+		status = "error"
+	case report.ExitCode != model.ExitOK:
+		status = "fail"
+	}
+
+	mode := report.Mode
+	if mode == "" {
+		mode = "none"
+	}
+
+	failed := make([]string, 0, len(report.Violations))
+	for _, violation := range report.Violations {
+		failed = append(failed, violation.Rule)
+	}
+	failedRules := "none"
+	if len(failed) > 0 {
+		failedRules = strings.Join(failed, ",")
+	}
+
+	return fmt.Sprintf("cloud-doctor-summary status=%s exit=%d mode=%s providers=%d provider_errors=%d budget_breaches=%d budget_warnings=%d waste=%d waste_cost_usd=%.2f new_waste=%d max_increase_pct=%.1f failed=%s",
+		status,
+		report.ExitCode,
+		mode,
+		report.Providers,
+		report.ProviderErrors,
+		report.BudgetBreaches,
+		report.BudgetWarnings,
+		report.WasteFindings,
+		report.WasteCost,
+		report.NewWasteFindings,
+		report.MaxIncreasePercent,
+		failedRules)
+}
@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// maxTagViolations limits how many violations are listed per provider
+const maxTagViolations = 20
+
+// DrawTagAuditReport displays tag compliance and untagged spend for each provider
+func DrawTagAuditReport(results []model.ProviderTagAudit, requiredKeys []string) {
+	fmt.Printf("\n%s\n", text.FgHiWhite.Sprint(" 🏷  TAG COMPLIANCE CHECKUP"))
+	fmt.Println(text.FgHiBlue.Sprint(" ------------------------------------------------"))
+	fmt.Printf(" Required keys: %s\n", strings.Join(requiredKeys, ", "))
+
+	drawTagComplianceTable(results)
+	drawTagSpendTable(results)
+
+	for _, result := range results {
+		if result.Error != nil {
+			fmt.Printf("\n %s %s: %s\n",
+				text.FgHiRed.Sprint("⚠"),
+				text.FgHiYellow.Sprint(strings.ToUpper(result.Provider)),
+				text.FgRed.Sprint(result.Error.Error()))
+			continue
+		}
+
+		if result.SpendError != nil {
+			fmt.Printf("\n %s %s %s\n",
+				text.FgHiYellow.Sprint("⚠"),
+				text.FgHiYellow.Sprint(strings.ToUpper(result.Provider)),
+				text.FgYellow.Sprintf("spend by tag unavailable: %v", result.SpendError))
+		}
+
+		if len(result.Violations) > 0 {
+			fmt.Printf("\n %s\n", text.FgHiCyan.Sprintf("🔍 %s Violations (Account: %s)", strings.ToUpper(result.Provider), result.AccountID))
+			drawTagViolationTable(result.Violations)
+		}
+		DrawWarnings(result.Provider, result.Warnings)
+	}
+}
+
+func drawTagComplianceTable(results []model.ProviderTagAudit) {
+	tw := table.NewWriter()
+	tw.SetOutputMirror(os.Stdout)
+	tw.SetTitle("Resource Compliance by Provider")
+	tw.AppendHeader(table.Row{"Provider", "Account/Project ID", "Resources", "Non-Compliant", "Compliance", "Status"})
+	tw.SetStyle(table.StyleRounded)
+
+	tw.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 3, Align: text.AlignCenter},
+		{Number: 4, Align: text.AlignCenter},
+		{Number: 5, Align: text.AlignCenter},
+		{Number: 6, Align: text.AlignCenter},
+	})
+
+	for _, result := range results {
+		if result.Error != nil {
+			tw.AppendRow(table.Row{
+				text.FgHiYellow.Sprint(strings.ToUpper(result.Provider)),
+				text.FgRed.Sprint("Error"),
+				"-",
+				"-",
+				"-",
+				text.FgRed.Sprint("⚠ Failed"),
+			})
+			continue
+		}
+
+		compliance := "-"
+		if result.ResourceCount > 0 {
+			compliant := result.ResourceCount - result.NonCompliantResources
+			compliance = fmt.Sprintf("%.1f%%", float64(compliant)/float64(result.ResourceCount)*100)
+		}
+
+		status := text.FgHiGreen.Sprint("✅ Compliant")
+		if result.NonCompliantResources > 0 {
+			status = text.FgHiRed.Sprint("⚠ Violations")
+		}
+		if len(result.Warnings) > 0 {
+			status += text.FgHiYellow.Sprint(" (partial)")
+		}
+
+		tw.AppendRow(table.Row{
+			text.FgHiCyan.Sprint(strings.ToUpper(result.Provider)),
+			result.AccountID,
+			result.ResourceCount,
+			formatWasteCount(result.NonCompliantResources),
+			compliance,
+			status,
+		})
+	}
+
+	tw.Render()
+}
+
+func drawTagSpendTable(results []model.ProviderTagAudit) {
+	hasSpend := false
+	for _, result := range results {
+		if result.Error == nil && len(result.Spend) > 0 {
+			hasSpend = true
+			break
+		}
+	}
+	if !hasSpend {
+		return
+	}
+
+	tw := table.NewWriter()
+	tw.SetOutputMirror(os.Stdout)
+	tw.SetTitle("Spend by Required Tag")
+	tw.AppendHeader(table.Row{"Provider", "Key", "Total", "Untagged", "Untagged %", "Invalid Value"})
+	tw.SetStyle(table.StyleRounded)
+
+	tw.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 3, Align: text.AlignRight},
+		{Number: 4, Align: text.AlignRight},
+		{Number: 5, Align: text.AlignCenter},
+		{Number: 6, Align: text.AlignRight},
+	})
+
+	for _, result := range results {
+		if result.Error != nil {
+			continue
+		}
+
+		for _, spend := range result.Spend {
+			percent := "-"
+			if spend.TotalCost > 0 {
+				share := spend.UntaggedCost / spend.TotalCost * 100
+				percent = fmt.Sprintf("%.1f%%", share)
+				if share > 0 {
+					percent = text.FgHiRed.Sprint(percent)
+				}
+			}
+
+			tw.AppendRow(table.Row{
+				text.FgHiCyan.Sprint(strings.ToUpper(result.Provider)),
+				spend.Key,
+				fmt.Sprintf("%.2f %s", spend.TotalCost, spend.Unit),
+				fmt.Sprintf("%.2f %s", spend.UntaggedCost, spend.Unit),
+				percent,
+				fmt.Sprintf("%.2f %s", spend.InvalidCost, spend.Unit),
+			})
+		}
+	}
+
+	tw.Render()
+}
+
+func drawTagViolationTable(violations []model.TagViolation) {
+	tw := table.NewWriter()
+	tw.SetOutputMirror(os.Stdout)
+	tw.SetStyle(table.StyleRounded)
+	tw.AppendHeader(table.Row{"Resource", "Type", "Key", "Problem"})
+
+	shown := violations
+	if len(shown) > maxTagViolations {
+		shown = shown[:maxTagViolations]
+	}
+
+	for _, violation := range shown {
+		problem := text.FgHiRed.Sprint("missing")
+		if violation.Reason == model.TagViolationInvalid {
+			problem = text.FgHiYellow.Sprintf("invalid value %q", violation.Value)
+		}
+
+		tw.AppendRow(table.Row{violation.ResourceID, violation.ResourceType, violation.Key, problem})
+	}
+
+	if len(violations) > len(shown) {
+		tw.AppendFooter(table.Row{fmt.Sprintf("+%d more", len(violations)-len(shown)), "", "", ""})
+	}
+
+	tw.Render()
+}
+
+func SortProviderTagAudits(results []model.ProviderTagAudit) {
+	providerOrder := map[string]int{"aws": 1, "gcp": 2, "azure": 3}
+	sort.Slice(results, func(i, j int) bool {
+		return providerOrder[results[i].Provider] < providerOrder[results[j].Provider]
+	})
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elC0mpa/aws-doctor/model"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// DrawWarnings lists the problems that made a provider's results partial
+func DrawWarnings(provider string, warnings []model.Warning) {
+	if len(warnings) == 0 {
+		return
+	}
+
+	fmt.Printf("\n %s %s %s\n",
+		text.FgHiYellow.Sprint("⚠"),
+		text.FgHiYellow.Sprint(strings.ToUpper(provider)),
+		text.FgYellow.Sprintf("results may be incomplete (%d warning(s)):", len(warnings)))
+
+	for _, warning := range warnings {
+		fmt.Printf("   %s %s\n", text.FgHiBlack.Sprintf("[%s]", warning.Kind), warning.String())
+	}
+}
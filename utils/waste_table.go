@@ -205,7 +205,13 @@ func populateStoppedInstanceRows(instances []model.StoppedInstance) []table.Row
 	var rows []table.Row
 
 	for _, instance := range instances {
-		timeInfo := fmt.Sprintf("%d days ago", instance.StoppedDays)
+		timeInfo := "unknown"
+		if instance.StoppedDays >= 0 {
+			timeInfo = fmt.Sprintf("%d days ago", instance.StoppedDays)
+			if instance.StoppedAtEstimated {
+				timeInfo = fmt.Sprintf("~%d days ago (estimated)", instance.StoppedDays)
+			}
+		}
 
 		rows = append(rows, table.Row{
 			"",